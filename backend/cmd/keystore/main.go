@@ -0,0 +1,76 @@
+// Command keystore manages the encrypted local secrets keystore used when
+// SECRETS_SOURCES includes "keystore".
+//
+//	SECRETS_KEYSTORE_PASSPHRASE=... go run ./cmd/keystore set SHOTSTACK_API_KEY < key.txt
+//	SECRETS_KEYSTORE_PASSPHRASE=... go run ./cmd/keystore list
+//	SECRETS_KEYSTORE_PASSPHRASE=... go run ./cmd/keystore delete SHOTSTACK_API_KEY
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dealshare/hacathon/backend/internal/secrets"
+)
+
+func main() {
+	defaultPath := os.Getenv("SECRETS_KEYSTORE")
+	if defaultPath == "" {
+		defaultPath = "./data/secrets.keystore"
+	}
+	path := flag.String("file", defaultPath, "keystore file")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: keystore [-file path] set KEY | delete KEY | list\n")
+		fmt.Fprintf(os.Stderr, "The passphrase is read from SECRETS_KEYSTORE_PASSPHRASE; set reads the value from stdin.\n")
+	}
+	flag.Parse()
+
+	passphrase := os.Getenv("SECRETS_KEYSTORE_PASSPHRASE")
+	if passphrase == "" {
+		fail("SECRETS_KEYSTORE_PASSPHRASE is required")
+	}
+
+	ks, err := secrets.OpenKeystore(*path, passphrase)
+	if err != nil {
+		fail(err.Error())
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		for _, key := range ks.Keys() {
+			fmt.Println(key)
+		}
+		return
+	case args[0] == "set" && len(args) == 2:
+		value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		value = strings.TrimSpace(value)
+		if value == "" {
+			fail(fmt.Sprintf("no value on stdin for %s (%v)", args[1], err))
+		}
+		ks.Set(args[1], value)
+	case args[0] == "delete" && len(args) == 2:
+		ks.Delete(args[1])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := ks.Save(passphrase); err != nil {
+		fail(err.Error())
+	}
+	fmt.Printf("✅ %s updated (%d secrets)\n", *path, len(ks.Keys()))
+}
+
+func fail(message string) {
+	fmt.Fprintf(os.Stderr, "❌ %s\n", message)
+	os.Exit(1)
+}
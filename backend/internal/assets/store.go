@@ -0,0 +1,185 @@
+package assets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/dealshare/hacathon/backend/internal/media"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/storage"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned for an asset that does not exist in the project
+var ErrNotFound = errors.New("asset not found")
+
+// Store records the files each project is made of, and what each was made from
+type Store struct {
+	db       *gorm.DB
+	storage  storage.Storage
+	cacheDir string // Where files from remote backends are downloaded to be described
+}
+
+// NewStore creates an asset store over the given blob storage
+func NewStore(db *gorm.DB, store storage.Storage, cacheDir string) *Store {
+	return &Store{db: db, storage: store, cacheDir: cacheDir}
+}
+
+// Record describes the stored file at asset.StorageKey (type, size, dimensions,
+// duration and checksum) and saves the asset, linked to the project's assets for
+// parentKeys. Each file is recorded once per project; recording it again loads
+// the existing asset into asset.
+func (s *Store) Record(ctx context.Context, asset *models.Asset, parentKeys ...string) error {
+	key, ok := storage.KeyFor(s.storage, asset.StorageKey, s.cacheDir)
+	if !ok {
+		return fmt.Errorf("%s is not in storage", asset.StorageKey)
+	}
+	asset.StorageKey = key
+
+	if existing, err := s.byKey(asset.ProjectID, key); err == nil {
+		*asset = *existing
+		return nil
+	}
+
+	if err := s.describe(ctx, asset); err != nil {
+		return fmt.Errorf("failed to describe %s: %v", key, err)
+	}
+
+	asset.ParentIDs = []string{}
+	for _, parentKey := range parentKeys {
+		if parentKey, ok = storage.KeyFor(s.storage, parentKey, s.cacheDir); !ok {
+			continue
+		}
+		parent, err := s.byKey(asset.ProjectID, parentKey)
+		if err != nil {
+			log.Printf("⚠️  Asset %s: parent %s is not recorded", key, parentKey)
+			continue
+		}
+		asset.ParentIDs = append(asset.ParentIDs, parent.ID)
+	}
+
+	if err := s.db.Create(asset).Error; err != nil {
+		// Another run may have recorded the same file meanwhile
+		if existing, lookupErr := s.byKey(asset.ProjectID, key); lookupErr == nil {
+			*asset = *existing
+			return nil
+		}
+		return fmt.Errorf("failed to save asset: %v", err)
+	}
+	return nil
+}
+
+// RecordInputs records the project's uploaded product image and person media,
+// which generated assets name as their parents
+func (s *Store) RecordInputs(ctx context.Context, project *models.Project) error {
+	inputs := []struct{ kind, ref string }{
+		{models.AssetKindProductImage, project.ProductImagePath},
+		{models.AssetKindPersonMedia, project.PersonMediaPath},
+	}
+	for _, input := range inputs {
+		if input.ref == "" {
+			continue
+		}
+		asset := &models.Asset{ProjectID: project.ID, Kind: input.kind, StorageKey: input.ref}
+		if err := s.Record(ctx, asset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the project's assets in the order they were made, optionally of one kind only
+func (s *Store) List(projectID, kind string) ([]models.Asset, error) {
+	query := s.db.Where("project_id = ?", projectID).Order("created_at ASC")
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	var assets []models.Asset
+	if err := query.Find(&assets).Error; err != nil {
+		return nil, err
+	}
+	return assets, nil
+}
+
+// Get loads one of the project's assets
+func (s *Store) Get(projectID, id string) (*models.Asset, error) {
+	var asset models.Asset
+	if err := s.db.First(&asset, "id = ? AND project_id = ?", id, projectID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// Latest loads the project's most recently recorded asset of a kind
+func (s *Store) Latest(projectID, kind string) (*models.Asset, error) {
+	var asset models.Asset
+	err := s.db.Where("project_id = ? AND kind = ?", projectID, kind).Order("created_at DESC").First(&asset).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// Parents loads the assets an asset was made from
+func (s *Store) Parents(asset *models.Asset) ([]models.Asset, error) {
+	parents := []models.Asset{}
+	if len(asset.ParentIDs) == 0 {
+		return parents, nil
+	}
+	err := s.db.Where("project_id = ? AND id IN ?", asset.ProjectID, asset.ParentIDs).
+		Order("created_at ASC").Find(&parents).Error
+	return parents, err
+}
+
+func (s *Store) byKey(projectID, key string) (*models.Asset, error) {
+	var asset models.Asset
+	if err := s.db.First(&asset, "project_id = ? AND storage_key = ?", projectID, key).Error; err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// describe fills in what the file's contents say about it. Images and videos are
+// inspected; anything else, such as a website's HTML, only gets a size and checksum.
+func (s *Store) describe(ctx context.Context, asset *models.Asset) error {
+	path, err := storage.Fetch(ctx, s.storage, asset.StorageKey, s.cacheDir)
+	if err != nil {
+		return err
+	}
+
+	if info, err := media.Inspect(path, media.Limits{}, media.KindImage, media.KindVideo); err == nil {
+		asset.MimeType = info.MIME
+		asset.SizeBytes = info.Size
+		asset.Width, asset.Height = info.Width, info.Height
+		asset.DurationSeconds = info.Duration
+		asset.Checksum = info.SHA256
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return err
+	}
+	asset.MimeType = storage.ContentType(asset.StorageKey)
+	asset.SizeBytes = size
+	asset.Checksum = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
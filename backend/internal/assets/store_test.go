@@ -0,0 +1,146 @@
+package assets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/storage"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestStore returns an asset store over a fresh database and local storage
+func newTestStore(t *testing.T) (*Store, *storage.Local) {
+	dir := t.TempDir()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "assets.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Asset{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	local := storage.NewLocal(map[string]string{
+		storage.PrefixUploads:  filepath.Join(dir, "uploads"),
+		storage.PrefixVideos:   filepath.Join(dir, "videos"),
+		storage.PrefixWebsites: filepath.Join(dir, "websites"),
+	}, nil)
+	return NewStore(db, local, filepath.Join(dir, "cache")), local
+}
+
+func putPNG(t *testing.T, local *storage.Local, key string, width, height int) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	if err := local.Put(context.Background(), key, &buf, int64(buf.Len()), "image/png"); err != nil {
+		t.Fatalf("put %s: %v", key, err)
+	}
+}
+
+func TestRecordDescribesAndLinks(t *testing.T) {
+	ctx := context.Background()
+	store, local := newTestStore(t)
+	putPNG(t, local, "uploads/p1/product.png", 640, 480)
+	putPNG(t, local, "uploads/p1/person.png", 300, 400)
+
+	project := &models.Project{ID: "p1", ProductImagePath: "uploads/p1/product.png", PersonMediaPath: "uploads/p1/person.png"}
+	if err := store.RecordInputs(ctx, project); err != nil {
+		t.Fatalf("RecordInputs() error = %v", err)
+	}
+
+	// A converted copy, recorded by its local path as older code paths do
+	putPNG(t, local, "videos/p1/person_converted.png", 300, 400)
+	path, _ := local.Path("videos/p1/person_converted.png")
+	converted := &models.Asset{ProjectID: "p1", Kind: models.AssetKindConvertedImage, StorageKey: path}
+	if err := store.Record(ctx, converted, "uploads/p1/person.png", "uploads/p1/missing.png"); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if converted.StorageKey != "videos/p1/person_converted.png" {
+		t.Errorf("StorageKey = %q, want the path resolved to its key", converted.StorageKey)
+	}
+	if converted.MimeType != "image/png" || converted.Width != 300 || converted.Height != 400 || converted.SizeBytes == 0 || len(converted.Checksum) != 64 {
+		t.Errorf("Record() described the image as %+v", converted)
+	}
+
+	parents, err := store.Parents(converted)
+	if err != nil {
+		t.Fatalf("Parents() error = %v", err)
+	}
+	if len(parents) != 1 || parents[0].Kind != models.AssetKindPersonMedia {
+		t.Errorf("Parents() = %+v, want the person upload only", parents)
+	}
+
+	if _, err := store.Get("p2", converted.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() from another project error = %v, want ErrNotFound", err)
+	}
+	if err := store.Record(ctx, &models.Asset{ProjectID: "p1", StorageKey: "/tmp/elsewhere.png"}); err == nil {
+		t.Errorf("Record() accepted a file outside storage")
+	}
+}
+
+func TestRecordDedupes(t *testing.T) {
+	ctx := context.Background()
+	store, local := newTestStore(t)
+	putPNG(t, local, "uploads/p1/product.png", 640, 480)
+
+	first := &models.Asset{ProjectID: "p1", Kind: models.AssetKindProductImage, StorageKey: "uploads/p1/product.png"}
+	if err := store.Record(ctx, first); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	again := &models.Asset{ProjectID: "p1", Kind: models.AssetKindProductImage, StorageKey: "uploads/p1/product.png"}
+	if err := store.Record(ctx, again); err != nil {
+		t.Fatalf("Record() again error = %v", err)
+	}
+	if again.ID != first.ID {
+		t.Errorf("recording the same file twice made a second asset")
+	}
+
+	// The same file in another project is that project's own asset
+	other := &models.Asset{ProjectID: "p2", Kind: models.AssetKindProductImage, StorageKey: "uploads/p1/product.png"}
+	if err := store.Record(ctx, other); err != nil || other.ID == first.ID {
+		t.Errorf("Record() in another project = %s, %v", other.ID, err)
+	}
+
+	all, _ := store.List("p1", "")
+	if len(all) != 1 {
+		t.Errorf("List() = %d assets, want 1", len(all))
+	}
+}
+
+func TestListByKind(t *testing.T) {
+	ctx := context.Background()
+	store, local := newTestStore(t)
+	putPNG(t, local, "uploads/p1/product.png", 640, 480)
+	page := "<html><body>Kettle</body></html>"
+	local.Put(ctx, "websites/p1/index.html", strings.NewReader(page), -1, "text/html")
+
+	store.Record(ctx, &models.Asset{ProjectID: "p1", Kind: models.AssetKindProductImage, StorageKey: "uploads/p1/product.png"})
+	website := &models.Asset{ProjectID: "p1", Kind: models.AssetKindWebsite, StorageKey: "websites/p1/index.html"}
+	if err := store.Record(ctx, website, "uploads/p1/product.png"); err != nil {
+		t.Fatalf("Record(website) error = %v", err)
+	}
+	// Pages are not media, so they only get a size and checksum
+	if website.SizeBytes != int64(len(page)) || len(website.Checksum) != 64 || !strings.HasPrefix(website.MimeType, "text/html") || website.Width != 0 {
+		t.Errorf("Record(website) = %+v", website)
+	}
+
+	all, err := store.List("p1", "")
+	if err != nil || len(all) != 2 || all[0].Kind != models.AssetKindProductImage {
+		t.Errorf("List() = %+v, %v, want the image first", all, err)
+	}
+	websites, _ := store.List("p1", models.AssetKindWebsite)
+	if len(websites) != 1 || websites[0].ID != website.ID || len(websites[0].ParentIDs) != 1 {
+		t.Errorf("List(website) = %+v", websites)
+	}
+	if none, _ := store.List("p1", models.AssetKindFinalVideo); len(none) != 0 {
+		t.Errorf("List(final_video) = %+v, want none", none)
+	}
+}
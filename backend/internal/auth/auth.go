@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/dealshare/hacathon/backend/internal/config"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// apiTokenPrefix tells API tokens apart from session JWTs
+const apiTokenPrefix = "hk_"
+
+// MinPasswordLength is the shortest password accepted for new accounts
+const MinPasswordLength = 8
+
+// userKey is the gin context key holding the authenticated *models.User
+const userKey = "auth.user"
+
+// ticketTTL is how long a ticket can be used to open its stream
+const ticketTTL = time.Minute
+
+// Authenticator issues session tokens and resolves the user behind a request
+type Authenticator struct {
+	db        *gorm.DB
+	secret    []byte
+	ttl       time.Duration
+	dummyHash []byte // Compared against for unknown emails, so they take as long as wrong passwords
+}
+
+// NewAuthenticator signs sessions with JWT_SECRET. Without one, a random secret is
+// generated, which signs everybody out whenever the server restarts.
+func NewAuthenticator(db *gorm.DB, cfg *config.Config) (*Authenticator, error) {
+	secret := []byte(cfg.JWTSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate session secret: %v", err)
+		}
+		log.Printf("⚠️  JWT_SECRET is not set; using a random secret, sessions will not survive a restart")
+	} else if len(secret) < 32 {
+		return nil, fmt.Errorf("JWT_SECRET must be at least 32 bytes")
+	}
+
+	dummyHash, err := bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Authenticator{
+		db:        db,
+		secret:    secret,
+		ttl:       time.Duration(cfg.SessionTTLHours) * time.Hour,
+		dummyHash: dummyHash,
+	}, nil
+}
+
+// HashPassword hashes a password for storage
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	if len(password) > 72 {
+		return "", fmt.Errorf("password must be at most 72 bytes")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %v", err)
+	}
+	return string(hash), nil
+}
+
+// Login checks an email and password and returns the user
+func (a *Authenticator) Login(email, password string) (*models.User, error) {
+	var user models.User
+	err := a.db.First(&user, "email = ?", strings.ToLower(strings.TrimSpace(email))).Error
+	if err != nil || user.PasswordHash == "" {
+		bcrypt.CompareHashAndPassword(a.dummyHash, []byte(password))
+		return nil, errors.New("invalid email or password")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, errors.New("invalid email or password")
+	}
+	return &user, nil
+}
+
+// IssueSession creates a session token for the user
+func (a *Authenticator) IssueSession(user *models.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(a.ttl)
+	token, err := signJWT(Claims{Subject: user.ID, IssuedAt: now.Unix(), ExpiresAt: expiresAt.Unix()}, a.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// IssueTicket creates a short-lived token that authenticates the user for GET
+// requests to one path only. Clients that cannot set headers, such as EventSource,
+// pass it as ?ticket=; sessions and API tokens are never accepted in URLs, which
+// end up in access logs.
+func (a *Authenticator) IssueTicket(user *models.User, path string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ticketTTL)
+	token, err := signJWT(Claims{Subject: user.ID, IssuedAt: now.Unix(), ExpiresAt: expiresAt.Unix(), Path: path}, a.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// CreateAPIToken creates a named API token for the user. The token itself is only
+// returned here; the database keeps its hash.
+func (a *Authenticator) CreateAPIToken(user *models.User, name string) (string, *models.APIToken, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("failed to generate token: %v", err)
+	}
+	token := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	record := &models.APIToken{UserID: user.ID, Name: name, TokenHash: hashToken(token)}
+	if err := a.db.Create(record).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to store token: %v", err)
+	}
+	return token, record, nil
+}
+
+// Middleware authenticates the request with a session JWT or an API token from
+// "Authorization: Bearer ...". GET requests may pass a ticket from IssueTicket as
+// ?ticket= instead, for the path it was issued for.
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user *models.User
+		var err error
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		ticket := c.Query("ticket")
+		switch {
+		case token != "":
+			user, err = a.authenticate(token)
+		case ticket != "" && c.Request.Method == "GET":
+			user, err = a.authenticateTicket(ticket, c.Request.URL.Path)
+		default:
+			c.AbortWithStatusJSON(401, gin.H{"error": "Authentication required"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(401, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(userKey, user)
+		c.Next()
+	}
+}
+
+func (a *Authenticator) authenticate(token string) (*models.User, error) {
+	var userID string
+	if strings.HasPrefix(token, apiTokenPrefix) {
+		var record models.APIToken
+		if err := a.db.First(&record, "token_hash = ?", hashToken(token)).Error; err != nil {
+			return nil, ErrInvalidToken
+		}
+		now := time.Now()
+		a.db.Model(&record).Update("last_used_at", &now)
+		userID = record.UserID
+	} else {
+		claims, err := verifyJWT(token, a.secret, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if claims.Path != "" {
+			return nil, ErrInvalidToken // A ticket, not a session
+		}
+		userID = claims.Subject
+	}
+	return a.user(userID)
+}
+
+// authenticateTicket accepts only a ticket issued for the path
+func (a *Authenticator) authenticateTicket(ticket, path string) (*models.User, error) {
+	if strings.HasPrefix(ticket, apiTokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+	claims, err := verifyJWT(ticket, a.secret, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Path == "" || claims.Path != path {
+		return nil, ErrInvalidToken
+	}
+	return a.user(claims.Subject)
+}
+
+func (a *Authenticator) user(userID string) (*models.User, error) {
+	var user models.User
+	if err := a.db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, ErrInvalidToken
+	}
+	return &user, nil
+}
+
+// RequireRole rejects authenticated users whose role does not include the given one
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := CurrentUser(c)
+		if user == nil || !user.HasRole(role) {
+			c.AbortWithStatusJSON(403, gin.H{"error": fmt.Sprintf("This action requires the %s role", role)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentUser returns the user authenticated by Middleware
+func CurrentUser(c *gin.Context) *models.User {
+	if user, ok := c.Get(userKey); ok {
+		return user.(*models.User)
+	}
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/dealshare/hacathon/backend/internal/config"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const eventsPath = "/api/v1/projects/p1/events"
+
+func newTestAuthenticator(t *testing.T) (*Authenticator, *models.User) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "auth.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.APIToken{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	a, err := NewAuthenticator(db, &config.Config{JWTSecret: string(testSecret), SessionTTLHours: 1})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	user := &models.User{Email: "editor@example.com", Role: models.RoleEditor}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return a, user
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a, user := newTestAuthenticator(t)
+
+	session, _, err := a.IssueSession(user)
+	if err != nil {
+		t.Fatalf("IssueSession: %v", err)
+	}
+	ticket, _, err := a.IssueTicket(user, eventsPath)
+	if err != nil {
+		t.Fatalf("IssueTicket: %v", err)
+	}
+	apiToken, _, err := a.CreateAPIToken(user, "ci")
+	if err != nil {
+		t.Fatalf("CreateAPIToken: %v", err)
+	}
+	stranger := &models.User{ID: "deleted-user"}
+	orphanSession, _, _ := a.IssueSession(stranger)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		bearer   string
+		ticket   string
+		wantCode int
+	}{
+		{"session header", "GET", "/api/v1/projects", session, "", 200},
+		{"api token header", "POST", "/api/v1/projects", apiToken, "", 200},
+		{"unknown api token", "GET", "/api/v1/projects", apiTokenPrefix + "nope", "", 401},
+		{"session of deleted user", "GET", "/api/v1/projects", orphanSession, "", 401},
+		{"no credentials", "GET", "/api/v1/projects", "", "", 401},
+		{"ticket for its path", "GET", eventsPath, "", ticket, 200},
+		{"ticket for another path", "GET", "/api/v1/projects", "", ticket, 401},
+		{"ticket on POST", "POST", eventsPath, "", ticket, 401},
+		{"ticket as bearer", "GET", eventsPath, ticket, "", 401},
+		{"session in query", "GET", eventsPath, "", session, 401},
+		{"api token in query", "GET", eventsPath, "", apiToken, 401},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(a.Middleware())
+			router.Handle(tt.method, tt.path, func(c *gin.Context) {
+				if CurrentUser(c).ID != user.ID {
+					t.Errorf("CurrentUser() = %s, want %s", CurrentUser(c).ID, user.ID)
+				}
+				c.Status(200)
+			})
+
+			target := tt.path
+			if tt.ticket != "" {
+				target += "?ticket=" + tt.ticket
+			}
+			req := httptest.NewRequest(tt.method, target, nil)
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		role     string
+		require  string
+		wantCode int
+	}{
+		{models.RoleViewer, models.RoleViewer, 200},
+		{models.RoleViewer, models.RoleEditor, 403},
+		{models.RoleEditor, models.RoleReviewer, 403},
+		{models.RoleReviewer, models.RoleEditor, 200},
+		{models.RolePublisher, models.RolePublisher, 200},
+		{"admin", models.RoleViewer, 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" needs "+tt.require, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(c *gin.Context) {
+				c.Set(userKey, &models.User{Role: tt.role})
+			}, RequireRole(tt.require), func(c *gin.Context) { c.Status(200) })
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{"short", true},
+		{"password123", false},
+		{string(make([]byte, 73)), true},
+	}
+	for _, tt := range tests {
+		_, err := HashPassword(tt.password)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("HashPassword(len %d) error = %v, wantErr %v", len(tt.password), err, tt.wantErr)
+		}
+	}
+}
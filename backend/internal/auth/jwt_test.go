@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestVerifyJWT(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	valid := Claims{Subject: "user-1", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Hour).Unix()}
+
+	sign := func(claims Claims, secret []byte) string {
+		token, err := signJWT(claims, secret)
+		if err != nil {
+			t.Fatalf("signJWT: %v", err)
+		}
+		return token
+	}
+	withHeader := func(token, header string) string {
+		parts := strings.Split(token, ".")
+		unsigned := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + parts[1]
+		return unsigned + "." + jwtSignature(unsigned, testSecret)
+	}
+	withPayload := func(token, payload string) string {
+		parts := strings.Split(token, ".")
+		return parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + parts[2]
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"valid", sign(valid, testSecret), false},
+		{"wrong secret", sign(valid, []byte("another-secret-another-secret-xx")), true},
+		{"tampered payload", withPayload(sign(valid, testSecret), `{"sub":"admin","iat":0,"exp":9999999999}`), true},
+		{"expired", sign(Claims{Subject: "user-1", ExpiresAt: now.Unix()}, testSecret), true},
+		{"no subject", sign(Claims{ExpiresAt: now.Add(time.Hour).Unix()}, testSecret), true},
+		{"alg none", withHeader(sign(valid, testSecret), `{"alg":"none","typ":"JWT"}`), true},
+		{"alg HS512", withHeader(sign(valid, testSecret), `{"alg":"HS512","typ":"JWT"}`), true},
+		{"missing signature", strings.Join(strings.Split(sign(valid, testSecret), ".")[:2], ".") + ".", true},
+		{"two parts", "a.b", true},
+		{"empty", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifyJWT(tt.token, testSecret, now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("verifyJWT() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyJWT() error = %v", err)
+			}
+			if *claims != valid {
+				t.Errorf("verifyJWT() claims = %+v, want %+v", *claims, valid)
+			}
+		})
+	}
+}
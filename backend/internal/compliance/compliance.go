@@ -0,0 +1,181 @@
+// Package compliance checks generated marketing copy against rule packs for the
+// product's category: banned phrases, claims that need evidence, prices that do
+// not match the product's, and missing disclaimers. Hard violations block
+// publication; warnings are shown to reviewers.
+package compliance
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Severities
+const (
+	SeverityHard    = "hard"    // Blocks publication until the copy is changed
+	SeverityWarning = "warning" // Needs a reviewer's judgement, e.g. evidence on file
+)
+
+// Finding kinds
+const (
+	KindBannedPhrase      = "banned_phrase"
+	KindUnverifiableClaim = "unverifiable_claim"
+	KindPriceMismatch     = "price_mismatch"
+	KindMissingDisclaimer = "missing_disclaimer"
+)
+
+// Sources of checked copy. Website features are numbered, e.g. "website_feature_2".
+const (
+	SourceScript  = "script"
+	SourceCaption = "caption"
+	SourceFeature = "website_feature"
+)
+
+// Finding is one problem found in a piece of copy
+type Finding struct {
+	Source   string `json:"source"`            // Which copy, e.g. "script" or "website_feature_2"
+	Kind     string `json:"kind"`              // One of the Kind constants
+	Severity string `json:"severity"`          // SeverityHard or SeverityWarning
+	Rule     string `json:"rule"`              // The rule's ID, e.g. "food.disease_claim"
+	Excerpt  string `json:"excerpt,omitempty"` // The offending words
+	Message  string `json:"message"`           // Why it is a problem and what to do instead
+}
+
+// Text is a piece of copy to check
+type Text struct {
+	Source string
+	Text   string
+}
+
+// Product is what the copy is checked against
+type Product struct {
+	Category string // The project's category, e.g. "food"; picks the rule pack
+	Price    string // The project's price, e.g. "$9.99"; empty if it has none
+}
+
+// Report is the result of a check
+type Report struct {
+	Pack     string    `json:"pack"` // Rule pack used: "food", "cosmetics", "electronics" or "general"
+	Findings []Finding `json:"findings"`
+}
+
+// Blocked reports whether the copy has hard violations
+func (r Report) Blocked() bool {
+	for _, finding := range r.Findings {
+		if finding.Severity == SeverityHard {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns a *BlockedError if the copy has hard violations, or nil
+func (r Report) Err() error {
+	if !r.Blocked() {
+		return nil
+	}
+	return &BlockedError{Report: r}
+}
+
+// BlockedError is returned when copy must not be published as it is
+type BlockedError struct {
+	Report Report
+}
+
+func (e *BlockedError) Error() string {
+	var reasons []string
+	for _, finding := range e.Report.Findings {
+		if finding.Severity == SeverityHard {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", finding.Source, finding.Message))
+		}
+	}
+	return fmt.Sprintf("copy breaks %d compliance rule(s): %s", len(reasons), strings.Join(reasons, "; "))
+}
+
+// Check runs the rule pack for the product's category over each text. Disclaimers
+// are looked for in the same text as the claim that needs them.
+func Check(product Product, texts ...Text) Report {
+	pack := packFor(product.Category)
+	report := Report{Pack: pack.name, Findings: []Finding{}}
+	for _, text := range texts {
+		if strings.TrimSpace(text.Text) == "" {
+			continue
+		}
+		report.Findings = append(report.Findings, pack.check(text)...)
+		report.Findings = append(report.Findings, checkPrices(product.Price, text)...)
+	}
+	return report
+}
+
+// phraseRule flags copy matching pattern, unless it also matches unless
+type phraseRule struct {
+	id       string // "<pack>.<name>", e.g. "food.disease_claim"
+	kind     string
+	severity string
+	pattern  *regexp.Regexp
+	unless   *regexp.Regexp // Wording that makes the claim acceptable; nil for none
+	message  string
+}
+
+// disclaimerRule requires a disclaimer in copy that makes a claim
+type disclaimerRule struct {
+	id         string
+	severity   string
+	trigger    *regexp.Regexp // The claim
+	disclaimer *regexp.Regexp // Accepted wordings of the disclaimer
+	message    string         // Says which disclaimer to add
+}
+
+// pack is the rules for one group of categories
+type pack struct {
+	name        string
+	categories  []string
+	phrases     []phraseRule
+	disclaimers []disclaimerRule
+}
+
+func (p pack) check(text Text) []Finding {
+	var findings []Finding
+	for _, rule := range p.phrases {
+		match := rule.pattern.FindString(text.Text)
+		if match == "" || (rule.unless != nil && rule.unless.MatchString(text.Text)) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Source: text.Source, Kind: rule.kind, Severity: rule.severity,
+			Rule: rule.id, Excerpt: match, Message: rule.message,
+		})
+	}
+	for _, rule := range p.disclaimers {
+		match := rule.trigger.FindString(text.Text)
+		if match == "" || rule.disclaimer.MatchString(text.Text) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Source: text.Source, Kind: KindMissingDisclaimer, Severity: rule.severity,
+			Rule: rule.id, Excerpt: match, Message: rule.message,
+		})
+	}
+	return findings
+}
+
+// packFor picks the rule pack for a category; categories without one get the
+// general rules only
+func packFor(category string) pack {
+	category = strings.ToLower(strings.TrimSpace(category))
+	for _, p := range packs {
+		for _, c := range p.categories {
+			if c == category {
+				return p.with(generalPack)
+			}
+		}
+	}
+	return generalPack
+}
+
+// with adds another pack's rules to the pack's own
+func (p pack) with(other pack) pack {
+	p.phrases = append(append([]phraseRule{}, p.phrases...), other.phrases...)
+	p.disclaimers = append(append([]disclaimerRule{}, p.disclaimers...), other.disclaimers...)
+	return p
+}
@@ -0,0 +1,102 @@
+package compliance
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		category  string
+		text      string
+		wantRules []string
+		blocked   bool
+	}{
+		{"clean copy", "food", "Crunchy oat bars with honey and almonds.", nil, false},
+		{"disease claim", "food", "This tea cures diabetes!", []string{"food.disease_claim"}, true},
+		{"disease claim a few words on", "food", "It prevents the common cold.", []string{"food.disease_claim"}, true},
+		{"immunity", "beverages", "Boosts your immune system every morning.", []string{"food.immunity"}, true},
+		{"detox is a warning", "food", "A gentle detox for busy days.", []string{"food.detox"}, false},
+		{"health benefit without disclaimer", "snacks", "Supports healthy digestion.", []string{"food.health_benefit_disclaimer"}, false},
+		{"health benefit with disclaimer", "snacks", "Supports healthy digestion. Enjoy as part of a balanced diet.", nil, false},
+		{"category rules only for their category", "electronics", "This tea cures diabetes!", nil, false},
+		{"category is case-insensitive", "  Food ", "This tea cures diabetes!", []string{"food.disease_claim"}, true},
+		{"general rules everywhere", "furniture", "The world's best chair, 100% guaranteed results.", []string{"general.absolute_guarantee", "general.superlative"}, true},
+		{"general rules with a pack", "food", "A miracle snack.", []string{"general.miracle"}, true},
+		{"case-insensitive", "food", "RISK-FREE and CLINICALLY PROVEN.", []string{"general.risk_free", "general.proven"}, true},
+		{"medical cosmetic", "skincare", "Heals stubborn acne overnight.", []string{"cosmetics.medical_claim"}, true},
+		{"chemical free", "beauty", "A chemical-free formula.", []string{"cosmetics.chemical_free"}, true},
+		{"visible results without disclaimer", "cosmetics", "Reduces the look of fine lines.", []string{"cosmetics.results_disclaimer"}, false},
+		{"visible results with disclaimer", "cosmetics", "Reduces the look of fine lines. Individual results may vary.", nil, false},
+		{"waterproof without rating", "electronics", "Fully waterproof earbuds.", []string{"electronics.waterproof"}, false},
+		{"waterproof with rating", "electronics", "Waterproof to IP68.", nil, false},
+		{"military grade with standard", "electronics", "Military-grade toughness, tested to MIL-STD-810H.", nil, false},
+		{"unbreakable", "electronics", "An unbreakable case.", []string{"electronics.indestructible"}, true},
+		{"battery hours without disclaimer", "electronics", "Enjoy 30 hours of playback.", []string{"electronics.battery_disclaimer"}, false},
+		{"battery hours with disclaimer", "electronics", "Enjoy 30 hours of playback. Battery life varies by use.", nil, false},
+	}
+	for _, tt := range tests {
+		report := Check(Product{Category: tt.category}, Text{Source: SourceScript, Text: tt.text})
+		var rules []string
+		for _, finding := range report.Findings {
+			rules = append(rules, finding.Rule)
+			if finding.Source != SourceScript || finding.Excerpt == "" || finding.Message == "" {
+				t.Errorf("%s: incomplete finding %+v", tt.name, finding)
+			}
+		}
+		if !reflect.DeepEqual(rules, tt.wantRules) {
+			t.Errorf("%s: rules = %v, want %v", tt.name, rules, tt.wantRules)
+		}
+		if report.Blocked() != tt.blocked {
+			t.Errorf("%s: Blocked() = %v, want %v", tt.name, report.Blocked(), tt.blocked)
+		}
+		if (report.Err() != nil) != tt.blocked {
+			t.Errorf("%s: Err() = %v, want an error only when blocked", tt.name, report.Err())
+		}
+	}
+}
+
+func TestCheckPacks(t *testing.T) {
+	tests := []struct {
+		category string
+		want     string
+	}{
+		{"food", "food"},
+		{"Grocery", "food"},
+		{"personal care", "cosmetics"},
+		{"electronics", "electronics"},
+		{"toys", "general"},
+		{"", "general"},
+	}
+	for _, tt := range tests {
+		if got := Check(Product{Category: tt.category}).Pack; got != tt.want {
+			t.Errorf("Check(%q).Pack = %q, want %q", tt.category, got, tt.want)
+		}
+	}
+}
+
+func TestCheckTexts(t *testing.T) {
+	report := Check(Product{Category: "food", Price: "$4.99"},
+		Text{Source: SourceScript, Text: "Only $4.99 and it cures the flu."},
+		Text{Source: SourceCaption, Text: "   "},
+		Text{Source: SourceFeature + "_2", Text: "Now $3.99!"},
+	)
+	var got []string
+	for _, finding := range report.Findings {
+		got = append(got, finding.Source+" "+finding.Rule)
+	}
+	want := []string{"script food.disease_claim", "website_feature_2 general.price_mismatch"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+
+	err := report.Err()
+	if err == nil || !strings.Contains(err.Error(), "2 compliance rule(s)") || !strings.Contains(err.Error(), "website_feature_2: States $3.99") {
+		t.Errorf("Err() = %v", err)
+	}
+	if findings := Check(Product{}).Findings; findings == nil || len(findings) != 0 {
+		t.Errorf("Check() without texts: findings = %#v, want an empty list", findings)
+	}
+}
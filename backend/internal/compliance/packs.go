@@ -0,0 +1,168 @@
+package compliance
+
+import "regexp"
+
+// re compiles a case-insensitive pattern
+func re(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)` + pattern)
+}
+
+// generalPack applies to every category
+var generalPack = pack{
+	name: "general",
+	phrases: []phraseRule{
+		{
+			id: "general.absolute_guarantee", kind: KindBannedPhrase, severity: SeverityHard,
+			pattern: re(`\b(100%|one hundred percent)\s+(guaranteed|safe|effective|risk[- ]free)\b|\bguaranteed\s+results\b`),
+			message: "Absolute guarantees cannot be substantiated; describe what the product does instead",
+		},
+		{
+			id: "general.risk_free", kind: KindBannedPhrase, severity: SeverityHard,
+			pattern: re(`\brisk[- ]free\b|\bno side[- ]effects\b`),
+			message: "No product is free of risk or side effects; remove the claim",
+		},
+		{
+			id: "general.miracle", kind: KindBannedPhrase, severity: SeverityHard,
+			pattern: re(`\bmiracle\b|\bmagic(al)?\s+(cure|formula|pill)\b`),
+			message: "Miracle claims are misleading; describe the actual benefit",
+		},
+		{
+			id: "general.superlative", kind: KindUnverifiableClaim, severity: SeverityWarning,
+			pattern: re(`#1\b|\bnumber\s+one\b|\bno\.\s?1\b|\bworld'?s\s+(best|first|finest)\b|\bbest\s+(ever|in\s+the\s+world|on\s+the\s+market)\b|\bunbeatable\b|\bunmatched\b|\bunrivall?ed\b`),
+			message: "Superlatives need evidence such as a ranking or survey on file; soften the claim or cite the source",
+		},
+		{
+			id: "general.proven", kind: KindUnverifiableClaim, severity: SeverityWarning,
+			pattern: re(`\b(clinically|scientifically|dermatologically|lab)[- ](proven|tested)\b`),
+			message: "Proof claims need the study on file; keep them only if the reviewer has it",
+		},
+		{
+			id: "general.endorsement", kind: KindUnverifiableClaim, severity: SeverityWarning,
+			pattern: re(`\b(doctors?|dentists?|dermatologists?|experts?)[- ](recommended|approved)\b`),
+			message: "Professional endorsements need a documented source",
+		},
+		{
+			id: "general.natural", kind: KindUnverifiableClaim, severity: SeverityWarning,
+			pattern: re(`\b100%\s+natural\b|\ball[- ]natural\b|\bpurely\s+natural\b`),
+			message: `"Natural" claims need every ingredient substantiated; prefer naming the ingredients`,
+		},
+	},
+}
+
+// packs hold the rules of specific categories, added to the general ones
+var packs = []pack{
+	{
+		name:       "food",
+		categories: []string{"food", "beverages", "grocery", "snacks"},
+		phrases: []phraseRule{
+			{
+				id: "food.disease_claim", kind: KindBannedPhrase, severity: SeverityHard,
+				pattern: re(`\b(cures?|treats?|prevents?|heals?|fights?)\s+(\w+\s+){0,3}?(disease|diabetes|cancer|cholesterol|blood\s+pressure|hypertension|obesity|infections?|colds?|flu|covid)\b`),
+				message: "Foods may not claim to cure, treat or prevent disease",
+			},
+			{
+				id: "food.immunity", kind: KindBannedPhrase, severity: SeverityHard,
+				pattern: re(`\b(boosts?|strengthens?|builds?)\s+(your\s+)?immun(e\s+system|ity)\b`),
+				message: "Immunity claims for foods are not allowed without an authorised health claim",
+			},
+			{
+				id: "food.weight_loss", kind: KindBannedPhrase, severity: SeverityHard,
+				pattern: re(`\bburns?\s+(belly\s+)?fat\b|\blose\s+weight\b|\bweight[- ]loss\b`),
+				message: "Weight-loss claims for foods are not allowed",
+			},
+			{
+				id: "food.detox", kind: KindUnverifiableClaim, severity: SeverityWarning,
+				pattern: re(`\bdetox(es|ifies|ify|ifying)?\b|\bcleanses?\b`),
+				message: "Detox and cleansing claims have no recognised basis; remove or rephrase",
+			},
+			{
+				id: "food.free_from", kind: KindUnverifiableClaim, severity: SeverityWarning,
+				pattern: re(`\b(sugar|fat|gluten|lactose|dairy)[- ]free\b|\b(no|zero)\s+(added\s+sugar|preservatives|artificial\s+\w+)\b`),
+				message: "Free-from claims need lab or label certification on file",
+			},
+		},
+		disclaimers: []disclaimerRule{
+			{
+				id: "food.health_benefit_disclaimer", severity: SeverityWarning,
+				trigger:    re(`\b(supports?|improves?|promotes?|good\s+for)\s+(your\s+)?(\w+\s+)?(health|digestion|heart|energy|gut|metabolism)\b`),
+				disclaimer: re(`balanced\s+diet|not\s+intended\s+to\s+diagnose`),
+				message:    `Health benefits need a disclaimer such as "Enjoy as part of a balanced diet and healthy lifestyle."`,
+			},
+		},
+	},
+	{
+		name:       "cosmetics",
+		categories: []string{"beauty", "cosmetics", "personal care", "skincare"},
+		phrases: []phraseRule{
+			{
+				id: "cosmetics.medical_claim", kind: KindBannedPhrase, severity: SeverityHard,
+				pattern: re(`\b(cures?|heals?|treats?)\s+(\w+\s+){0,2}?(acne|eczema|psoriasis|rosacea|dermatitis|hair\s+loss|infections?)\b`),
+				message: "Treating a condition makes a cosmetic a drug; describe how it looks or feels instead",
+			},
+			{
+				id: "cosmetics.permanent_results", kind: KindBannedPhrase, severity: SeverityHard,
+				pattern: re(`\b(permanently|forever)\s+(removes?|erases?|eliminates?)\b|\b(removes?|erases?|eliminates?)\s+(\w+\s+){0,2}?(wrinkles|cellulite|scars|stretch\s+marks)\s+(permanently|forever)\b`),
+				message: "Cosmetics cannot promise permanent results",
+			},
+			{
+				id: "cosmetics.anti_aging", kind: KindBannedPhrase, severity: SeverityHard,
+				pattern: re(`\b(reverses?|stops?|halts?)\s+(the\s+)?ag(e|ing)\b`),
+				message: `Cosmetics cannot stop or reverse ageing; say "reduces the look of fine lines" or similar`,
+			},
+			{
+				id: "cosmetics.chemical_free", kind: KindBannedPhrase, severity: SeverityHard,
+				pattern: re(`\bchemical[- ]free\b|\bno\s+chemicals\b`),
+				message: `"Chemical-free" is false for any product; name what it is free of instead`,
+			},
+			{
+				id: "cosmetics.certification", kind: KindUnverifiableClaim, severity: SeverityWarning,
+				pattern: re(`\bhypoallergenic\b|\bnon[- ]comedogenic\b|\bcruelty[- ]free\b|\bcertified\s+organic\b`),
+				message: "Certification claims need the test or certificate on file",
+			},
+		},
+		disclaimers: []disclaimerRule{
+			{
+				id: "cosmetics.results_disclaimer", severity: SeverityWarning,
+				trigger:    re(`\b(reduces?|minimi[sz]es?|fades?|smooth(s|es)?|firms?|brightens?|tightens?)\b[^.!?]*\b(wrinkles|fine\s+lines|dark\s+spots|pores|skin)\b`),
+				disclaimer: re(`results\s+(may\s+)?vary`),
+				message:    `Visible-result claims need "Individual results may vary."`,
+			},
+		},
+	},
+	{
+		name:       "electronics",
+		categories: []string{"electronics"},
+		phrases: []phraseRule{
+			{
+				id: "electronics.indestructible", kind: KindBannedPhrase, severity: SeverityHard,
+				pattern: re(`\bunbreakable\b|\bindestructible\b|\bnever\s+(breaks|fails|overheats)\b`),
+				message: "No device is unbreakable; state the tested drop height or rating instead",
+			},
+			{
+				id: "electronics.unlimited_battery", kind: KindBannedPhrase, severity: SeverityHard,
+				pattern: re(`\b(lifetime|infinite|unlimited|never[- ]ending)\s+battery\b|\bnever\s+needs?\s+charging\b`),
+				message: "Battery life is always limited; state the rated hours",
+			},
+			{
+				id: "electronics.waterproof", kind: KindUnverifiableClaim, severity: SeverityWarning,
+				pattern: re(`\bwaterproof\b`),
+				unless:  re(`\bIP[X0-9][0-9]\b`),
+				message: "Water resistance needs its IP rating, e.g. IP68; say water-resistant with the rating",
+			},
+			{
+				id: "electronics.military_grade", kind: KindUnverifiableClaim, severity: SeverityWarning,
+				pattern: re(`\bmilitary[- ]grade\b`),
+				unless:  re(`MIL-STD`),
+				message: `"Military-grade" needs the standard it was tested to, e.g. MIL-STD-810H`,
+			},
+		},
+		disclaimers: []disclaimerRule{
+			{
+				id: "electronics.battery_disclaimer", severity: SeverityWarning,
+				trigger:    re(`\b\d+\s*\+?\s*(hours?|hrs?|days?)\s+(of\s+)?(battery|playback|play\s*time|talk\s*time|standby)\b`),
+				disclaimer: re(`\bvar(y|ies)\b|test\s+conditions|typical\s+use`),
+				message:    `Battery-life figures need "Battery life varies by use and settings."`,
+			},
+		},
+	},
+}
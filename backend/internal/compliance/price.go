@@ -0,0 +1,88 @@
+package compliance
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pricePattern finds amounts of money: a currency before the number ("$9.99",
+// "Rs. 1,299", "₹499") or after it ("499 rupees", "20 USD")
+var pricePattern = regexp.MustCompile(`(?i)(?:[$€£₹¥]|\brs\.?|\binr\b|\busd\b|\beur\b)\s?(\d[\d,]*(?:\.\d{1,2})?)|\b(\d[\d,]*(?:\.\d{1,2})?)\s?(?:dollars|rupees|euros|pounds|usd|inr|eur)\b`)
+
+// priceCuePattern matches words that present the amount after them as what the
+// product costs: "for $9.99", "only $9.99", "priced at $9.99", "now $9.99"
+var priceCuePattern = regexp.MustCompile(`(?i)\b(?:for|only|just|now|priced at|costs?|sells? at|yours at)\s*$`)
+
+// amountPattern finds the number in the project's own price, which may have no currency
+var amountPattern = regexp.MustCompile(`\d[\d,]*(?:\.\d+)?`)
+
+// checkPrices flags amounts in the copy that are not the product's price. Only an
+// amount stated as the product's price ("for $7.99", "priced at $7.99") is a hard
+// mismatch; other amounts, like "save $10", "free shipping over ₹499" or "under $20",
+// may be true and are left to a reviewer as warnings. Copy may not state a price
+// at all when the product has none.
+func checkPrices(productPrice string, text Text) []Finding {
+	price, hasPrice := parseAmount(amountPattern.FindString(productPrice))
+
+	var findings []Finding
+	for _, match := range pricePattern.FindAllStringSubmatchIndex(text.Text, -1) {
+		excerpt := strings.TrimSpace(text.Text[match[0]:match[1]])
+		number := submatch(text.Text, match, 1)
+		if number == "" {
+			number = submatch(text.Text, match, 2)
+		}
+		amount, ok := parseAmount(number)
+		if !ok || (hasPrice && math.Abs(amount-price) < 0.005) {
+			continue
+		}
+
+		stated := priceCuePattern.MatchString(text.Text[:match[0]])
+		finding := Finding{Source: text.Source, Kind: KindPriceMismatch, Excerpt: excerpt}
+		switch {
+		case stated && hasPrice:
+			finding.Severity, finding.Rule = SeverityHard, "general.price_mismatch"
+			finding.Message = fmt.Sprintf("States %s as the price, but the product's price is %s; quote the product's price or remove the amount", excerpt, productPrice)
+		case stated:
+			finding.Severity, finding.Rule = SeverityHard, "general.price_mismatch"
+			finding.Message = fmt.Sprintf("States %s as the price, but the product has no price set; set the price or remove the amount", excerpt)
+		default:
+			finding.Severity, finding.Rule = SeverityWarning, "general.other_amount"
+			finding.Message = fmt.Sprintf("Mentions %s, which is not the product's price; check the amount is right and cannot be read as the price", excerpt)
+		}
+		findings = replaceFinding(findings, finding)
+	}
+	return findings
+}
+
+// replaceFinding adds the finding unless the excerpt is already flagged. A repeated
+// amount is flagged once, as a hard mismatch if any mention states it as the price.
+func replaceFinding(findings []Finding, finding Finding) []Finding {
+	for i, existing := range findings {
+		if existing.Excerpt == finding.Excerpt {
+			if existing.Severity == SeverityWarning && finding.Severity == SeverityHard {
+				findings[i] = finding
+			}
+			return findings
+		}
+	}
+	return append(findings, finding)
+}
+
+// submatch returns the text of group n of a FindAllStringSubmatchIndex match, or ""
+func submatch(text string, match []int, n int) string {
+	if match[2*n] < 0 {
+		return ""
+	}
+	return text[match[2*n]:match[2*n+1]]
+}
+
+func parseAmount(number string) (float64, bool) {
+	if number == "" {
+		return 0, false
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(number, ",", ""), 64)
+	return amount, err == nil
+}
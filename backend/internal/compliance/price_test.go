@@ -0,0 +1,48 @@
+package compliance
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckPrices(t *testing.T) {
+	tests := []struct {
+		name  string
+		price string
+		text  string
+		want  []string // Flagged excerpts and their severity, e.g. "$7.99 hard"
+	}{
+		{"matching price", "$9.99", "Get it today for just $9.99.", nil},
+		{"price without currency", "9.99", "Only $9.99!", nil},
+		{"other price", "$9.99", "Now only $7.99!", []string{"$7.99 hard"}},
+		{"priced at", "$9.99", "Priced at $8.49 for launch week.", []string{"$8.49 hard"}},
+		{"rupees with separators", "₹1,299", "Yours for Rs. 1,299 or 1299 rupees.", nil},
+		{"rupees mismatch", "₹1,299", "Yours for ₹999.", []string{"₹999 hard"}},
+		{"currency after the number", "20", "Just 25 USD this week.", []string{"25 USD hard"}},
+		{"whole and decimal prices", "$10", "Just $10.00.", nil},
+		{"numbers that are not prices", "$10", "Lasts 30 hours, 2 sizes, 100% cotton.", nil},
+		{"no price set", "", "Only $5!", []string{"$5 hard"}},
+		{"no price and no amounts", "", "Order yours today.", nil},
+
+		// Amounts that are not the product's price may be true, so a reviewer checks them
+		{"saving", "$49", "Save $10 when you buy two.", []string{"$10 warning"}},
+		{"shipping threshold", "₹1,299", "Free shipping over ₹499.", []string{"₹499 warning"}},
+		{"gift guide", "$15", "The best gift under $20.", []string{"$20 warning"}},
+		{"no price set, other amount", "", "Save $5 today.", []string{"$5 warning"}},
+		{"repeated amount flagged once", "$10", "$12 today, $12 tomorrow.", []string{"$12 warning"}},
+		{"repeated amount stated as the price", "$10", "Worth $12? Yours for $12.", []string{"$12 hard"}},
+		{"saving and a wrong price", "$49", "Save $10: now $39!", []string{"$10 warning", "$39 hard"}},
+	}
+	for _, tt := range tests {
+		var flagged []string
+		for _, finding := range checkPrices(tt.price, Text{Source: SourceCaption, Text: tt.text}) {
+			flagged = append(flagged, finding.Excerpt+" "+finding.Severity)
+			if finding.Kind != KindPriceMismatch {
+				t.Errorf("%s: finding %+v is not a price finding", tt.name, finding)
+			}
+		}
+		if !reflect.DeepEqual(flagged, tt.want) {
+			t.Errorf("%s: flagged %q, want %q", tt.name, flagged, tt.want)
+		}
+	}
+}
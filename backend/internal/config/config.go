@@ -1,7 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dealshare/hacathon/backend/internal/secrets"
 )
 
 type Config struct {
@@ -9,7 +15,8 @@ type Config struct {
 	UploadPath         string
 	GeneratedVideoPath string
 	WebsitePath        string
-	AIAPIKey           string
+	AIAPIKey           string // Synthesia / RunwayML Gen-2 key
+	DIDAPIKey          string // D-ID key, sent as "Basic <key>"
 	AIAPIURL           string
 	AIProvider         string // "runwayml", "did", "synthesia", or "mock"
 	Port               string
@@ -19,31 +26,205 @@ type Config struct {
 	GeminiAPIKey       string // For generating website content and features
 	UseFullAIPipeline  bool   // If true, uses RunwayML + Shotstack for complete AI pipeline
 	UseV0Style         bool   // If true, uses modern v0.dev style for websites
+	JobWorkers         int    // Number of background workers generating videos
+	// Per-role provider overrides (empty = derived from AIProvider/UseFullAIPipeline)
+	AvatarProvider       string // "did", "synthesia", "runwayml_gen2", "mock"
+	ProductVideoProvider string // "runwayml", "did", "mock", "none"
+	CompositorProvider   string // "shotstack", "ffmpeg", "none"
+	ScriptProvider       string // "gemini"
+	MediaHostProvider    string // "signed", "shotstack"
+	CaptionFontPath      string // TTF used for burned-in captions (empty = fontconfig default)
+	LayoutsDir           string // Extra composite layouts as YAML/JSON files (missing = built-ins only)
+	MusicDir             string // Background music tracks and their library.yaml (missing = no music)
+	HLSRenditions        []int  // Shorter side of each HLS rendition of a final video, e.g. 1080 (empty = no HLS)
+	// Instagram defaults when a request does not carry its own credentials
+	InstagramAccessToken string
+	InstagramUserID      string
+	// Where the credentials above were read from, e.g. "env,file:/run/secrets"
+	SecretSources string
+	// Master key (base64, 32 bytes) that encrypts workspace credentials at rest
+	WorkspaceEncryptionKey string
+	// Whether workspaces may use the server's credentials for vendors they have no keys for
+	WorkspaceCredentialFallback bool
+	// Authentication
+	JWTSecret       string   // Signs session tokens (random per process when empty)
+	SessionTTLHours int      // How long a login session lasts
+	CORSOrigins     []string // Browser origins allowed to call the API
+	// Upload limits
+	MaxImageUploadMB  int
+	MaxVideoUploadMB  int
+	MinMediaDimension int // Pixels, shortest side
+	MaxMediaDimension int // Pixels, longest side
+	MaxVideoSeconds   int
+	AllowVideoUploads bool // Presenters may be uploaded as videos, which needs ffprobe to validate them
+	// Blob storage
+	StorageBackend    string // "local" (the directories above) or "s3"
+	StorageCacheDir   string // Local copies of stored media for ffmpeg and uploads to vendors
+	S3Endpoint        string // Empty for AWS, e.g. "http://localhost:9000" for MinIO
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3PathStyle       bool
+	// Signed media URLs vendors download from
+	PublicBaseURL      string // Address vendors reach this server at
+	MediaURLSecret     string // Signs media URLs
+	MediaURLTTLMinutes int
 }
 
-func Load() *Config {
-	// Try multiple environment variable names for Gemini API key
-	geminiAPIKey := getEnv("GOOGLE_GEMINI_API_KEY", "")
-	if geminiAPIKey == "" {
-		geminiAPIKey = getEnv("GEMINI_API_KEY", "")
+// Load reads settings from the environment and vendor credentials from the
+// configured secret sources (SECRETS_SOURCES, default "env,file")
+func Load() (*Config, error) {
+	source, err := secrets.Open(secrets.Options{
+		Sources:      strings.Split(getEnv("SECRETS_SOURCES", "env,file"), ","),
+		Dir:          getEnv("SECRETS_DIR", "/run/secrets"),
+		KeystorePath: getEnv("SECRETS_KEYSTORE", "./data/secrets.keystore"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Each credential may go by more than one name; the first one set wins
+	credential := func(keys ...string) string {
+		if err != nil {
+			return ""
+		}
+		var value string
+		value, err = secrets.First(source, keys...)
+		return value
 	}
-	
-	return &Config{
+
+	cfg := &Config{
 		DatabasePath:       getEnv("DATABASE_PATH", "./data/app.db"),
 		UploadPath:         getEnv("UPLOAD_PATH", "./uploads"),
 		GeneratedVideoPath: getEnv("GENERATED_VIDEO_PATH", "./generated/videos"),
 		WebsitePath:        getEnv("WEBSITE_PATH", "./generated/websites"),
-		AIAPIKey:           getEnv("AI_API_KEY", ""),
+		AIAPIKey:           credential("AI_API_KEY"),
+		DIDAPIKey:          credential("DID_API_KEY", "AI_API_KEY"),
 		AIAPIURL:           getEnv("AI_API_URL", ""),
 		AIProvider:         getEnv("AI_PROVIDER", "mock"), // Options: runwayml, did, synthesia, mock
 		Port:               getEnv("PORT", "8080"),
 		// New AI service API keys
-		RunwayMLAPIKey:     getEnv("RUNWAYML_API_KEY", ""),
-		ShotstackAPIKey:    getEnv("SHOTSTACK_API_KEY", ""),
-		GeminiAPIKey:       geminiAPIKey,
+		RunwayMLAPIKey:     credential("RUNWAYML_API_KEY"),
+		ShotstackAPIKey:    credential("SHOTSTACK_API_KEY"),
+		GeminiAPIKey:       credential("GOOGLE_GEMINI_API_KEY", "GEMINI_API_KEY"),
 		UseFullAIPipeline:  getEnv("USE_FULL_AI_PIPELINE", "false") == "true",
 		UseV0Style:         getEnv("USE_V0_STYLE", "true") == "true", // Default to true for modern websites
+		JobWorkers:         getEnvInt("JOB_WORKERS", 2),
+		// Per-role provider overrides
+		AvatarProvider:       getEnv("AVATAR_PROVIDER", ""),
+		ProductVideoProvider: getEnv("PRODUCT_VIDEO_PROVIDER", ""),
+		CompositorProvider:   getEnv("COMPOSITOR_PROVIDER", ""),
+		ScriptProvider:       getEnv("SCRIPT_PROVIDER", ""),
+		MediaHostProvider:    getEnv("MEDIA_HOST_PROVIDER", ""),
+		CaptionFontPath:      getEnv("CAPTION_FONT", ""),
+		LayoutsDir:           getEnv("LAYOUTS_DIR", "./layouts"),
+		MusicDir:             getEnv("MUSIC_DIR", "./music"),
+		HLSRenditions:        getEnvInts("HLS_RENDITIONS", []int{1080, 720, 480}),
+		// Instagram defaults
+		InstagramAccessToken: credential("INSTAGRAM_ACCESS_TOKEN"),
+		InstagramUserID:      getEnv("INSTAGRAM_USER_ID", ""),
+		SecretSources:        source.Name(),
+		// Workspace credentials
+		WorkspaceEncryptionKey:      credential("WORKSPACE_ENCRYPTION_KEY"),
+		WorkspaceCredentialFallback: getEnv("WORKSPACE_CREDENTIAL_FALLBACK", "false") == "true",
+		// Authentication
+		JWTSecret:       credential("JWT_SECRET"),
+		SessionTTLHours: getEnvInt("SESSION_TTL_HOURS", 24),
+		CORSOrigins:     strings.Split(getEnv("CORS_ORIGINS", "http://localhost:3000"), ","),
+		// Upload limits
+		MaxImageUploadMB:  getEnvInt("MAX_IMAGE_UPLOAD_MB", 20),
+		MaxVideoUploadMB:  getEnvInt("MAX_VIDEO_UPLOAD_MB", 200),
+		MinMediaDimension: getEnvInt("MIN_MEDIA_DIMENSION", 64),
+		MaxMediaDimension: getEnvInt("MAX_MEDIA_DIMENSION", 8192),
+		MaxVideoSeconds:   getEnvInt("MAX_VIDEO_SECONDS", 300),
+		AllowVideoUploads: getEnv("ALLOW_VIDEO_UPLOADS", "true") == "true",
+		// Blob storage
+		StorageBackend:    getEnv("STORAGE_BACKEND", "local"),
+		StorageCacheDir:   getEnv("STORAGE_CACHE_DIR", "./data/cache"),
+		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnv("S3_BUCKET", ""),
+		S3AccessKeyID:     credential("S3_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID"),
+		S3SecretAccessKey: credential("S3_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY"),
+		S3PathStyle:       getEnv("S3_PATH_STYLE", "true") == "true",
+		// Signed media URLs
+		PublicBaseURL:      getEnv("PUBLIC_BASE_URL", "http://localhost:"+getEnv("PORT", "8080")),
+		MediaURLSecret:     credential("MEDIA_URL_SECRET"),
+		MediaURLTTLMinutes: getEnvInt("MEDIA_URL_TTL_MINUTES", 60),
+	}
+	if err != nil {
+		return nil, err
 	}
+	return cfg, nil
+}
+
+// Credentials are the vendor accounts a workspace can bring instead of the server's own
+type Credentials struct {
+	DIDAPIKey            string `json:"did_api_key,omitempty"`
+	AIAPIKey             string `json:"ai_api_key,omitempty"`
+	RunwayMLAPIKey       string `json:"runwayml_api_key,omitempty"`
+	ShotstackAPIKey      string `json:"shotstack_api_key,omitempty"`
+	GeminiAPIKey         string `json:"gemini_api_key,omitempty"`
+	InstagramAccessToken string `json:"instagram_access_token,omitempty"`
+	InstagramUserID      string `json:"instagram_user_id,omitempty"`
+}
+
+// Names lists the credentials that are set, never their values
+func (c Credentials) Names() []string {
+	names := []string{}
+	for name, value := range map[string]string{
+		"did_api_key":            c.DIDAPIKey,
+		"ai_api_key":             c.AIAPIKey,
+		"runwayml_api_key":       c.RunwayMLAPIKey,
+		"shotstack_api_key":      c.ShotstackAPIKey,
+		"gemini_api_key":         c.GeminiAPIKey,
+		"instagram_access_token": c.InstagramAccessToken,
+		"instagram_user_id":      c.InstagramUserID,
+	} {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate reports credentials that cannot be used as given. The Instagram token
+// and user ID name one account, so they are set together or not at all.
+func (c Credentials) Validate() error {
+	if (c.InstagramAccessToken == "") != (c.InstagramUserID == "") {
+		return fmt.Errorf("instagram_access_token and instagram_user_id must be set together")
+	}
+	return nil
+}
+
+// WithCredentials returns a copy of the configuration in which every credential
+// that is set replaces the server's own. Credentials that are not set keep the
+// server's only when WorkspaceCredentialFallback allows it; otherwise they are
+// left empty, and providers that need them refuse to start.
+func (c *Config) WithCredentials(creds Credentials) (*Config, error) {
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+
+	clone := *c
+	override := func(current *string, value string) {
+		if value != "" || !c.WorkspaceCredentialFallback {
+			*current = value
+		}
+	}
+	override(&clone.DIDAPIKey, creds.DIDAPIKey)
+	override(&clone.AIAPIKey, creds.AIAPIKey)
+	override(&clone.RunwayMLAPIKey, creds.RunwayMLAPIKey)
+	override(&clone.ShotstackAPIKey, creds.ShotstackAPIKey)
+	override(&clone.GeminiAPIKey, creds.GeminiAPIKey)
+	// Replaced as a pair, so a post never mixes one account's token with another's user
+	if creds.InstagramAccessToken != "" || !c.WorkspaceCredentialFallback {
+		clone.InstagramAccessToken = creds.InstagramAccessToken
+		clone.InstagramUserID = creds.InstagramUserID
+	}
+	return &clone, nil
 }
 
 func getEnv(key, defaultValue string) string {
@@ -52,3 +233,25 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvInts reads a comma-separated list of positive numbers; "none" is an empty list
+func getEnvInts(key string, defaultValue []int) []int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	var values []int
+	for _, field := range strings.Split(raw, ",") {
+		if value, err := strconv.Atoi(strings.TrimSpace(field)); err == nil && value > 0 {
+			values = append(values, value)
+		}
+	}
+	return values
+}
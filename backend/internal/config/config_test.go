@@ -0,0 +1,63 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func serverConfig(fallback bool) *Config {
+	return &Config{
+		DIDAPIKey:                   "server-did",
+		RunwayMLAPIKey:              "server-runway",
+		ShotstackAPIKey:             "server-shotstack",
+		InstagramAccessToken:        "server-token",
+		InstagramUserID:             "server-user",
+		WorkspaceCredentialFallback: fallback,
+	}
+}
+
+func TestWithCredentialsFallback(t *testing.T) {
+	creds := Credentials{ShotstackAPIKey: "brand-shotstack"}
+
+	cfg, err := serverConfig(true).WithCredentials(creds)
+	if err != nil {
+		t.Fatalf("WithCredentials() error = %v", err)
+	}
+	if cfg.ShotstackAPIKey != "brand-shotstack" || cfg.DIDAPIKey != "server-did" || cfg.RunwayMLAPIKey != "server-runway" {
+		t.Errorf("with fallback: keys = %q, %q, %q, want the workspace's Shotstack key and the server's others",
+			cfg.ShotstackAPIKey, cfg.DIDAPIKey, cfg.RunwayMLAPIKey)
+	}
+	if cfg.InstagramAccessToken != "server-token" || cfg.InstagramUserID != "server-user" {
+		t.Errorf("with fallback: Instagram = %q/%q, want the server's account", cfg.InstagramAccessToken, cfg.InstagramUserID)
+	}
+
+	// Without the policy the workspace gets nothing of the server's
+	cfg, err = serverConfig(false).WithCredentials(creds)
+	if err != nil {
+		t.Fatalf("WithCredentials() error = %v", err)
+	}
+	if cfg.ShotstackAPIKey != "brand-shotstack" || cfg.DIDAPIKey != "" || cfg.RunwayMLAPIKey != "" {
+		t.Errorf("without fallback: keys = %q, %q, %q, want only the workspace's Shotstack key",
+			cfg.ShotstackAPIKey, cfg.DIDAPIKey, cfg.RunwayMLAPIKey)
+	}
+	if cfg.InstagramAccessToken != "" || cfg.InstagramUserID != "" {
+		t.Errorf("without fallback: Instagram = %q/%q, want no account", cfg.InstagramAccessToken, cfg.InstagramUserID)
+	}
+}
+
+func TestWithCredentialsInstagramPair(t *testing.T) {
+	cfg, err := serverConfig(true).WithCredentials(Credentials{InstagramAccessToken: "brand-token", InstagramUserID: "brand-user"})
+	if err != nil || cfg.InstagramAccessToken != "brand-token" || cfg.InstagramUserID != "brand-user" {
+		t.Errorf("WithCredentials(pair) = %+v, %v, want the workspace's account", cfg, err)
+	}
+
+	for _, creds := range []Credentials{
+		{InstagramAccessToken: "brand-token"},
+		{InstagramUserID: "brand-user"},
+	} {
+		// Filling the gap from the server would post with one account's token as another's user
+		if _, err := serverConfig(true).WithCredentials(creds); err == nil || !strings.Contains(err.Error(), "set together") {
+			t.Errorf("WithCredentials(%+v) error = %v, want the pair to be required", creds, err)
+		}
+	}
+}
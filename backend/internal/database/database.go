@@ -25,6 +25,43 @@ func Initialize(dbPath string) (*gorm.DB, error) {
 }
 
 func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(&models.Project{})
+	if err := db.AutoMigrate(&models.Project{}, &models.Job{}, &models.Workspace{}, &models.User{}, &models.APIToken{}, &models.Asset{}, &models.BrandKit{}, &models.Script{}); err != nil {
+		return err
+	}
+	return backfillScripts(db)
+}
+
+// backfillScripts gives projects from before scripts were versioned and reviewed a
+// draft script holding their generated one, so it can be reviewed like any other
+func backfillScripts(db *gorm.DB) error {
+	if err := db.Model(&models.Script{}).Where("root_id = '' OR root_id IS NULL").
+		Updates(map[string]interface{}{"root_id": gorm.Expr("id"), "version": 1, "status": models.ScriptStatusDraft}).Error; err != nil {
+		return err
+	}
+
+	var projects []models.Project
+	if err := db.Where("generated_script <> '' AND (active_script_id = '' OR active_script_id IS NULL)").Find(&projects).Error; err != nil {
+		return err
+	}
+	for _, project := range projects {
+		script := models.Script{
+			ProjectID:       project.ID,
+			Text:            project.GeneratedScript,
+			Tone:            "professional",
+			DurationSeconds: 15,
+			Language:        "en",
+			AuthorID:        project.OwnerID,
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&script).Error; err != nil {
+				return err
+			}
+			return tx.Model(&project).Update("active_script_id", script.ID).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
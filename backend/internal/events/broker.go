@@ -0,0 +1,100 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types emitted while a project's pipeline runs
+const (
+	TypeQueued   = "queued"   // job accepted and waiting for a worker
+	TypeStage    = "stage"    // pipeline entered a new stage
+	TypePoll     = "poll"     // a remote task was polled
+	TypeUpload   = "upload"   // a local file was pushed to a remote host
+	TypeTask     = "task"     // a remote task was submitted
+	TypeArtifact = "artifact" // a stage produced a file
+	TypeFailure  = "failure"  // the job failed
+	TypeComplete = "complete" // the job finished successfully
+	TypeCancel   = "cancel"   // the job was cancelled
+)
+
+// Event is a single progress notification for a project
+type Event struct {
+	Type        string    `json:"type"`
+	ProjectID   string    `json:"project_id"`
+	JobID       string    `json:"job_id,omitempty"`
+	Stage       string    `json:"stage,omitempty"`
+	Percent     int       `json:"percent,omitempty"`
+	Provider    string    `json:"provider,omitempty"`
+	TaskID      string    `json:"task_id,omitempty"`
+	Attempt     int       `json:"attempt,omitempty"`
+	MaxAttempts int       `json:"max_attempts,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	Path        string    `json:"path,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// subscriberBuffer is how many events a slow subscriber may lag behind before events are dropped
+const subscriberBuffer = 64
+
+// Broker fans out events to subscribers of a project. It is in-memory only;
+// clients that reconnect should re-read the job state for anything they missed.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan Event]struct{}
+}
+
+// NewBroker creates an empty broker
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[string]map[chan Event]struct{}),
+	}
+}
+
+// Publish sends the event to every subscriber of its project without blocking
+func (b *Broker) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[event.ProjectID] {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber is not keeping up; drop rather than stall the pipeline
+		}
+	}
+}
+
+// Subscribe returns a channel of events for the project and a function to unsubscribe
+func (b *Broker) Subscribe(projectID string) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[projectID] == nil {
+		b.subscribers[projectID] = make(map[chan Event]struct{})
+	}
+	b.subscribers[projectID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if subs, ok := b.subscribers[projectID]; ok {
+			if _, ok := subs[ch]; ok {
+				delete(subs, ch)
+				close(ch)
+			}
+			if len(subs) == 0 {
+				delete(b.subscribers, projectID)
+			}
+		}
+	}
+
+	return ch, unsubscribe
+}
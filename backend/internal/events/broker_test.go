@@ -0,0 +1,91 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBrokerPublish(t *testing.T) {
+	b := NewBroker()
+	first, unsubscribeFirst := b.Subscribe("p1")
+	defer unsubscribeFirst()
+	second, unsubscribeSecond := b.Subscribe("p1")
+	defer unsubscribeSecond()
+	other, unsubscribeOther := b.Subscribe("p2")
+	defer unsubscribeOther()
+
+	b.Publish(Event{Type: TypeStage, ProjectID: "p1", Stage: "avatar", Percent: 5})
+
+	for name, ch := range map[string]<-chan Event{"first": first, "second": second} {
+		select {
+		case event := <-ch:
+			if event.Type != TypeStage || event.Stage != "avatar" || event.Percent != 5 {
+				t.Errorf("%s subscriber got %+v", name, event)
+			}
+			if event.Time.IsZero() {
+				t.Errorf("%s subscriber got an event without a time", name)
+			}
+		default:
+			t.Errorf("%s subscriber got nothing", name)
+		}
+	}
+	select {
+	case event := <-other:
+		t.Errorf("subscriber of another project got %+v", event)
+	default:
+	}
+}
+
+func TestBrokerKeepsEventTime(t *testing.T) {
+	b := NewBroker()
+	ch, unsubscribe := b.Subscribe("p1")
+	defer unsubscribe()
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	b.Publish(Event{Type: TypeQueued, ProjectID: "p1", Time: at})
+	if event := <-ch; !event.Time.Equal(at) {
+		t.Errorf("event time = %v, want %v", event.Time, at)
+	}
+}
+
+func TestBrokerDropsForSlowSubscribers(t *testing.T) {
+	b := NewBroker()
+	ch, unsubscribe := b.Subscribe("p1")
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < subscriberBuffer+10; i++ {
+			b.Publish(Event{Type: TypePoll, ProjectID: "p1", Attempt: i + 1})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish blocked on a subscriber that is not reading")
+	}
+
+	if len(ch) != subscriberBuffer {
+		t.Fatalf("subscriber has %d events buffered, want %d", len(ch), subscriberBuffer)
+	}
+	if event := <-ch; event.Attempt != 1 {
+		t.Errorf("first buffered event is attempt %d, want the oldest (1)", event.Attempt)
+	}
+}
+
+func TestBrokerUnsubscribe(t *testing.T) {
+	b := NewBroker()
+	ch, unsubscribe := b.Subscribe("p1")
+	unsubscribe()
+
+	if _, open := <-ch; open {
+		t.Error("channel is still open after unsubscribing")
+	}
+	if len(b.subscribers) != 0 {
+		t.Errorf("broker still tracks %d projects", len(b.subscribers))
+	}
+
+	unsubscribe() // Twice is harmless
+	b.Publish(Event{Type: TypeComplete, ProjectID: "p1"})
+}
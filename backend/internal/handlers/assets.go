@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/dealshare/hacathon/backend/internal/assets"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAssets lists every file recorded for a project: uploads, intermediate clips
+// and final outputs, oldest first. ?kind= narrows the list to one AssetKind.
+func (h *Handlers) GetAssets(c *gin.Context) {
+	var project models.Project
+	if !h.loadProject(c, c.Param("id"), &project) {
+		return
+	}
+
+	list, err := h.assets.List(project.ID, c.Query("kind"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch assets"})
+		return
+	}
+
+	c.JSON(200, gin.H{"project_id": project.ID, "assets": list})
+}
+
+// GetAsset returns one asset together with the assets it was made from
+func (h *Handlers) GetAsset(c *gin.Context) {
+	var project models.Project
+	if !h.loadProject(c, c.Param("id"), &project) {
+		return
+	}
+	asset, ok := h.loadAsset(c, project.ID)
+	if !ok {
+		return
+	}
+
+	parents, err := h.assets.Parents(asset)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch parent assets"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"asset":        asset,
+		"parents":      parents,
+		"download_url": fmt.Sprintf("/api/v1/projects/%s/assets/%s/download", project.ID, asset.ID),
+	})
+}
+
+// DownloadAsset sends the asset's file as an attachment. Remote backends redirect
+// to a short-lived signed URL instead.
+func (h *Handlers) DownloadAsset(c *gin.Context) {
+	var project models.Project
+	if !h.loadProject(c, c.Param("id"), &project) {
+		return
+	}
+	asset, ok := h.loadAsset(c, project.ID)
+	if !ok {
+		return
+	}
+
+	if _, local := h.storage.(*storage.Local); !local {
+		url, err := h.storage.SignedURL(c.Request.Context(), asset.StorageKey, mediaURLTTL)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to sign file URL"})
+			return
+		}
+		c.Redirect(302, url)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", asset.Kind+"-"+path.Base(asset.StorageKey)))
+	h.serveObject(c, asset.StorageKey)
+}
+
+// loadAsset loads the :assetId asset of the project, responding 404 if there is none
+func (h *Handlers) loadAsset(c *gin.Context, projectID string) (*models.Asset, bool) {
+	asset, err := h.assets.Get(projectID, c.Param("assetId"))
+	if errors.Is(err, assets.ErrNotFound) {
+		c.JSON(404, gin.H{"error": "Asset not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch asset"})
+		return nil, false
+	}
+	return asset, true
+}
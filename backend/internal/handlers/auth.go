@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/dealshare/hacathon/backend/internal/auth"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// sessionResponse signs the user in and returns the session token
+func (h *Handlers) sessionResponse(c *gin.Context, status int, user *models.User) {
+	token, expiresAt, err := h.auth.IssueSession(user)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create session"})
+		return
+	}
+	c.JSON(status, gin.H{
+		"token":      token,
+		"expires_at": expiresAt,
+		"user":       user,
+	})
+}
+
+// newUser validates and stores an account
+func (h *Handlers) newUser(c *gin.Context, email, name, password, role, workspaceID string) (*models.User, bool) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || !strings.Contains(email, "@") {
+		c.JSON(400, gin.H{"error": "A valid email is required"})
+		return nil, false
+	}
+	if !models.ValidRole(role) {
+		c.JSON(400, gin.H{"error": "role must be viewer, editor, reviewer or publisher"})
+		return nil, false
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	var existing int64
+	h.db.Model(&models.User{}).Where("email = ?", email).Count(&existing)
+	if existing > 0 {
+		c.JSON(409, gin.H{"error": "A user with this email already exists"})
+		return nil, false
+	}
+
+	user := &models.User{Email: email, Name: name, PasswordHash: hash, Role: role, WorkspaceID: workspaceID}
+	if err := h.db.Create(user).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create user"})
+		return nil, false
+	}
+	return user, true
+}
+
+// registerMu makes the "no users yet" check and the first account's creation atomic
+var registerMu sync.Mutex
+
+// Register creates the first account, an agency-level publisher. Once any user
+// exists, accounts are created by publishers through POST /users instead.
+func (h *Handlers) Register(c *gin.Context) {
+	var requestBody struct {
+		Email    string `json:"email"`
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(400, gin.H{"error": "email and password are required"})
+		return
+	}
+
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	var count int64
+	h.db.Model(&models.User{}).Count(&count)
+	if count > 0 {
+		c.JSON(403, gin.H{"error": "Registration is closed; ask a publisher to create your account"})
+		return
+	}
+
+	user, ok := h.newUser(c, requestBody.Email, requestBody.Name, requestBody.Password, models.RolePublisher, "")
+	if !ok {
+		return
+	}
+
+	// Projects created before accounts existed belong to the first user
+	h.db.Model(&models.Project{}).Where("owner_id = '' OR owner_id IS NULL").Update("owner_id", user.ID)
+
+	h.sessionResponse(c, 201, user)
+}
+
+// Login exchanges an email and password for a session token
+func (h *Handlers) Login(c *gin.Context) {
+	var requestBody struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(400, gin.H{"error": "email and password are required"})
+		return
+	}
+
+	user, err := h.auth.Login(requestBody.Email, requestBody.Password)
+	if err != nil {
+		c.JSON(401, gin.H{"error": err.Error()})
+		return
+	}
+	h.sessionResponse(c, 200, user)
+}
+
+// Me returns the signed-in user
+func (h *Handlers) Me(c *gin.Context) {
+	c.JSON(200, auth.CurrentUser(c))
+}
+
+// CreateAPIToken issues an API token acting as the signed-in user. The token is
+// only shown in this response.
+func (h *Handlers) CreateAPIToken(c *gin.Context) {
+	var requestBody struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil || requestBody.Name == "" {
+		c.JSON(400, gin.H{"error": "name is required"})
+		return
+	}
+
+	token, record, err := h.auth.CreateAPIToken(auth.CurrentUser(c), requestBody.Name)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create token", "details": err.Error()})
+		return
+	}
+	c.JSON(201, gin.H{
+		"id":         record.ID,
+		"name":       record.Name,
+		"token":      token,
+		"created_at": record.CreatedAt,
+	})
+}
+
+// GetAPITokens lists the signed-in user's API tokens
+func (h *Handlers) GetAPITokens(c *gin.Context) {
+	var tokens []models.APIToken
+	if err := h.db.Where("user_id = ?", auth.CurrentUser(c).ID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch tokens"})
+		return
+	}
+	c.JSON(200, gin.H{"tokens": tokens})
+}
+
+// DeleteAPIToken revokes one of the signed-in user's API tokens
+func (h *Handlers) DeleteAPIToken(c *gin.Context) {
+	result := h.db.Where("id = ? AND user_id = ?", c.Param("id"), auth.CurrentUser(c).ID).Delete(&models.APIToken{})
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Token not found"})
+		return
+	}
+	c.JSON(200, gin.H{"id": c.Param("id"), "status": "revoked"})
+}
+
+// CreateUser adds an account. Workspace publishers add users to their own workspace;
+// agency-level publishers may pick any workspace, or none.
+func (h *Handlers) CreateUser(c *gin.Context) {
+	var requestBody struct {
+		Email       string `json:"email"`
+		Name        string `json:"name"`
+		Password    string `json:"password"`
+		Role        string `json:"role"`
+		WorkspaceID string `json:"workspace_id"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user", "details": err.Error()})
+		return
+	}
+
+	creator := auth.CurrentUser(c)
+	if requestBody.WorkspaceID == "" {
+		requestBody.WorkspaceID = creator.WorkspaceID
+	}
+	if !creator.CanManageWorkspace(requestBody.WorkspaceID) {
+		c.JSON(403, gin.H{"error": "You can only add users to your own workspace"})
+		return
+	}
+	if requestBody.WorkspaceID != "" {
+		if _, err := h.workspaces.Get(requestBody.WorkspaceID); err != nil {
+			c.JSON(400, gin.H{"error": "Workspace not found"})
+			return
+		}
+	}
+
+	user, ok := h.newUser(c, requestBody.Email, requestBody.Name, requestBody.Password, requestBody.Role, requestBody.WorkspaceID)
+	if !ok {
+		return
+	}
+	c.JSON(201, user)
+}
+
+// GetUsers lists the accounts in the publisher's workspace, or all for agency-level publishers
+func (h *Handlers) GetUsers(c *gin.Context) {
+	query := h.db.Order("email")
+	if workspaceID := auth.CurrentUser(c).WorkspaceID; workspaceID != "" {
+		query = query.Where("workspace_id = ?", workspaceID)
+	}
+
+	var users []models.User
+	if err := query.Find(&users).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	c.JSON(200, gin.H{"users": users})
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dealshare/hacathon/backend/internal/media"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/services"
+	"github.com/dealshare/hacathon/backend/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// brandKitResponse adds the public URLs of the kit's files
+func brandKitResponse(kit *models.BrandKit) gin.H {
+	return gin.H{
+		"brand_kit": kit,
+		"logo_url":  storage.PublicPath(kit.LogoPath),
+		"intro_url": storage.PublicPath(kit.IntroPath),
+		"outro_url": storage.PublicPath(kit.OutroPath),
+	}
+}
+
+// GetBrandKit gets the workspace's brand kit
+func (h *Handlers) GetBrandKit(c *gin.Context) {
+	workspace, ok := h.loadWorkspace(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	kit, err := h.workspaces.BrandKit(workspace.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch brand kit"})
+		return
+	}
+	if kit == nil {
+		c.JSON(404, gin.H{"error": "Workspace has no brand kit"})
+		return
+	}
+	c.JSON(200, brandKitResponse(kit))
+}
+
+// UpdateBrandKit creates or updates the workspace's brand kit from a multipart form.
+// Fields left out keep their value: primary_color, secondary_color, heading_font,
+// body_font and watermark_position, and the files logo (an image), intro and outro
+// (videos). clear lists files to remove, e.g. "intro,outro".
+func (h *Handlers) UpdateBrandKit(c *gin.Context) {
+	workspace, ok := h.loadWorkspace(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// Cap the request at the logo, two bumpers and the form fields
+	limits := h.uploadLimits()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxImageBytes+2*limits.MaxVideoBytes+1<<20)
+
+	form, err := c.MultipartForm()
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.JSON(413, gin.H{"error": fmt.Sprintf("Upload is too large (at most %d MB in total)", maxBytesErr.Limit>>20)})
+		return
+	}
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to parse form"})
+		return
+	}
+
+	kit, err := h.workspaces.BrandKit(workspace.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch brand kit"})
+		return
+	}
+	if kit == nil {
+		kit = &models.BrandKit{WorkspaceID: workspace.ID}
+	}
+
+	for field, value := range map[string]*string{
+		"primary_color":      &kit.PrimaryColor,
+		"secondary_color":    &kit.SecondaryColor,
+		"heading_font":       &kit.HeadingFont,
+		"body_font":          &kit.BodyFont,
+		"watermark_position": &kit.WatermarkPosition,
+	} {
+		if values, ok := form.Value[field]; ok && len(values) > 0 {
+			*value = strings.TrimSpace(values[0])
+		}
+	}
+	if err := services.ValidateBrandKit(kit); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	files := []struct {
+		field string
+		path  *string
+		kind  string
+	}{
+		{"logo", &kit.LogoPath, media.KindImage},
+		{"intro", &kit.IntroPath, media.KindVideo},
+		{"outro", &kit.OutroPath, media.KindVideo},
+	}
+
+	cleared := map[string]bool{}
+	for _, field := range strings.Split(c.PostForm("clear"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			cleared[field] = true
+		}
+	}
+
+	// New files are removed again if the update fails; replaced ones once it succeeds
+	var storedKeys, replacedKeys []string
+	saved := false
+	defer func() {
+		if !saved {
+			for _, key := range storedKeys {
+				h.storage.Delete(context.Background(), key)
+			}
+		}
+	}()
+	for _, file := range files {
+		previous := *file.path
+		if cleared[file.field] {
+			*file.path = ""
+		}
+		if headers := form.File[file.field]; len(headers) > 0 {
+			key, _, ok := h.saveUpload(c, file.field, headers[0], "brand/"+workspace.ID, file.field, file.kind)
+			if !ok {
+				return
+			}
+			if key != previous {
+				storedKeys = append(storedKeys, key)
+			}
+			*file.path = key
+		}
+		if previous != "" && previous != *file.path {
+			replacedKeys = append(replacedKeys, previous)
+		}
+	}
+
+	if err := h.db.Save(kit).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to save brand kit"})
+		return
+	}
+	saved = true
+	for _, key := range replacedKeys {
+		h.storage.Delete(context.Background(), key)
+	}
+
+	fmt.Printf("🎨 Brand kit of workspace %s updated\n", workspace.ID)
+	c.JSON(200, brandKitResponse(kit))
+}
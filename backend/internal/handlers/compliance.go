@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dealshare/hacathon/backend/internal/compliance"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// projectProduct is what the project's copy is checked against
+func projectProduct(project *models.Project) compliance.Product {
+	return compliance.Product{Category: project.ProductCategory, Price: project.ProductPrice}
+}
+
+// checkScript runs the compliance rules over a script's text and keeps the
+// findings on it; they are saved with the script
+func checkScript(project *models.Project, script *models.Script) compliance.Report {
+	report := compliance.Check(projectProduct(project), compliance.Text{Source: compliance.SourceScript, Text: script.Text})
+	script.Findings = report.Findings
+	return report
+}
+
+// projectCaption is the caption the project's reel is posted with: the custom one,
+// or the generated one if it is empty
+func projectCaption(project *models.Project, custom string) string {
+	if custom != "" {
+		return custom
+	}
+	return services.GenerateInstagramCaption(project.ProductName, project.ProductDescription, project.ProductPrice)
+}
+
+// GetCompliance checks the project's active script and its Instagram caption, or
+// the ?caption= given instead, explaining what would block publication
+func (h *Handlers) GetCompliance(c *gin.Context) {
+	var project models.Project
+	if !h.loadProject(c, c.Param("id"), &project) {
+		return
+	}
+
+	texts := []compliance.Text{{Source: compliance.SourceCaption, Text: projectCaption(&project, c.Query("caption"))}}
+	var script models.Script
+	err := h.db.First(&script, "id = ? AND project_id = ?", project.ActiveScriptID, project.ID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(500, gin.H{"error": "Failed to fetch script"})
+		return
+	}
+	if err == nil {
+		texts = append([]compliance.Text{{Source: compliance.SourceScript, Text: script.Text}}, texts...)
+	}
+
+	report := compliance.Check(projectProduct(&project), texts...)
+	c.JSON(200, gin.H{
+		"project_id":       project.ID,
+		"active_script_id": project.ActiveScriptID,
+		"pack":             report.Pack,
+		"blocked":          report.Blocked(),
+		"findings":         report.Findings,
+	})
+}
+
+// logFindings prints a report's findings for the copy it checked
+func logFindings(what string, report compliance.Report) {
+	if len(report.Findings) == 0 {
+		return
+	}
+	fmt.Printf("⚖️  %d compliance finding(s) on %s (%s rules):\n", len(report.Findings), what, report.Pack)
+	for _, finding := range report.Findings {
+		fmt.Printf("   %s %s: %q - %s\n", finding.Severity, finding.Rule, finding.Excerpt, finding.Message)
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/dealshare/hacathon/backend/internal/assets"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/services"
+	"github.com/dealshare/hacathon/backend/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// coverCandidate is a thumbnail of one of the project's current renders
+type coverCandidate struct {
+	models.Asset
+	Render string // Storage key of the render it was taken from
+}
+
+// coverCandidates lists the thumbnails taken from the project's current renders.
+// Thumbnails of videos the project has since replaced are left out.
+func (h *Handlers) coverCandidates(project *models.Project) ([]coverCandidate, error) {
+	renders := map[string]bool{project.GeneratedVideoPath: true}
+	for _, key := range project.GeneratedVideos {
+		renders[key] = true
+	}
+
+	list, err := h.assets.List(project.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	renderKeys := map[string]string{} // Asset ID to storage key
+	for _, asset := range list {
+		if asset.StorageKey != "" && renders[asset.StorageKey] {
+			renderKeys[asset.ID] = asset.StorageKey
+		}
+	}
+
+	candidates := []coverCandidate{}
+	for _, asset := range list {
+		if asset.Kind != models.AssetKindThumbnail {
+			continue
+		}
+		for _, parent := range asset.ParentIDs {
+			if key, ok := renderKeys[parent]; ok {
+				candidates = append(candidates, coverCandidate{Asset: asset, Render: key})
+				break
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// GetCovers lists the thumbnails the project's cover can be chosen from: frames of
+// each render and their title cards
+func (h *Handlers) GetCovers(c *gin.Context) {
+	var project models.Project
+	if !h.loadProject(c, c.Param("id"), &project) {
+		return
+	}
+
+	candidates, err := h.coverCandidates(&project)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch thumbnails"})
+		return
+	}
+
+	covers := make([]gin.H, len(candidates))
+	for i, candidate := range candidates {
+		covers[i] = gin.H{
+			"asset":       candidate.Asset,
+			"url":         storage.PublicPath(candidate.StorageKey),
+			"title_card":  candidate.FrameSeconds == nil,
+			"selected":    candidate.StorageKey == project.CoverPath,
+			"render_path": candidate.Render,
+		}
+	}
+	c.JSON(200, gin.H{"project_id": project.ID, "cover_path": project.CoverPath, "covers": covers})
+}
+
+// SetCover chooses one of the project's thumbnails as its cover. Websites generated
+// afterwards use it as the video poster and Open Graph image, and Instagram
+// uploads as the Reel's cover.
+func (h *Handlers) SetCover(c *gin.Context) {
+	var project models.Project
+	if !h.loadProject(c, c.Param("id"), &project) {
+		return
+	}
+
+	var requestBody struct {
+		AssetID string `json:"asset_id"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil || requestBody.AssetID == "" {
+		c.JSON(400, gin.H{"error": "asset_id is required"})
+		return
+	}
+
+	asset, err := h.assets.Get(project.ID, requestBody.AssetID)
+	if errors.Is(err, assets.ErrNotFound) {
+		c.JSON(404, gin.H{"error": "Asset not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch asset"})
+		return
+	}
+
+	candidates, err := h.coverCandidates(&project)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch thumbnails"})
+		return
+	}
+	found := false
+	for _, candidate := range candidates {
+		found = found || candidate.ID == asset.ID
+	}
+	if !found {
+		c.JSON(400, gin.H{"error": "Asset is not a thumbnail of the project's current video"})
+		return
+	}
+
+	project.CoverPath = asset.StorageKey
+	project.CoverFrameSeconds = asset.FrameSeconds
+	if err := h.db.Model(&project).Select("cover_path", "cover_frame_seconds").Updates(&project).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to save cover"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"project_id":          project.ID,
+		"cover_path":          project.CoverPath,
+		"cover_url":           storage.PublicPath(project.CoverPath),
+		"cover_frame_seconds": project.CoverFrameSeconds,
+	})
+}
+
+// reelCover turns the project's cover into a Reel cover for the posted video. A
+// frame is passed as an offset into the video, so it is taken from the Reel's own
+// render; a title card is sent as an image, preferring the one made for videoKey.
+func (h *Handlers) reelCover(project *models.Project, videoKey string) (services.ReelCover, error) {
+	if project.CoverPath == "" {
+		return services.ReelCover{}, nil
+	}
+	if project.CoverFrameSeconds != nil {
+		return services.ReelCover{FrameOffset: project.CoverFrameSeconds}, nil
+	}
+
+	coverKey := project.CoverPath
+	candidates, err := h.coverCandidates(project)
+	if err != nil {
+		return services.ReelCover{}, err
+	}
+	if chosen := findCandidate(candidates, coverKey); chosen != nil && chosen.Render != videoKey {
+		for _, candidate := range candidates {
+			if candidate.FrameSeconds == nil && candidate.Render == videoKey && candidate.JobID == chosen.JobID {
+				coverKey = candidate.StorageKey
+			}
+		}
+	}
+
+	url, err := h.signer.URL(coverKey, h.signer.TTL())
+	if err != nil {
+		return services.ReelCover{}, err
+	}
+	return services.ReelCover{URL: url}, nil
+}
+
+func findCandidate(candidates []coverCandidate, key string) *coverCandidate {
+	for i := range candidates {
+		if candidates[i].StorageKey == key {
+			return &candidates[i]
+		}
+	}
+	return nil
+}
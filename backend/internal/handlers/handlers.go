@@ -1,36 +1,126 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
+	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
+	"github.com/dealshare/hacathon/backend/internal/assets"
+	"github.com/dealshare/hacathon/backend/internal/auth"
+	"github.com/dealshare/hacathon/backend/internal/compliance"
 	"github.com/dealshare/hacathon/backend/internal/config"
+	"github.com/dealshare/hacathon/backend/internal/events"
+	"github.com/dealshare/hacathon/backend/internal/jobs"
+	"github.com/dealshare/hacathon/backend/internal/layouts"
+	"github.com/dealshare/hacathon/backend/internal/media"
 	"github.com/dealshare/hacathon/backend/internal/models"
 	"github.com/dealshare/hacathon/backend/internal/services"
+	"github.com/dealshare/hacathon/backend/internal/storage"
+	"github.com/dealshare/hacathon/backend/internal/workspaces"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type Handlers struct {
-	db        *gorm.DB
-	config    *config.Config
-	aiService *services.AIService
+	db         *gorm.DB
+	config     *config.Config
+	aiService  *services.AIService
+	workspaces *workspaces.Store
+	storage    storage.Storage
+	signer     *storage.Signer
+	assets     *assets.Store
+	auth       *auth.Authenticator
+	jobs       *jobs.Queue
+	events     *events.Broker
 }
 
-func New(db *gorm.DB, cfg *config.Config) *Handlers {
+func New(db *gorm.DB, cfg *config.Config) (*Handlers, error) {
+	store, err := workspaces.NewStore(db, cfg)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := storage.NewSigner(cfg)
+	if err != nil {
+		return nil, err
+	}
+	blobs, err := storage.New(cfg, signer)
+	if err != nil {
+		return nil, err
+	}
+	authenticator, err := auth.NewAuthenticator(db, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	aiService := services.NewAIService(cfg, blobs, signer)
+	assetStore := assets.NewStore(db, blobs, cfg.StorageCacheDir)
+	broker := events.NewBroker()
+	queue := jobs.NewQueue(db, aiService, store, assetStore, broker, cfg.JobWorkers)
+	queue.Start()
+
 	return &Handlers{
-		db:        db,
-		config:    cfg,
-		aiService: services.NewAIService(cfg),
+		db:         db,
+		config:     cfg,
+		aiService:  aiService,
+		workspaces: store,
+		storage:    blobs,
+		signer:     signer,
+		assets:     assetStore,
+		auth:       authenticator,
+		jobs:       queue,
+		events:     broker,
+	}, nil
+}
+
+// Auth returns the authenticator guarding the API routes
+func (h *Handlers) Auth() *auth.Authenticator {
+	return h.auth
+}
+
+// loadProject loads a project the signed-in user may access. Other users' projects
+// are reported as not found, so their IDs cannot be probed.
+func (h *Handlers) loadProject(c *gin.Context, projectID string, project *models.Project) bool {
+	if err := h.db.First(project, "id = ?", projectID).Error; err != nil || !auth.CurrentUser(c).CanAccessProject(project) {
+		c.JSON(404, gin.H{"error": "Project not found"})
+		return false
 	}
+	return true
+}
+
+// projectConfig resolves the configuration for a project's workspace, responding
+// with an error and returning false if the workspace or its credentials are unusable
+func (h *Handlers) projectConfig(c *gin.Context, workspaceID string) (*config.Config, bool) {
+	cfg, err := h.workspaces.Config(workspaceID)
+	if errors.Is(err, workspaces.ErrNotFound) {
+		c.JSON(400, gin.H{"error": "Workspace not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to load workspace credentials", "details": err.Error()})
+		return nil, false
+	}
+	return cfg, true
 }
 
 // UploadMedia handles product image and person media uploads
 func (h *Handlers) UploadMedia(c *gin.Context) {
+	// Cap the request at one image plus one video (and some room for the form fields)
+	limits := h.uploadLimits()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxImageBytes+limits.MaxVideoBytes+1<<20)
+
 	// Parse multipart form
 	form, err := c.MultipartForm()
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.JSON(413, gin.H{"error": fmt.Sprintf("Upload is too large (at most %d MB in total)", maxBytesErr.Limit>>20)})
+		return
+	}
 	if err != nil {
 		c.JSON(400, gin.H{"error": "Failed to parse form"})
 		return
@@ -57,76 +147,106 @@ func (h *Handlers) UploadMedia(c *gin.Context) {
 	productDescription := c.PostForm("product_description")
 	productCategory := c.PostForm("product_category")
 	productPrice := c.PostForm("product_price")
-
-	// Determine media type
-	personMediaType := "image"
-	if strings.HasSuffix(strings.ToLower(personFile.Filename), ".mp4") ||
-		strings.HasSuffix(strings.ToLower(personFile.Filename), ".mov") ||
-		strings.HasSuffix(strings.ToLower(personFile.Filename), ".avi") {
-		personMediaType = "video"
+	// Projects go to the uploader's workspace; agency-level users may pick one they manage
+	user := auth.CurrentUser(c)
+	workspaceID := c.PostForm("workspace_id")
+	if user.WorkspaceID != "" {
+		if workspaceID != "" && workspaceID != user.WorkspaceID {
+			c.JSON(403, gin.H{"error": "You can only create projects in your own workspace"})
+			return
+		}
+		workspaceID = user.WorkspaceID
+	} else if workspaceID != "" && !user.CanManageWorkspace(workspaceID) {
+		c.JSON(403, gin.H{"error": "Only agency-level publishers can create projects in a workspace"})
+		return
 	}
 
-	// Create upload directories
-	os.MkdirAll(h.config.UploadPath, 0755)
-
-	// Save files
-	productPath := filepath.Join(h.config.UploadPath, productFile.Filename)
-	if err := c.SaveUploadedFile(productFile, productPath); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to save product image"})
+	// The script writer and later steps use the workspace's accounts
+	cfg, ok := h.projectConfig(c, workspaceID)
+	if !ok {
 		return
 	}
 
-	personPath := filepath.Join(h.config.UploadPath, personFile.Filename)
-	if err := c.SaveUploadedFile(personFile, personPath); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to save person media"})
+	// Each project's uploads are stored under uploads/<project ID>/, removed again if the upload fails
+	projectID := uuid.New().String()
+	var storedKeys []string
+	created := false
+	defer func() {
+		if !created {
+			for _, key := range storedKeys {
+				h.storage.Delete(context.Background(), key)
+			}
+		}
+	}()
+
+	// Save files; their types come from their contents, not their names
+	productPath, _, ok := h.saveUpload(c, "product_image", productFile, projectID, "product", media.KindImage)
+	if !ok {
 		return
 	}
-
-	// Generate AI script from product description using Google Gemini Pro - MANDATORY!
-	fmt.Printf("\n" + strings.Repeat("=", 60) + "\n")
-	fmt.Printf("🤖 GEMINI AI SCRIPT GENERATION (REQUIRED)\n")
-	fmt.Printf(strings.Repeat("=", 60) + "\n")
-	
-	geminiAPIKey := os.Getenv("GOOGLE_GEMINI_API_KEY")
-	if geminiAPIKey == "" {
-		geminiAPIKey = os.Getenv("GEMINI_API_KEY") // Fallback to shorter name
+	storedKeys = append(storedKeys, productPath)
+	personKinds := []string{media.KindImage}
+	if h.config.AllowVideoUploads {
+		personKinds = append(personKinds, media.KindVideo)
 	}
-	
-	if geminiAPIKey == "" {
-		fmt.Printf("❌ ERROR: GOOGLE_GEMINI_API_KEY is REQUIRED!\n")
-		fmt.Printf("   Please set GOOGLE_GEMINI_API_KEY in your .env file\n")
-		fmt.Printf("   Get your API key from: https://ai.google.dev/\n")
-		fmt.Printf(strings.Repeat("=", 60) + "\n\n")
-		c.JSON(400, gin.H{
-			"error": "Gemini API key is required. Please set GOOGLE_GEMINI_API_KEY in your environment variables.",
-		})
+	personPath, personInfo, ok := h.saveUpload(c, "person_media", personFile, projectID, "person", personKinds...)
+	if !ok {
 		return
 	}
-	
-	fmt.Printf("🔑 API Key: %s...%s (%d chars)\n", geminiAPIKey[:10], geminiAPIKey[len(geminiAPIKey)-4:], len(geminiAPIKey))
+	storedKeys = append(storedKeys, personPath)
+	personMediaType := personInfo.Kind
+
+	// Generate AI script from product description with the configured script writer - MANDATORY!
+	fmt.Print("\n" + strings.Repeat("=", 60) + "\n")
+	fmt.Printf("🤖 AI SCRIPT GENERATION (REQUIRED)\n")
+	fmt.Print(strings.Repeat("=", 60) + "\n")
+
+	scriptWriter, err := h.aiService.WithConfig(cfg).ScriptWriter()
+	if err != nil {
+		fmt.Printf("❌ ERROR: %v\n", err)
+		fmt.Print(strings.Repeat("=", 60) + "\n\n")
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("✍️  Script writer: %s\n", scriptWriter.Name())
 	fmt.Printf("📦 Product: %s\n", productName)
 	fmt.Printf("📝 Description: %s\n", productDescription)
-	fmt.Printf(strings.Repeat("-", 60) + "\n")
-	
-	geminiService := services.NewGeminiService(geminiAPIKey)
-	generatedScript, err := geminiService.GenerateMarketingScript(productName, productDescription, productCategory, productPrice)
+	fmt.Print(strings.Repeat("-", 60) + "\n")
+
+	generatedScript, err := scriptWriter.GenerateMarketingScript(productName, productDescription, productCategory, productPrice)
 	if err != nil {
-		fmt.Printf("❌ GEMINI FAILED: %v\n", err)
-		fmt.Printf("❌ Cannot proceed without Gemini-generated script!\n")
-		fmt.Printf(strings.Repeat("=", 60) + "\n\n")
+		fmt.Printf("❌ %s FAILED: %v\n", scriptWriter.Name(), err)
+		fmt.Printf("❌ Cannot proceed without an AI-generated script!\n")
+		fmt.Print(strings.Repeat("=", 60) + "\n\n")
 		c.JSON(500, gin.H{
-			"error": fmt.Sprintf("Failed to generate AI script with Gemini: %v. Please check your API key and try again.", err),
+			"error": fmt.Sprintf("Failed to generate AI script with %s: %v. Please check your API key and try again.", scriptWriter.Name(), err),
 		})
 		return
 	}
-	
-	fmt.Printf("✅ GEMINI SUCCESS!\n")
+
+	fmt.Printf("✅ SCRIPT SUCCESS!\n")
 	fmt.Printf("📝 Generated Script:\n")
 	fmt.Printf("   \"%s\"\n", generatedScript)
-	fmt.Printf(strings.Repeat("=", 60) + "\n\n")
+	fmt.Print(strings.Repeat("=", 60) + "\n\n")
+
+	// The script is the project's first variant, and its active one
+	script := &models.Script{
+		ID:              uuid.New().String(),
+		ProjectID:       projectID,
+		Text:            generatedScript,
+		Tone:            "professional",
+		DurationSeconds: 15,
+		Language:        "en",
+		Provider:        scriptWriter.Name(),
+		AuthorID:        user.ID,
+	}
 
 	// Create project record
 	project := &models.Project{
+		ID:                 projectID,
+		WorkspaceID:        workspaceID,
+		OwnerID:            user.ID,
 		ProductImagePath:   productPath,
 		PersonMediaPath:    personPath,
 		PersonMediaType:    personMediaType,
@@ -135,136 +255,523 @@ func (h *Handlers) UploadMedia(c *gin.Context) {
 		ProductCategory:    productCategory,
 		ProductPrice:       productPrice,
 		GeneratedScript:    generatedScript,
-		Status:             "uploaded",
+		ActiveScriptID:     script.ID,
+		Status:             models.ProjectStatusUploaded,
 	}
+	checkScript(project, script)
 
-	if err := h.db.Create(project).Error; err != nil {
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(project).Error; err != nil {
+			return err
+		}
+		return tx.Create(script).Error
+	})
+	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to create project"})
 		return
 	}
+	created = true
+
+	if err := h.assets.RecordInputs(c.Request.Context(), project); err != nil {
+		fmt.Printf("⚠️  Failed to record uploaded assets: %v\n", err)
+	}
 
 	c.JSON(201, gin.H{
 		"project_id":        project.ID,
 		"status":            project.Status,
 		"message":           "Files uploaded successfully",
 		"generated_script":  generatedScript,
+		"active_script_id":  script.ID,
 		"product_name":      productName,
 		"product_description": productDescription,
 	})
 }
 
-// GenerateVideo generates a promotional video from uploaded media
+// GenerateVideo queues promotional video generation for a project.
+// It returns 202 with a job ID immediately; poll GET /api/v1/jobs/:id for progress.
 func (h *Handlers) GenerateVideo(c *gin.Context) {
 	projectID := c.Param("id")
 
-	// Parse request body for video options (NO custom script - always use Gemini-generated)
+	// Parse request body for video options. The script is the project's active one;
+	// it is edited and approved through /projects/:id/scripts
+	var requestBody jobs.VideoOptions
+	c.BindJSON(&requestBody)
+
+	var project models.Project
+	if !h.loadProject(c, projectID, &project) {
+		return
+	}
+
+	// Only approved copy is rendered
+	script, ok := h.requireApprovedScript(c, &project, "generate a video")
+	if !ok {
+		return
+	}
+
+	if err := requestBody.Timing.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	ratios, err := services.AspectRatios(requestBody.AspectRatios)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	requestBody.AspectRatios = ratios
+	if err := services.ValidateCaptions(requestBody.Captions); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	// A mood picks one of its tracks now, so a resumed job keeps the same music
+	track, err := services.PickMusic(requestBody.Music)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	requestBody.Music = track
+
+	// Reject unknown providers before queuing anything
+	selection := h.aiService.ProviderSelection(requestBody.Providers)
+	if err := selection.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	// Pin the providers on the job so a resumed run uses the same ones
+	requestBody.Providers = selection.Pinned()
+
+	// Only one generation per project at a time
+	if active, err := h.jobs.ActiveJob(project.ID); err == nil {
+		c.JSON(409, gin.H{
+			"error":  "Video generation is already in progress for this project",
+			"job_id": active.ID,
+		})
+		return
+	}
+
+	if !project.CanTransition(models.ProjectStatusVideoGenerating) {
+		c.JSON(409, gin.H{"error": fmt.Sprintf("Cannot generate a video while the project is %s", project.Status)})
+		return
+	}
+
+	fmt.Print("\n" + strings.Repeat("=", 60) + "\n")
+	fmt.Printf("🎬 QUEUING VIDEO WITH APPROVED SCRIPT\n")
+	fmt.Print(strings.Repeat("=", 60) + "\n")
+	fmt.Printf("📝 Script: \"%s\"\n", project.GeneratedScript)
+	fmt.Print(strings.Repeat("=", 60) + "\n\n")
+
+	job, err := h.jobs.EnqueueVideo(&project, script, requestBody)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to queue video generation", "details": err.Error()})
+		return
+	}
+
+	c.JSON(202, gin.H{
+		"project_id":    project.ID,
+		"job_id":        job.ID,
+		"status":        project.Status,
+		"aspect_ratios": ratios,
+		"music":         track,
+		"status_url":    fmt.Sprintf("/api/v1/jobs/%s", job.ID),
+	})
+}
+
+// Recomposite queues a new composite of the project's stored avatar and product
+// clips with another layout or timing. Only the compositor runs, so D-ID and
+// RunwayML are not paid again. The latest clips are used unless avatar_asset_id
+// or product_asset_id pick earlier ones. Like GenerateVideo it returns 202 with a job ID.
+func (h *Handlers) Recomposite(c *gin.Context) {
+	projectID := c.Param("id")
+
 	var requestBody struct {
-		ProductVideoStyle string `json:"product_video_style"` // "rotation", "zoom", "pan", "reveal", "auto"
-		Layout            string `json:"layout"`              // "product_main" or "avatar_main"
+		jobs.VideoOptions        // layout, duration, avatar_start and provider overrides
+		AvatarAssetID     string `json:"avatar_asset_id"`
+		ProductAssetID    string `json:"product_asset_id"`
 	}
 	c.BindJSON(&requestBody)
 
 	var project models.Project
-	if err := h.db.First(&project, "id = ?", projectID).Error; err != nil {
-		c.JSON(404, gin.H{"error": "Project not found"})
+	if !h.loadProject(c, projectID, &project) {
+		return
+	}
+	script, ok := h.requireApprovedScript(c, &project, "render a video")
+	if !ok {
 		return
 	}
 
-	// Update status
-	project.Status = "video_generating"
-	h.db.Save(&project)
-
-	// ALWAYS use Gemini-generated script - no custom script override
-	if project.GeneratedScript == "" {
-		project.Status = "uploaded" // Revert status
-		h.db.Save(&project)
-		c.JSON(400, gin.H{
-			"error": "No Gemini-generated script found. Please ensure product description was processed correctly.",
+	layout := requestBody.Layout
+	if layout == "" {
+		layout = "product_main"
+	}
+	if !services.ValidLayout(layout) {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Unknown layout %q (available: %s)", layout, strings.Join(services.Layouts(), ", "))})
+		return
+	}
+	requestBody.Layout = layout
+	if err := requestBody.Timing.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	ratios, err := services.AspectRatios(requestBody.AspectRatios)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	requestBody.AspectRatios = ratios
+	if err := services.ValidateCaptions(requestBody.Captions); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	// A mood picks one of its tracks now, so a resumed job keeps the same music
+	track, err := services.PickMusic(requestBody.Music)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	requestBody.Music = track
+
+	selection := h.aiService.ProviderSelection(requestBody.Providers)
+	if selection.Compositor == "" {
+		c.JSON(400, gin.H{"error": "No compositor is configured; set COMPOSITOR_PROVIDER or pass providers.compositor"})
+		return
+	}
+	// Only the compositor runs, so an avatar-only configuration can still re-composite
+	if err := selection.ValidateComposite(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	requestBody.Providers = selection.Pinned()
+
+	avatarClip, ok := h.storedClip(c, project.ID, models.AssetKindAvatarVideo, requestBody.AvatarAssetID)
+	if !ok {
+		return
+	}
+	productClip, ok := h.storedClip(c, project.ID, models.AssetKindProductVideo, requestBody.ProductAssetID)
+	if !ok {
+		return
+	}
+	if !h.requireClipScript(c, project.ID, avatarClip, script) {
+		return
+	}
+
+	if active, err := h.jobs.ActiveJob(project.ID); err == nil {
+		c.JSON(409, gin.H{
+			"error":  "Video generation is already in progress for this project",
+			"job_id": active.ID,
 		})
 		return
 	}
+	if !project.CanTransition(models.ProjectStatusVideoGenerating) {
+		c.JSON(409, gin.H{"error": fmt.Sprintf("Cannot re-composite while the project is %s", project.Status)})
+		return
+	}
 
-	fmt.Printf("\n" + strings.Repeat("=", 60) + "\n")
-	fmt.Printf("🎬 USING GEMINI-GENERATED SCRIPT FOR VIDEO\n")
-	fmt.Printf(strings.Repeat("=", 60) + "\n")
-	fmt.Printf("📝 Script: \"%s\"\n", project.GeneratedScript)
-	fmt.Printf(strings.Repeat("=", 60) + "\n\n")
-
-	// Generate video using AI service with Gemini-generated script
-	videoPath, err := h.aiService.GenerateVideo(
-		project.ProductImagePath,
-		project.PersonMediaPath,
-		project.PersonMediaType,
-		project.GeneratedScript, // ALWAYS use Gemini script
-		requestBody.ProductVideoStyle,
-		requestBody.Layout,
-	)
+	fmt.Printf("🔁 Queuing re-composite of project %s: layout %s, clips %s + %s\n", project.ID, layout, avatarClip, productClip)
+
+	job, err := h.jobs.EnqueueRecomposite(&project, script, requestBody.VideoOptions, avatarClip, productClip)
 	if err != nil {
-		project.Status = "uploaded" // Revert status
-		h.db.Save(&project)
-		c.JSON(500, gin.H{"error": "Failed to generate video", "details": err.Error()})
+		c.JSON(500, gin.H{"error": "Failed to queue re-composite", "details": err.Error()})
 		return
 	}
 
-	// Update project with generated video path
-	project.GeneratedVideoPath = videoPath
-	project.Status = "video_complete"
-	h.db.Save(&project)
+	c.JSON(202, gin.H{
+		"project_id":    project.ID,
+		"job_id":        job.ID,
+		"status":        project.Status,
+		"layout":        layout,
+		"aspect_ratios": ratios,
+		"music":         track,
+		"avatar_video":  avatarClip,
+		"product_video": productClip,
+		"status_url":    fmt.Sprintf("/api/v1/jobs/%s", job.ID),
+	})
+}
+
+// storedClip finds the storage key of a clip to re-composite: the given asset, or
+// else the project's latest clip of that kind. Projects from before assets were
+// recorded fall back to the clips of their latest finished job.
+func (h *Handlers) storedClip(c *gin.Context, projectID, kind, assetID string) (string, bool) {
+	if assetID != "" {
+		asset, err := h.assets.Get(projectID, assetID)
+		if err != nil || asset.Kind != kind {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("Asset %s is not a %s of this project", assetID, kind)})
+			return "", false
+		}
+		return asset.StorageKey, true
+	}
 
-	c.JSON(200, gin.H{
+	if asset, err := h.assets.Latest(projectID, kind); err == nil {
+		return asset.StorageKey, true
+	}
+
+	var job models.Job
+	err := h.db.Where("project_id = ? AND status = ? AND avatar_video_path != '' AND product_video_path != ''",
+		projectID, models.JobStatusCompleted).Order("created_at DESC").First(&job).Error
+	if err != nil {
+		c.JSON(409, gin.H{"error": "No stored avatar and product clips to re-composite; generate a video first"})
+		return "", false
+	}
+	if kind == models.AssetKindAvatarVideo {
+		return job.AvatarVideoPath, true
+	}
+	return job.ProductVideoPath, true
+}
+
+// requireClipScript responds with 409 and returns false unless the avatar clip
+// speaks the given script. A re-composite keeps the clip's speech but captions the
+// active script, so after the script is edited only a full generation will do.
+func (h *Handlers) requireClipScript(c *gin.Context, projectID, avatarClip string, script *models.Script) bool {
+	var job models.Job
+	err := h.db.Where("project_id = ? AND type = ? AND avatar_video_path = ?", projectID, models.JobTypeVideo, avatarClip).
+		Order("created_at DESC").First(&job).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(500, gin.H{"error": "Failed to fetch the avatar clip's job"})
+		return false
+	}
+	if job.ScriptID == script.ID {
+		return true
+	}
+
+	spoken := "an unknown script"
+	if job.ScriptID != "" {
+		spoken = fmt.Sprintf("script %s (v%d)", job.ScriptID, job.ScriptVersion)
+	}
+	c.JSON(409, gin.H{
+		"error":            fmt.Sprintf("The avatar clip speaks %s, not the active script (v%d); generate a full video to re-record it", spoken, script.Version),
+		"clip_script_id":   job.ScriptID,
+		"active_script_id": script.ID,
+	})
+	return false
+}
+
+// CancelGeneration stops the project's queued or running video job.
+// Queued jobs are cancelled immediately (200); running jobs stop once the current
+// step has been interrupted (202) and the project then moves to "cancelled".
+func (h *Handlers) CancelGeneration(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var project models.Project
+	if !h.loadProject(c, projectID, &project) {
+		return
+	}
+
+	job, err := h.jobs.Cancel(project.ID)
+	if errors.Is(err, jobs.ErrNoActiveJob) {
+		c.JSON(409, gin.H{"error": "No video generation in progress for this project"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to cancel video generation", "details": err.Error()})
+		return
+	}
+
+	if job.Status == models.JobStatusCancelled {
+		c.JSON(200, gin.H{"project_id": project.ID, "job_id": job.ID, "status": "cancelled"})
+		return
+	}
+	c.JSON(202, gin.H{
 		"project_id": project.ID,
-		"video_path": videoPath,
-		"status":     project.Status,
+		"job_id":     job.ID,
+		"status":     "cancelling",
+		"status_url": fmt.Sprintf("/api/v1/jobs/%s", job.ID),
+	})
+}
+
+// GetJob reports the stage, progress, error and result paths of a background job
+func (h *Handlers) GetJob(c *gin.Context) {
+	jobID := c.Param("id")
+
+	var job models.Job
+	if err := h.db.First(&job, "id = ?", jobID).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Job not found"})
+		return
+	}
+	var project models.Project
+	if err := h.db.First(&project, "id = ?", job.ProjectID).Error; err != nil || !auth.CurrentUser(c).CanAccessProject(&project) {
+		c.JSON(404, gin.H{"error": "Job not found"})
+		return
+	}
+
+	c.JSON(200, job)
+}
+
+// CreateEventsTicket issues a ticket that opens the project's event stream for a
+// minute, for EventSource clients that cannot send an Authorization header
+func (h *Handlers) CreateEventsTicket(c *gin.Context) {
+	var project models.Project
+	if !h.loadProject(c, c.Param("id"), &project) {
+		return
+	}
+
+	path := fmt.Sprintf("/api/v1/projects/%s/events", project.ID)
+	ticket, expiresAt, err := h.auth.IssueTicket(auth.CurrentUser(c), path)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to issue ticket"})
+		return
+	}
+	c.JSON(201, gin.H{
+		"ticket":     ticket,
+		"expires_at": expiresAt,
+		"events_url": path + "?ticket=" + url.QueryEscape(ticket),
 	})
 }
 
+// ProjectEvents streams pipeline progress for a project as Server-Sent Events.
+// The first event is a snapshot of the latest job so late subscribers can catch up.
+func (h *Handlers) ProjectEvents(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var project models.Project
+	if !h.loadProject(c, projectID, &project) {
+		return
+	}
+
+	// Subscribe before reading the snapshot so no event falls in between
+	stream, unsubscribe := h.events.Subscribe(project.ID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+
+	snapshot := events.Event{
+		Type:      "snapshot",
+		ProjectID: project.ID,
+		Status:    project.Status,
+		Time:      time.Now(),
+	}
+	var latest models.Job
+	if err := h.db.Where("project_id = ?", project.ID).Order("created_at DESC").First(&latest).Error; err == nil {
+		snapshot.JobID = latest.ID
+		snapshot.Stage = latest.Stage
+		snapshot.Percent = latest.Progress
+		snapshot.Message = latest.Error
+		snapshot.Path = latest.ResultPath
+	}
+	c.SSEvent(snapshot.Type, snapshot)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-stream:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", gin.H{"time": time.Now()})
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// revertStatus moves the project back to status after the step started with it
+// failed with cause. If the project has moved on meanwhile, e.g. a restart settled
+// it, it responds with 409 and returns false.
+func (h *Handlers) revertStatus(c *gin.Context, project *models.Project, status string, cause error) bool {
+	if err := project.Transition(h.db, status); err != nil {
+		c.JSON(409, gin.H{"error": "The step failed and the project changed status meanwhile", "details": fmt.Sprintf("%v; %v", cause, err)})
+		return false
+	}
+	return true
+}
+
 // GenerateWebsite generates a website for the product
 func (h *Handlers) GenerateWebsite(c *gin.Context) {
 	projectID := c.Param("id")
 
 	var project models.Project
-	if err := h.db.First(&project, "id = ?", projectID).Error; err != nil {
-		c.JSON(404, gin.H{"error": "Project not found"})
+	if !h.loadProject(c, projectID, &project) {
+		return
+	}
+
+	cfg, ok := h.projectConfig(c, project.WorkspaceID)
+	if !ok {
+		return
+	}
+	kit, err := h.workspaces.BrandKit(project.WorkspaceID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to load the brand kit", "details": err.Error()})
 		return
 	}
 
 	// Update status
-	project.Status = "website_generating"
-	h.db.Save(&project)
+	previousStatus := project.Status
+	if err := project.Transition(h.db, models.ProjectStatusWebsiteGenerating); err != nil {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Generate website
-	websitePath, err := h.aiService.GenerateWebsite(project)
+	websitePath, report, err := h.aiService.WithConfig(cfg).GenerateWebsite(project, kit)
 	if err != nil {
-		project.Status = "video_complete" // Revert status
-		h.db.Save(&project)
+		if !h.revertStatus(c, &project, previousStatus, err) {
+			return
+		}
+		var blocked *compliance.BlockedError
+		if errors.As(err, &blocked) {
+			c.JSON(422, gin.H{"error": "The website copy breaks compliance rules", "details": blocked.Error(), "findings": report.Findings})
+			return
+		}
 		c.JSON(500, gin.H{"error": "Failed to generate website", "details": err.Error()})
 		return
 	}
 
 	// Update project
 	project.WebsitePath = websitePath
-	project.Status = "website_complete"
-	h.db.Save(&project)
+	if err := project.Transition(h.db, models.ProjectStatusWebsiteComplete, "website_path"); err != nil {
+		c.JSON(409, gin.H{"error": "The project changed status while its website was generated, so it was not saved", "details": err.Error()})
+		return
+	}
+
+	website := &models.Asset{ProjectID: project.ID, Kind: models.AssetKindWebsite, StorageKey: websitePath + "/index.html"}
+	if err := h.assets.RecordInputs(c.Request.Context(), &project); err != nil {
+		fmt.Printf("⚠️  Failed to record project inputs: %v\n", err)
+	} else if err := h.assets.Record(c.Request.Context(), website, project.ProductImagePath, project.VideoFor(layouts.Landscape)); err != nil {
+		fmt.Printf("⚠️  Failed to record website asset: %v\n", err)
+	}
 
-	fmt.Printf("\n" + strings.Repeat("=", 60) + "\n")
+	fmt.Print("\n" + strings.Repeat("=", 60) + "\n")
 	fmt.Printf("✅ WEBSITE GENERATION COMPLETE\n")
-	fmt.Printf(strings.Repeat("=", 60) + "\n")
+	fmt.Print(strings.Repeat("=", 60) + "\n")
 	fmt.Printf("📁 Website Path: %s\n", websitePath)
 	fmt.Printf("🆔 Project ID: %s\n", project.ID)
 	fmt.Printf("📊 Status: %s\n", project.Status)
-	fmt.Printf(strings.Repeat("=", 60) + "\n\n")
+	fmt.Print(strings.Repeat("=", 60) + "\n\n")
 
 	c.JSON(200, gin.H{
 		"project_id":   project.ID,
 		"website_path": websitePath,
 		"status":       project.Status,
+		"findings":     report.Findings,
 	})
 }
 
-// GetProjects lists all projects
+// GetProjects lists the projects the user can access (see User.CanAccessProject),
+// optionally narrowed to one workspace with ?workspace_id=
 func (h *Handlers) GetProjects(c *gin.Context) {
+	user := auth.CurrentUser(c)
+	query := h.db.Order("created_at DESC")
+	switch {
+	case user.WorkspaceID != "":
+		query = query.Where("owner_id = ? OR workspace_id = ?", user.ID, user.WorkspaceID)
+	case user.ManagesEveryWorkspace():
+		query = query.Where("owner_id = ? OR workspace_id != ''", user.ID)
+	default:
+		query = query.Where("owner_id = ?", user.ID)
+	}
+	if workspaceID := c.Query("workspace_id"); workspaceID != "" {
+		query = query.Where("workspace_id = ?", workspaceID)
+	}
+
 	var projects []models.Project
-	if err := h.db.Order("created_at DESC").Find(&projects).Error; err != nil {
+	if err := query.Find(&projects).Error; err != nil {
 		c.JSON(500, gin.H{"error": "Failed to fetch projects"})
 		return
 	}
@@ -277,8 +784,7 @@ func (h *Handlers) GetProject(c *gin.Context) {
 	projectID := c.Param("id")
 
 	var project models.Project
-	if err := h.db.First(&project, "id = ?", projectID).Error; err != nil {
-		c.JSON(404, gin.H{"error": "Project not found"})
+	if !h.loadProject(c, projectID, &project) {
 		return
 	}
 
@@ -298,8 +804,7 @@ func (h *Handlers) UploadToInstagram(c *gin.Context) {
 	c.BindJSON(&requestBody)
 
 	var project models.Project
-	if err := h.db.First(&project, "id = ?", projectID).Error; err != nil {
-		c.JSON(404, gin.H{"error": "Project not found"})
+	if !h.loadProject(c, projectID, &project) {
 		return
 	}
 
@@ -308,51 +813,77 @@ func (h *Handlers) UploadToInstagram(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "No generated video found. Please generate video first."})
 		return
 	}
+	if _, ok := h.requireApprovedScript(c, &project, "publish to Instagram"); !ok {
+		return
+	}
+
+	cfg, ok := h.projectConfig(c, project.WorkspaceID)
+	if !ok {
+		return
+	}
+
+	// Instagram downloads the video from a signed URL on this server.
+	// Reels are vertical, so the 9:16 render is posted when there is one.
+	videoKey, ok := storage.KeyFor(h.storage, project.VideoFor(layouts.Portrait), h.config.StorageCacheDir)
+	if !ok {
+		c.JSON(500, gin.H{"error": "Generated video is not in storage"})
+		return
+	}
+	videoURL, err := h.signer.URL(videoKey, h.signer.TTL())
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to sign video URL", "details": err.Error()})
+		return
+	}
 
-	// Validate Instagram credentials
-	accessToken := requestBody.InstagramAccessToken
+	cover, err := h.reelCover(&project, videoKey)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to prepare cover image", "details": err.Error()})
+		return
+	}
+
+	// Validate Instagram credentials. The token and user ID name one account, so a
+	// request carries both or neither and then the workspace's (or the server's) pair is used.
+	accessToken, instagramUserID := requestBody.InstagramAccessToken, requestBody.InstagramUserID
+	if (accessToken == "") != (instagramUserID == "") {
+		c.JSON(400, gin.H{"error": "Instagram access token and user ID must be given together"})
+		return
+	}
 	if accessToken == "" {
-		// Try to get from environment variable
-		accessToken = os.Getenv("INSTAGRAM_ACCESS_TOKEN")
+		accessToken, instagramUserID = cfg.InstagramAccessToken, cfg.InstagramUserID
 	}
 	if accessToken == "" {
-		c.JSON(400, gin.H{"error": "Instagram access token is required"})
+		c.JSON(400, gin.H{"error": "Instagram access token and user ID are required"})
 		return
 	}
 
-	instagramUserID := requestBody.InstagramUserID
-	if instagramUserID == "" {
-		instagramUserID = os.Getenv("INSTAGRAM_USER_ID")
-	}
-	if instagramUserID == "" {
-		c.JSON(400, gin.H{"error": "Instagram user ID is required"})
+	// Generate caption, which is posted as it is and so must pass the compliance rules
+	caption := projectCaption(&project, requestBody.CustomCaption)
+	report := compliance.Check(projectProduct(&project), compliance.Text{Source: compliance.SourceCaption, Text: caption})
+	if err := report.Err(); err != nil {
+		logFindings("Instagram caption", report)
+		c.JSON(422, gin.H{"error": "The caption breaks compliance rules", "details": err.Error(), "findings": report.Findings})
 		return
 	}
 
 	// Update status
-	project.Status = "instagram_uploading"
-	h.db.Save(&project)
-
-	// Generate caption
-	caption := requestBody.CustomCaption
-	if caption == "" {
-		caption = services.GenerateInstagramCaption(
-			project.ProductName,
-			project.ProductDescription,
-			project.ProductPrice,
-		)
+	previousStatus := project.Status
+	if err := project.Transition(h.db, models.ProjectStatusInstagramUploading); err != nil {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
 	}
 
 	// Create Instagram service and upload
 	instagramService := services.NewInstagramService(accessToken)
 	postID, postURL, err := instagramService.UploadVideoToInstagram(
-		project.GeneratedVideoPath,
+		videoURL,
 		caption,
 		instagramUserID,
+		cover,
 	)
 	if err != nil {
-		project.Status = "video_complete" // Revert status
-		h.db.Save(&project)
+		if !h.revertStatus(c, &project, previousStatus, err) {
+			return
+		}
 		c.JSON(500, gin.H{"error": "Failed to upload to Instagram", "details": err.Error()})
 		return
 	}
@@ -360,14 +891,25 @@ func (h *Handlers) UploadToInstagram(c *gin.Context) {
 	// Update project with Instagram post details
 	project.InstagramPostID = postID
 	project.InstagramPostURL = postURL
-	project.Status = "instagram_posted"
-	h.db.Save(&project)
+	postColumns := []string{"instagram_post_id", "instagram_post_url"}
+	if err := project.Transition(h.db, models.ProjectStatusInstagramPosted, postColumns...); err != nil {
+		// The reel is live either way, so the post is still recorded
+		h.db.Model(&project).Select(postColumns).Updates(&project)
+		c.JSON(409, gin.H{
+			"error":              "The reel was posted, but the project changed status meanwhile",
+			"details":            err.Error(),
+			"instagram_post_id":  postID,
+			"instagram_post_url": postURL,
+		})
+		return
+	}
 
 	c.JSON(200, gin.H{
 		"project_id":          project.ID,
 		"instagram_post_id":   postID,
 		"instagram_post_url":  postURL,
 		"caption":             caption,
+		"findings":            report.Findings,
 		"status":              project.Status,
 		"message":             "Video successfully posted to Instagram!",
 	})
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dealshare/hacathon/backend/internal/assets"
+	"github.com/dealshare/hacathon/backend/internal/auth"
+	"github.com/dealshare/hacathon/backend/internal/config"
+	"github.com/dealshare/hacathon/backend/internal/database"
+	"github.com/dealshare/hacathon/backend/internal/events"
+	"github.com/dealshare/hacathon/backend/internal/jobs"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/services"
+	"github.com/dealshare/hacathon/backend/internal/storage"
+	"github.com/dealshare/hacathon/backend/internal/workspaces"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestHandlers returns handlers over a fresh database for an avatar-only
+// configuration. The job queue is not started, so queued jobs stay queued.
+func newTestHandlers(t *testing.T) *Handlers {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "app.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	cfg := &config.Config{
+		AIProvider:         "mock",
+		UploadPath:         filepath.Join(dir, "uploads"),
+		GeneratedVideoPath: filepath.Join(dir, "videos"),
+		WebsitePath:        filepath.Join(dir, "websites"),
+		StorageBackend:     "local",
+		StorageCacheDir:    filepath.Join(dir, "cache"),
+		PublicBaseURL:      "http://localhost:8080",
+		JWTSecret:          strings.Repeat("s", 32),
+		SessionTTLHours:    1,
+	}
+	store, err := workspaces.NewStore(db, cfg)
+	if err != nil {
+		t.Fatalf("workspaces: %v", err)
+	}
+	signer, err := storage.NewSigner(cfg)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	blobs, err := storage.New(cfg, signer)
+	if err != nil {
+		t.Fatalf("storage: %v", err)
+	}
+	authenticator, err := auth.NewAuthenticator(db, cfg)
+	if err != nil {
+		t.Fatalf("authenticator: %v", err)
+	}
+
+	aiService := services.NewAIService(cfg, blobs, signer)
+	assetStore := assets.NewStore(db, blobs, cfg.StorageCacheDir)
+	broker := events.NewBroker()
+	return &Handlers{
+		db:         db,
+		config:     cfg,
+		aiService:  aiService,
+		workspaces: store,
+		storage:    blobs,
+		signer:     signer,
+		assets:     assetStore,
+		auth:       authenticator,
+		jobs:       jobs.NewQueue(db, aiService, store, assetStore, broker, 1),
+		events:     broker,
+	}
+}
+
+// seedRenderedProject creates an editor and a project of theirs with an approved
+// script and a finished video job, whose clips a re-composite can reuse
+func seedRenderedProject(t *testing.T, h *Handlers) (*models.Project, string) {
+	t.Helper()
+	user := &models.User{Email: "editor@example.com", Role: models.RoleEditor}
+	h.db.Create(user)
+	token, _, err := h.auth.IssueSession(user)
+	if err != nil {
+		t.Fatalf("IssueSession() error = %v", err)
+	}
+
+	project := &models.Project{OwnerID: user.ID, ProductName: "Kettle", Status: models.ProjectStatusVideoComplete}
+	h.db.Create(project)
+	script := &models.Script{ProjectID: project.ID, Text: "Meet the kettle.", Status: models.ScriptStatusApproved}
+	h.db.Create(script)
+	h.db.Model(project).Update("active_script_id", script.ID)
+
+	h.db.Create(&models.Job{
+		ProjectID:        project.ID,
+		Type:             models.JobTypeVideo,
+		Status:           models.JobStatusCompleted,
+		ScriptID:         script.ID,
+		ScriptVersion:    script.Version,
+		AvatarVideoPath:  "videos/avatar.mp4",
+		ProductVideoPath: "videos/product.mp4",
+	})
+	return project, token
+}
+
+func TestRecompositeProviders(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantErr  string
+	}{
+		// The configuration has no product video provider, which a re-composite never runs
+		{"compositor only", `{"providers":{"compositor":"ffmpeg"}}`, 202, ""},
+		{"no compositor", `{}`, 400, "No compositor is configured"},
+		{"unknown compositor", `{"providers":{"compositor":"blender"}}`, 400, `unknown compositor provider "blender"`},
+		{"unknown media host", `{"providers":{"compositor":"ffmpeg","media_host":"ftp"}}`, 400, `unknown media host provider "ftp"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandlers(t)
+			project, token := seedRenderedProject(t, h)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/api/v1/projects/:id/recomposite", h.auth.Middleware(), h.Recomposite)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/projects/"+project.ID+"/recomposite", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			var response struct {
+				Error string `json:"error"`
+				JobID string `json:"job_id"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			if !strings.Contains(response.Error, tt.wantErr) {
+				t.Errorf("error = %q, want one mentioning %q", response.Error, tt.wantErr)
+			}
+			if tt.wantCode == 202 && response.JobID == "" {
+				t.Errorf("response has no job_id: %s", rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"github.com/dealshare/hacathon/backend/internal/music"
+	"github.com/gin-gonic/gin"
+)
+
+// GetMusic lists the background music library. A video's "music" option takes
+// one of the track names or moods.
+func (h *Handlers) GetMusic(c *gin.Context) {
+	c.JSON(200, gin.H{"tracks": music.Tracks(), "moods": music.Moods()})
+}
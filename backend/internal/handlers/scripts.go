@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dealshare/hacathon/backend/internal/auth"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetScripts lists the project's script variants, newest first
+func (h *Handlers) GetScripts(c *gin.Context) {
+	var project models.Project
+	if !h.loadProject(c, c.Param("id"), &project) {
+		return
+	}
+
+	var scripts []models.Script
+	if err := h.db.Where("project_id = ?", project.ID).Order("created_at DESC").Find(&scripts).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch scripts"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"project_id":       project.ID,
+		"active_script_id": project.ActiveScriptID,
+		"scripts":          scripts,
+		"tones":            services.ScriptTones,
+		"durations":        services.ScriptDurations,
+		"languages":        services.ScriptLanguages,
+	})
+}
+
+// GenerateScripts writes new script variants for the project with the options in
+// the body (tone, duration, audience, language and how many). They are stored
+// alongside the earlier ones; SetActiveScript picks the one videos are made from.
+func (h *Handlers) GenerateScripts(c *gin.Context) {
+	var project models.Project
+	if !h.loadProject(c, c.Param("id"), &project) {
+		return
+	}
+
+	var requestBody services.ScriptOptions
+	c.BindJSON(&requestBody)
+	opts, err := requestBody.WithDefaults()
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	cfg, ok := h.projectConfig(c, project.WorkspaceID)
+	if !ok {
+		return
+	}
+	scriptWriter, err := h.aiService.WithConfig(cfg).ScriptWriter()
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	texts, err := scriptWriter.GenerateScriptVariants(project.ProductName, project.ProductDescription, project.ProductCategory, project.ProductPrice, opts)
+	if err != nil {
+		fmt.Printf("❌ %s script variants failed: %v\n", scriptWriter.Name(), err)
+		c.JSON(502, gin.H{"error": fmt.Sprintf("Failed to generate scripts with %s: %v", scriptWriter.Name(), err)})
+		return
+	}
+
+	scripts := make([]models.Script, len(texts))
+	for i, text := range texts {
+		scripts[i] = models.Script{
+			ProjectID:       project.ID,
+			Text:            text,
+			Tone:            opts.Tone,
+			DurationSeconds: opts.Duration,
+			Audience:        opts.Audience,
+			Language:        opts.Language,
+			Provider:        scriptWriter.Name(),
+			AuthorID:        auth.CurrentUser(c).ID,
+		}
+		checkScript(&project, &scripts[i])
+	}
+	if err := h.db.Create(&scripts).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to save scripts"})
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"project_id":       project.ID,
+		"active_script_id": project.ActiveScriptID,
+		"scripts":          scripts,
+	})
+}
+
+// SetActiveScript picks the script variant the project's next videos are generated
+// from. It cannot change while a video is being generated.
+func (h *Handlers) SetActiveScript(c *gin.Context) {
+	var project models.Project
+	if !h.loadProject(c, c.Param("id"), &project) {
+		return
+	}
+
+	var requestBody struct {
+		ScriptID string `json:"script_id"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil || requestBody.ScriptID == "" {
+		c.JSON(400, gin.H{"error": "script_id is required"})
+		return
+	}
+
+	var script models.Script
+	err := h.db.First(&script, "id = ? AND project_id = ?", requestBody.ScriptID, project.ID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "Script not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch script"})
+		return
+	}
+
+	if active, err := h.jobs.ActiveJob(project.ID); err == nil {
+		c.JSON(409, gin.H{
+			"error":  "Cannot change the script while a video is being generated",
+			"job_id": active.ID,
+		})
+		return
+	}
+
+	project.ActiveScriptID = script.ID
+	project.GeneratedScript = script.Text
+	if err := h.db.Model(&project).Select("active_script_id", "generated_script").Updates(&project).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to save active script"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"project_id":       project.ID,
+		"active_script_id": project.ActiveScriptID,
+		"generated_script": project.GeneratedScript,
+		"script":           script,
+	})
+}
+
+// maxScriptLength caps edited scripts, in characters; a minute read aloud is far shorter
+const maxScriptLength = 2000
+
+// loadScript loads the :scriptId script of the project, responding with an error
+// and returning false if there is none
+func (h *Handlers) loadScript(c *gin.Context, projectID string, script *models.Script) bool {
+	err := h.db.First(script, "id = ? AND project_id = ?", c.Param("scriptId"), projectID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "Script not found"})
+		return false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch script"})
+		return false
+	}
+	return true
+}
+
+// requireApprovedScript returns the project's active script if it is approved, and
+// otherwise responds with 409 and returns false. action completes "...before you
+// can", e.g. "generate a video".
+func (h *Handlers) requireApprovedScript(c *gin.Context, project *models.Project, action string) (*models.Script, bool) {
+	var script models.Script
+	err := h.db.First(&script, "id = ? AND project_id = ?", project.ActiveScriptID, project.ID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(409, gin.H{"error": fmt.Sprintf("The project has no active script; generate or pick one, and have it approved before you can %s", action)})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch script"})
+		return nil, false
+	}
+	if !script.Approved() {
+		c.JSON(409, gin.H{
+			"error":         fmt.Sprintf("The project's script must be approved before you can %s (it is %s)", action, script.Status),
+			"script_id":     script.ID,
+			"script_status": script.Status,
+		})
+		return nil, false
+	}
+	return &script, true
+}
+
+// GetScriptVersions lists every version of a script, oldest first
+func (h *Handlers) GetScriptVersions(c *gin.Context) {
+	var project models.Project
+	if !h.loadProject(c, c.Param("id"), &project) {
+		return
+	}
+	var script models.Script
+	if !h.loadScript(c, project.ID, &script) {
+		return
+	}
+
+	var versions []models.Script
+	if err := h.db.Where("root_id = ?", script.RootID).Order("version").Find(&versions).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch script versions"})
+		return
+	}
+	c.JSON(200, gin.H{"project_id": project.ID, "root_id": script.RootID, "active_script_id": project.ActiveScriptID, "versions": versions})
+}
+
+// EditScript saves edited text as a new draft version of a script; the version
+// edited is kept as it was. Editing the active script makes the new version active,
+// so it has to be approved before the next video.
+func (h *Handlers) EditScript(c *gin.Context) {
+	var project models.Project
+	if !h.loadProject(c, c.Param("id"), &project) {
+		return
+	}
+	var script models.Script
+	if !h.loadScript(c, project.ID, &script) {
+		return
+	}
+
+	var requestBody struct {
+		Text string `json:"text"`
+	}
+	c.BindJSON(&requestBody)
+	text := strings.TrimSpace(requestBody.Text)
+	if text == "" {
+		c.JSON(400, gin.H{"error": "text is required"})
+		return
+	}
+	if len([]rune(text)) > maxScriptLength {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("text must be at most %d characters", maxScriptLength)})
+		return
+	}
+	if text == script.Text {
+		c.JSON(400, gin.H{"error": "text is unchanged"})
+		return
+	}
+
+	activate := project.ActiveScriptID == script.ID
+	if activate {
+		if active, err := h.jobs.ActiveJob(project.ID); err == nil {
+			c.JSON(409, gin.H{
+				"error":  "Cannot change the script while a video is being generated",
+				"job_id": active.ID,
+			})
+			return
+		}
+	}
+
+	version := models.Script{
+		ProjectID:       project.ID,
+		RootID:          script.RootID,
+		PreviousID:      script.ID,
+		Text:            text,
+		Tone:            script.Tone,
+		DurationSeconds: script.DurationSeconds,
+		Audience:        script.Audience,
+		Language:        script.Language,
+		AuthorID:        auth.CurrentUser(c).ID,
+	}
+	checkScript(&project, &version)
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Numbered after the latest version, which need not be the one edited
+		var latest int
+		if err := tx.Model(&models.Script{}).Where("root_id = ?", script.RootID).Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		version.Version = latest + 1
+		if err := tx.Create(&version).Error; err != nil {
+			return err
+		}
+		if !activate {
+			return nil
+		}
+		project.ActiveScriptID = version.ID
+		project.GeneratedScript = version.Text
+		return tx.Model(&project).Select("active_script_id", "generated_script").Updates(&project).Error
+	})
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to save script", "details": err.Error()})
+		return
+	}
+
+	c.JSON(201, gin.H{"project_id": project.ID, "active_script_id": project.ActiveScriptID, "script": version})
+}
+
+// SubmitScript sends a draft script for review
+func (h *Handlers) SubmitScript(c *gin.Context) {
+	now := time.Now()
+	h.transitionScript(c, models.ScriptStatusInReview, map[string]interface{}{"submitted_at": &now})
+}
+
+// WithdrawScript takes a script out of review, back to draft
+func (h *Handlers) WithdrawScript(c *gin.Context) {
+	h.transitionScript(c, models.ScriptStatusDraft, map[string]interface{}{"submitted_at": nil})
+}
+
+// ReviewScript approves or rejects a script in review. A rejection needs a comment
+// saying what to change; a script that breaks a hard compliance rule cannot be
+// approved, and nobody can approve a script they wrote.
+func (h *Handlers) ReviewScript(c *gin.Context) {
+	var requestBody struct {
+		Decision string `json:"decision"` // "approve" or "reject"
+		Comment  string `json:"comment"`
+	}
+	c.BindJSON(&requestBody)
+	comment := strings.TrimSpace(requestBody.Comment)
+
+	var to string
+	switch requestBody.Decision {
+	case "approve":
+		to = models.ScriptStatusApproved
+	case "reject":
+		to = models.ScriptStatusRejected
+		if comment == "" {
+			c.JSON(400, gin.H{"error": "A comment is required to reject a script"})
+			return
+		}
+	default:
+		c.JSON(400, gin.H{"error": `decision must be "approve" or "reject"`})
+		return
+	}
+
+	now := time.Now()
+	h.transitionScript(c, to, map[string]interface{}{
+		"reviewer_id":    auth.CurrentUser(c).ID,
+		"review_comment": comment,
+		"reviewed_at":    &now,
+	})
+}
+
+// transitionScript moves the :scriptId script to another review status
+func (h *Handlers) transitionScript(c *gin.Context, to string, changes map[string]interface{}) {
+	var project models.Project
+	if !h.loadProject(c, c.Param("id"), &project) {
+		return
+	}
+	var script models.Script
+	if !h.loadScript(c, project.ID, &script) {
+		return
+	}
+
+	if !script.CanTransition(to) {
+		c.JSON(409, gin.H{"error": fmt.Sprintf("Script is %s and cannot be moved to %s", script.Status, to), "status": script.Status})
+		return
+	}
+	if to == models.ScriptStatusApproved {
+		user := auth.CurrentUser(c)
+		if user.ID == script.AuthorID {
+			c.JSON(403, gin.H{"error": "You cannot approve a script you wrote; another reviewer has to approve it"})
+			return
+		}
+		changes["approved_by"] = user.ID
+	}
+
+	// The rules or the product's price may have changed since the script was written
+	report := checkScript(&project, &script)
+	if err := h.db.Model(&script).Select("findings").Updates(&script).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to save compliance findings"})
+		return
+	}
+	if to == models.ScriptStatusApproved && report.Blocked() {
+		logFindings("script "+script.ID, report)
+		c.JSON(409, gin.H{
+			"error":    "The script breaks compliance rules and cannot be approved; edit it and submit the new version",
+			"details":  report.Err().Error(),
+			"findings": report.Findings,
+		})
+		return
+	}
+
+	if err := script.Transition(h.db, to, changes); err != nil {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("📝 Script %s (v%d) of project %s is now %s\n", script.ID, script.Version, project.ID, script.Status)
+	c.JSON(200, gin.H{"project_id": project.ID, "active_script_id": project.ActiveScriptID, "script": script})
+}
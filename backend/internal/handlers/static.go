@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dealshare/hacathon/backend/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// mediaURLTTL is how long the storage URLs that static routes redirect to stay valid
+const mediaURLTTL = time.Hour
+
+// ServeStored serves the stored objects under a key prefix at a static route, e.g.
+// /static/generated/videos/<name> serves videos/<name>. Remote backends redirect
+// media to a signed URL; websites and HLS playlists are proxied so their relative
+// links keep working.
+func (h *Handlers) ServeStored(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := prefix + strings.TrimPrefix(c.Param("path"), "/")
+		if !storage.ValidKey(key) {
+			c.JSON(404, gin.H{"error": "File not found"})
+			return
+		}
+
+		if _, local := h.storage.(*storage.Local); !local && prefix != storage.PrefixWebsites && path.Ext(key) != ".m3u8" {
+			url, err := h.storage.SignedURL(c.Request.Context(), key, mediaURLTTL)
+			if err != nil {
+				c.JSON(500, gin.H{"error": "Failed to sign file URL"})
+				return
+			}
+			c.Redirect(302, url)
+			return
+		}
+
+		h.serveObject(c, key)
+	}
+}
+
+// ServeSignedMedia serves any stored object to holders of a signed URL from
+// storage.Signer, e.g. vendors fetching clips. The signature replaces authentication.
+func (h *Handlers) ServeSignedMedia(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("path"), "/")
+	if !storage.ValidKey(key) {
+		c.JSON(404, gin.H{"error": "File not found"})
+		return
+	}
+	if err := h.signer.Verify(key, c.Query("expires"), c.Query("signature"), time.Now()); err != nil {
+		c.JSON(403, gin.H{"error": err.Error()})
+		return
+	}
+	h.serveObject(c, key)
+}
+
+// serveObject streams a stored object, with range support where the backend allows it
+func (h *Handlers) serveObject(c *gin.Context, key string) {
+	body, object, err := h.storage.Get(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(404, gin.H{"error": "File not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer body.Close()
+
+	c.Header("Content-Type", object.ContentType)
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		// Supports range requests, which video players rely on
+		http.ServeContent(c.Writer, c.Request, path.Base(key), object.ModTime, seeker)
+		return
+	}
+	c.DataFromReader(200, object.Size, object.ContentType, body, nil)
+}
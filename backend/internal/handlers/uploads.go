@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+
+	"github.com/dealshare/hacathon/backend/internal/media"
+	"github.com/dealshare/hacathon/backend/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// uploadLimits are the configured bounds for uploaded media
+func (h *Handlers) uploadLimits() media.Limits {
+	return media.Limits{
+		MaxImageBytes:   int64(h.config.MaxImageUploadMB) << 20,
+		MaxVideoBytes:   int64(h.config.MaxVideoUploadMB) << 20,
+		MinDimension:    h.config.MinMediaDimension,
+		MaxDimension:    h.config.MaxMediaDimension,
+		MaxVideoSeconds: float64(h.config.MaxVideoSeconds),
+	}
+}
+
+// saveUpload validates an uploaded file by its content and stores it under
+// uploads/<projectID>/ with a content-addressed name (e.g. product-3f2a9c….jpg)
+// and returns its storage key. The client's file name is never used, so uploads
+// cannot collide or escape the project. On failure it responds with a 4xx/5xx.
+func (h *Handlers) saveUpload(c *gin.Context, field string, header *multipart.FileHeader, projectID, name string, kinds ...string) (string, *media.Info, bool) {
+	limits := h.uploadLimits()
+	maxBytes := limits.MaxImageBytes
+	for _, kind := range kinds {
+		if kind == media.KindVideo {
+			maxBytes = limits.MaxVideoBytes
+		}
+	}
+	if header.Size > maxBytes {
+		c.JSON(413, gin.H{"error": fmt.Sprintf("%s is too large (at most %d MB)", field, maxBytes>>20)})
+		return "", nil, false
+	}
+
+	tmpPath, err := copyToTemp(header)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to save %s", field)})
+		return "", nil, false
+	}
+	defer os.Remove(tmpPath)
+
+	info, err := media.Inspect(tmpPath, limits, kinds...)
+	if err != nil {
+		if mediaErr, ok := media.AsError(err); ok {
+			c.JSON(mediaErr.Status, gin.H{"error": fmt.Sprintf("%s: %s", field, mediaErr.Message)})
+		} else {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to inspect %s", field), "details": err.Error()})
+		}
+		return "", nil, false
+	}
+
+	key := storage.UploadKey(projectID, fmt.Sprintf("%s-%s%s", name, info.SHA256[:16], info.Ext))
+	if err := storage.PutFile(c.Request.Context(), h.storage, key, tmpPath); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to save %s", field), "details": err.Error()})
+		return "", nil, false
+	}
+	return key, info, true
+}
+
+// copyToTemp writes the upload to a temporary file for inspection
+func copyToTemp(header *multipart.FileHeader) (string, error) {
+	src, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
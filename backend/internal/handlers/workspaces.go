@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/dealshare/hacathon/backend/internal/auth"
+	"github.com/dealshare/hacathon/backend/internal/config"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/workspaces"
+	"github.com/gin-gonic/gin"
+)
+
+// workspaceResponse never includes credential values, only which ones are set
+func (h *Handlers) workspaceResponse(workspace *models.Workspace) gin.H {
+	response := gin.H{
+		"id":         workspace.ID,
+		"name":       workspace.Name,
+		"created_at": workspace.CreatedAt,
+		"updated_at": workspace.UpdatedAt,
+	}
+	creds, err := h.workspaces.Credentials(workspace)
+	if err != nil {
+		response["credentials_error"] = err.Error()
+		return response
+	}
+	response["credentials"] = creds.Names()
+	return response
+}
+
+// loadWorkspace loads a workspace the signed-in user may see: their own, or any for agency-level users
+func (h *Handlers) loadWorkspace(c *gin.Context, workspaceID string) (*models.Workspace, bool) {
+	user := auth.CurrentUser(c)
+	if user.WorkspaceID != "" && user.WorkspaceID != workspaceID {
+		c.JSON(404, gin.H{"error": "Workspace not found"})
+		return nil, false
+	}
+
+	workspace, err := h.workspaces.Get(workspaceID)
+	if errors.Is(err, workspaces.ErrNotFound) {
+		c.JSON(404, gin.H{"error": "Workspace not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch workspace"})
+		return nil, false
+	}
+	return workspace, true
+}
+
+// CreateWorkspace creates a workspace, optionally with its vendor credentials.
+// Only agency-level publishers, who belong to no workspace, can create workspaces.
+func (h *Handlers) CreateWorkspace(c *gin.Context) {
+	if auth.CurrentUser(c).WorkspaceID != "" {
+		c.JSON(403, gin.H{"error": "Only agency-level publishers can create workspaces"})
+		return
+	}
+
+	var requestBody struct {
+		Name        string             `json:"name"`
+		Credentials config.Credentials `json:"credentials"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil || requestBody.Name == "" {
+		c.JSON(400, gin.H{"error": "name is required"})
+		return
+	}
+
+	workspace := &models.Workspace{Name: requestBody.Name}
+	if err := h.workspaces.SetCredentials(workspace, requestBody.Credentials); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.db.Create(workspace).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create workspace"})
+		return
+	}
+
+	c.JSON(201, h.workspaceResponse(workspace))
+}
+
+// GetWorkspaces lists the workspaces the user can see
+func (h *Handlers) GetWorkspaces(c *gin.Context) {
+	query := h.db.Order("name")
+	if user := auth.CurrentUser(c); user.WorkspaceID != "" {
+		query = query.Where("id = ?", user.WorkspaceID)
+	}
+
+	var list []models.Workspace
+	if err := query.Find(&list).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch workspaces"})
+		return
+	}
+
+	response := make([]gin.H, len(list))
+	for i := range list {
+		response[i] = h.workspaceResponse(&list[i])
+	}
+	c.JSON(200, gin.H{"workspaces": response})
+}
+
+// GetWorkspace gets a single workspace by ID
+func (h *Handlers) GetWorkspace(c *gin.Context) {
+	workspace, ok := h.loadWorkspace(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(200, h.workspaceResponse(workspace))
+}
+
+// UpdateWorkspaceCredentials replaces the workspace's vendor credentials.
+// Credentials left out fall back to the server's own when WORKSPACE_CREDENTIAL_FALLBACK
+// allows it; an empty object removes them all.
+func (h *Handlers) UpdateWorkspaceCredentials(c *gin.Context) {
+	workspace, ok := h.loadWorkspace(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var creds config.Credentials
+	if err := c.ShouldBindJSON(&creds); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid credentials", "details": err.Error()})
+		return
+	}
+	if err := h.workspaces.SetCredentials(workspace, creds); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.db.Save(workspace).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to save workspace credentials"})
+		return
+	}
+
+	c.JSON(200, h.workspaceResponse(workspace))
+}
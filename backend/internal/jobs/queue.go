@@ -0,0 +1,619 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dealshare/hacathon/backend/internal/assets"
+	"github.com/dealshare/hacathon/backend/internal/events"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/services"
+	"github.com/dealshare/hacathon/backend/internal/workspaces"
+	"gorm.io/gorm"
+)
+
+// VideoOptions are the request options persisted with a video job
+type VideoOptions struct {
+	ProductVideoStyle string                     `json:"product_video_style"` // "rotation", "zoom", "pan", "reveal", "auto"
+	Layout            string                     `json:"layout"`              // "product_main", "presenter", "split", "dual_highlight", "avatar_main"
+	Providers         services.ProviderSelection `json:"providers"`           // Per-project provider overrides
+	AspectRatios      []string                   `json:"aspect_ratios"`       // "16:9", "9:16" and/or "1:1"; the first is the primary render
+	Captions          string                     `json:"captions"`            // "burned" (default), "sidecar" or "off"
+	Music             string                     `json:"music"`               // A library track or mood; resolved to a track when queued
+	services.Timing                              // "duration" and "avatar_start" of the composite, in seconds
+}
+
+// maxAttempts is how many times a job is started, counting resumes after restarts,
+// before it is failed instead of resumed again
+const maxAttempts = 3
+
+// pollInterval is how often idle workers look for queued jobs that were not handed
+// to them, e.g. because pending was full
+const pollInterval = 5 * time.Second
+
+// ErrNoActiveJob is returned by Cancel when the project has nothing queued or running
+var ErrNoActiveJob = errors.New("no video generation in progress")
+
+// Queue runs video generation jobs on a fixed pool of background workers.
+// Jobs and their intermediate outputs are persisted, so work survives a restart.
+type Queue struct {
+	db         *gorm.DB
+	aiService  *services.AIService
+	workspaces *workspaces.Store // Resolves each project's credentials
+	assets     *assets.Store     // Records every clip a run produces
+	broker     *events.Broker
+	workers    int
+	pending    chan string // Jobs to start right away; the database has every queued job
+
+	// Runs each claimed job: runVideoJob, or a stub in tests
+	runJob func(ctx context.Context, job *models.Job) error
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc // Cancels the run of each job being worked on
+}
+
+// NewQueue creates a job queue that publishes progress to the broker; call Start to begin processing
+func NewQueue(db *gorm.DB, aiService *services.AIService, store *workspaces.Store, assetStore *assets.Store, broker *events.Broker, workers int) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+	q := &Queue{
+		db:         db,
+		aiService:  aiService,
+		workspaces: store,
+		assets:     assetStore,
+		broker:     broker,
+		workers:    workers,
+		pending:    make(chan string, 256),
+		running:    map[string]context.CancelFunc{},
+	}
+	q.runJob = q.runVideoJob
+	return q
+}
+
+// Start recovers jobs left over from a previous run and starts the workers
+func (q *Queue) Start() {
+	q.recover()
+
+	for i := 0; i < q.workers; i++ {
+		go q.worker(i + 1)
+	}
+	log.Printf("Job queue started with %d workers", q.workers)
+}
+
+// EnqueueVideo persists a video job for the project's approved active script and
+// queues it for a worker
+func (q *Queue) EnqueueVideo(project *models.Project, script *models.Script, opts VideoOptions) (*models.Job, error) {
+	return q.enqueue(project, &models.Job{
+		Type:          models.JobTypeVideo,
+		ScriptID:      script.ID,
+		ScriptVersion: script.Version,
+	}, opts)
+}
+
+// EnqueueRecomposite queues a job that composites the given stored clips again
+// with new options, without generating new ones. The avatar clip must speak script.
+func (q *Queue) EnqueueRecomposite(project *models.Project, script *models.Script, opts VideoOptions, avatarVideoPath, productVideoPath string) (*models.Job, error) {
+	return q.enqueue(project, &models.Job{
+		Type:             models.JobTypeRecomposite,
+		ScriptID:         script.ID,
+		ScriptVersion:    script.Version,
+		AvatarVideoPath:  avatarVideoPath,
+		ProductVideoPath: productVideoPath,
+	}, opts)
+}
+
+// enqueue persists the job with its options, moves the project to video_generating
+// and queues the job for a worker
+func (q *Queue) enqueue(project *models.Project, job *models.Job, opts VideoOptions) (*models.Job, error) {
+	optionsJSON, err := json.Marshal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job options: %v", err)
+	}
+
+	job.ProjectID = project.ID
+	job.Status = models.JobStatusQueued
+	job.Stage = "queued"
+	job.Options = string(optionsJSON)
+
+	err = q.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		return project.Transition(tx, models.ProjectStatusVideoGenerating)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to persist job: %v", err)
+	}
+
+	q.broker.Publish(events.Event{
+		Type:      events.TypeQueued,
+		ProjectID: job.ProjectID,
+		JobID:     job.ID,
+		Stage:     job.Stage,
+	})
+
+	q.hand(job.ID)
+	return job, nil
+}
+
+// hand passes the job to an idle worker without waiting. When all are busy and
+// pending is full, the job stays queued until a worker finds it in the database.
+func (q *Queue) hand(jobID string) {
+	select {
+	case q.pending <- jobID:
+	default:
+		log.Printf("Job queue busy, job %s waits for the next free worker", jobID)
+	}
+}
+
+// ActiveJob returns the queued or running job for a project, if there is one
+func (q *Queue) ActiveJob(projectID string) (*models.Job, error) {
+	var job models.Job
+	err := q.db.Where("project_id = ? AND status IN ?", projectID,
+		[]string{models.JobStatusQueued, models.JobStatusRunning}).
+		Order("created_at DESC").First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Cancel stops the project's active job. A queued job is cancelled right away;
+// a running job is cancelled by its worker once the pipeline has stopped.
+func (q *Queue) Cancel(projectID string) (*models.Job, error) {
+	job, err := q.ActiveJob(projectID)
+	if err != nil {
+		return nil, ErrNoActiveJob
+	}
+
+	// Signal the run first; a worker may claim a queued job at any moment
+	q.mu.Lock()
+	cancel, ok := q.running[job.ID]
+	q.mu.Unlock()
+	if ok {
+		log.Printf("🛑 Cancelling running job %s", job.ID)
+		cancel()
+		return job, nil
+	}
+
+	result := q.db.Model(&models.Job{}).
+		Where("id = ? AND status = ?", job.ID, models.JobStatusQueued).
+		Update("status", models.JobStatusCancelled)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to cancel job: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("job %s is %s and cannot be cancelled", job.ID, job.Status)
+	}
+
+	log.Printf("🛑 Cancelled queued job %s", job.ID)
+	job.Status = models.JobStatusCancelled
+	q.cancelled(job)
+	return job, nil
+}
+
+// recover re-queues jobs that were running when the server stopped, so they resume
+// from their last finished step, and re-queues waiting ones. It assumes this is the
+// only server using the database: a job running on another instance would look
+// interrupted and be run twice.
+func (q *Queue) recover() {
+	var running []models.Job
+	q.db.Where("status = ?", models.JobStatusRunning).Find(&running)
+	for i := range running {
+		job := &running[i]
+		if job.Attempts >= maxAttempts {
+			log.Printf("⚠️  Job %s was interrupted %d times, marking as failed", job.ID, job.Attempts)
+			q.fail(job, fmt.Errorf("interrupted by server restart after %d attempts", job.Attempts))
+			continue
+		}
+		log.Printf("♻️  Job %s was interrupted by a restart at stage %q, resuming", job.ID, job.Stage)
+		job.Status = models.JobStatusQueued
+		q.db.Model(job).Update("status", job.Status)
+	}
+
+	var queued []models.Job
+	q.db.Where("status = ?", models.JobStatusQueued).Order("created_at ASC").Find(&queued)
+	for _, job := range queued {
+		q.hand(job.ID)
+	}
+	if len(queued) > 0 {
+		log.Printf("Re-queued %d waiting jobs", len(queued))
+	}
+
+	q.settleStuckProjects()
+}
+
+// settleStuckProjects moves projects out of in-progress statuses that no job or
+// request will ever finish, e.g. a website generation cut short by a restart
+func (q *Queue) settleStuckProjects() {
+	var stuck []models.Project
+	q.db.Where("status IN ?", []string{
+		models.ProjectStatusVideoGenerating,
+		models.ProjectStatusWebsiteGenerating,
+		models.ProjectStatusInstagramUploading,
+	}).Find(&stuck)
+
+	for i := range stuck {
+		project := &stuck[i]
+		if project.Status == models.ProjectStatusVideoGenerating {
+			if _, err := q.ActiveJob(project.ID); err == nil {
+				continue // Its job resumes
+			}
+		}
+
+		to := project.SettledStatus()
+		if project.Status == models.ProjectStatusWebsiteGenerating && project.WebsitePath != "" {
+			to = models.ProjectStatusWebsiteComplete
+		}
+		if err := project.Transition(q.db, to); err != nil {
+			log.Printf("⚠️  Project %s stuck in %s: %v", project.ID, project.Status, err)
+			continue
+		}
+		log.Printf("♻️  Project %s was left in progress by a restart, now %s", project.ID, to)
+	}
+}
+
+// worker runs the jobs handed to it, and between them works through any others
+// still queued, oldest first
+func (q *Queue) worker(n int) {
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+
+	for {
+		for {
+			var job models.Job
+			err := q.db.Where("status = ?", models.JobStatusQueued).Order("created_at ASC").First(&job).Error
+			if err != nil || !q.run(n, job.ID) {
+				break // Nothing queued, or another worker claimed it first
+			}
+		}
+
+		select {
+		case jobID := <-q.pending:
+			q.run(n, jobID)
+		case <-poll.C:
+		}
+	}
+}
+
+// run claims the queued job and runs it, reporting whether it was claimed
+func (q *Queue) run(n int, jobID string) bool {
+	var job models.Job
+	if err := q.db.First(&job, "id = ?", jobID).Error; err != nil {
+		log.Printf("⚠️  Worker %d: job %s not found: %v", n, jobID, err)
+		return false
+	}
+
+	// Registered before the claim, so Cancel never finds it running but unregistered
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.mu.Lock()
+	if _, taken := q.running[job.ID]; taken {
+		q.mu.Unlock()
+		return false // Another worker has it
+	}
+	q.running[job.ID] = cancel
+	q.mu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		delete(q.running, job.ID)
+		q.mu.Unlock()
+	}()
+
+	// Claim the job; it may have been cancelled or taken since it was queued
+	claimed := q.db.Model(&models.Job{}).
+		Where("id = ? AND status = ?", job.ID, models.JobStatusQueued).
+		Update("status", models.JobStatusRunning)
+	if claimed.Error != nil || claimed.RowsAffected != 1 {
+		return false
+	}
+
+	log.Printf("Worker %d: running job %s for project %s", n, job.ID, job.ProjectID)
+	err := q.runJob(ctx, &job)
+	switch {
+	case err == nil:
+		log.Printf("✅ Job %s completed: %s", job.ID, job.ResultPath)
+	case ctx.Err() != nil:
+		log.Printf("🛑 Job %s cancelled", job.ID)
+		job.Status = models.JobStatusCancelled
+		q.cancelled(&job)
+	default:
+		log.Printf("❌ Job %s failed: %v", job.ID, err)
+		q.fail(&job, err)
+	}
+	return true
+}
+
+// runVideoJob generates the project's video and records the result on the job and project
+func (q *Queue) runVideoJob(ctx context.Context, job *models.Job) error {
+	var opts VideoOptions
+	if job.Options != "" {
+		if err := json.Unmarshal([]byte(job.Options), &opts); err != nil {
+			return fmt.Errorf("invalid job options: %v", err)
+		}
+	}
+
+	var project models.Project
+	if err := q.db.First(&project, "id = ?", job.ProjectID).Error; err != nil {
+		return fmt.Errorf("project not found: %v", err)
+	}
+
+	cfg, err := q.workspaces.Config(project.WorkspaceID)
+	if err != nil {
+		return fmt.Errorf("workspace credentials unavailable: %v", err)
+	}
+	brand, err := q.workspaces.BrandKit(project.WorkspaceID)
+	if err != nil {
+		return fmt.Errorf("brand kit unavailable: %v", err)
+	}
+
+	// Generated assets name the uploads as parents; projects from before assets were tracked get them recorded here
+	if err := q.assets.RecordInputs(ctx, &project); err != nil {
+		log.Printf("⚠️  Job %s: failed to record project inputs: %v", job.ID, err)
+	}
+
+	now := time.Now()
+	job.Status = models.JobStatusRunning
+	job.Attempts++
+	if job.StartedAt == nil {
+		job.StartedAt = &now
+	}
+	q.db.Save(job)
+
+	// The job speaks the script approved when it was queued; jobs from before that
+	// was recorded speak the active one. Its language picks the presenter's voice.
+	scriptID := job.ScriptID
+	if scriptID == "" {
+		scriptID = project.ActiveScriptID
+	}
+	script := models.Script{Text: project.GeneratedScript}
+	if scriptID != "" {
+		if err := q.db.First(&script, "id = ?", scriptID).Error; err != nil {
+			log.Printf("⚠️  Job %s: script %s not found, reading the project's in English: %v", job.ID, scriptID, err)
+		}
+	}
+
+	aiService := q.aiService.WithConfig(cfg)
+	reporter := &jobReporter{db: q.db, assets: q.assets, broker: q.broker, job: job}
+	req := services.VideoRequest{
+		ProductImagePath:  project.ProductImagePath,
+		PersonMediaPath:   project.PersonMediaPath,
+		PersonMediaType:   project.PersonMediaType,
+		Script:            script.Text,
+		Language:          script.Language,
+		Title:             project.ProductName,
+		ProductVideoStyle: opts.ProductVideoStyle,
+		Layout:            opts.Layout,
+		Timing:            opts.Timing,
+		AspectRatios:      opts.AspectRatios,
+		Captions:          opts.Captions,
+		Music:             opts.Music,
+		Brand:             brand,
+		Providers:         opts.Providers,
+		Resume:            resumeState(job),
+	}
+
+	var videoPath string
+	if job.Type == models.JobTypeRecomposite {
+		videoPath, err = aiService.Recomposite(ctx, reporter, req)
+	} else {
+		videoPath, err = aiService.GenerateVideo(ctx, reporter, req)
+	}
+	if err != nil {
+		return err
+	}
+
+	finished := time.Now()
+	job.Status = models.JobStatusCompleted
+	job.Stage = "done"
+	job.Progress = 100
+	job.ResultPath = videoPath
+	job.FinishedAt = &finished
+	q.db.Save(job)
+
+	project.GeneratedVideoPath = videoPath
+	project.GeneratedVideos = job.Renders
+	project.SubtitlesPath = job.SubtitlesPath
+	project.Streams = job.Streams
+	project.CoverPath, project.CoverFrameSeconds = job.CoverPath, nil // A cover chosen for the old video does not fit the new one
+	err = project.Transition(q.db, models.ProjectStatusVideoComplete,
+		"generated_video_path", "generated_videos", "subtitles_path", "streams", "cover_path", "cover_frame_seconds")
+	if err != nil {
+		// E.g. cancelled at the last moment; the job keeps the render
+		log.Printf("⚠️  Job %s: the project was not updated: %v", job.ID, err)
+	}
+
+	q.broker.Publish(events.Event{
+		Type:      events.TypeComplete,
+		ProjectID: job.ProjectID,
+		JobID:     job.ID,
+		Stage:     job.Stage,
+		Percent:   job.Progress,
+		Path:      videoPath,
+	})
+	return nil
+}
+
+// resumeState is what earlier runs of the job left behind for this one to reuse
+func resumeState(job *models.Job) services.ResumeState {
+	return services.ResumeState{
+		AvatarVideoPath:  job.AvatarVideoPath,
+		ProductVideoPath: job.ProductVideoPath,
+		AvatarTaskID:     job.AvatarTaskID,
+		ProductTaskID:    job.ProductTaskID,
+		CompositeTaskID:  job.CompositeTaskID,
+		Renders:          job.Renders,
+	}
+}
+
+// fail records the error on the job and reverts the project so it can be retried
+func (q *Queue) fail(job *models.Job, err error) {
+	finished := time.Now()
+	job.Status = models.JobStatusFailed
+	job.Error = err.Error()
+	job.FinishedAt = &finished
+	q.db.Save(job)
+
+	var project models.Project
+	if q.db.First(&project, "id = ?", job.ProjectID).Error == nil &&
+		project.Status == models.ProjectStatusVideoGenerating {
+		if err := project.Transition(q.db, project.SettledStatus()); err != nil {
+			log.Printf("⚠️  Job %s: %v", job.ID, err)
+		}
+	}
+
+	q.broker.Publish(events.Event{
+		Type:      events.TypeFailure,
+		ProjectID: job.ProjectID,
+		JobID:     job.ID,
+		Stage:     job.Stage,
+		Percent:   job.Progress,
+		Message:   job.Error,
+	})
+}
+
+// cancelled finalises a cancelled job and moves its project to the cancelled status
+func (q *Queue) cancelled(job *models.Job) {
+	finished := time.Now()
+	job.Status = models.JobStatusCancelled
+	job.FinishedAt = &finished
+	q.db.Save(job)
+
+	var project models.Project
+	if q.db.First(&project, "id = ?", job.ProjectID).Error == nil &&
+		project.Status == models.ProjectStatusVideoGenerating {
+		if err := project.Transition(q.db, models.ProjectStatusCancelled); err != nil {
+			log.Printf("⚠️  Job %s: %v", job.ID, err)
+		}
+	}
+
+	q.broker.Publish(events.Event{
+		Type:      events.TypeCancel,
+		ProjectID: job.ProjectID,
+		JobID:     job.ID,
+		Stage:     job.Stage,
+		Percent:   job.Progress,
+	})
+}
+
+// jobReporter persists pipeline progress onto the job row, records the run's
+// assets and publishes it all as events
+type jobReporter struct {
+	db     *gorm.DB
+	assets *assets.Store
+	broker *events.Broker
+	job    *models.Job
+}
+
+func (r *jobReporter) publish(event events.Event) {
+	event.ProjectID = r.job.ProjectID
+	event.JobID = r.job.ID
+	if event.Stage == "" {
+		event.Stage = r.job.Stage
+	}
+	r.broker.Publish(event)
+}
+
+func (r *jobReporter) Stage(stage string, percent int) {
+	r.job.Stage = stage
+	r.job.Progress = percent
+	r.db.Model(r.job).Updates(map[string]interface{}{"stage": stage, "progress": percent})
+	r.publish(events.Event{Type: events.TypeStage, Stage: stage, Percent: percent})
+}
+
+func (r *jobReporter) Artifact(artifact services.Artifact) {
+	switch artifact.Kind {
+	case models.AssetKindAvatarVideo:
+		r.job.AvatarVideoPath = artifact.Key
+		r.db.Model(r.job).Update("avatar_video_path", artifact.Key)
+	case models.AssetKindProductVideo:
+		r.job.ProductVideoPath = artifact.Key
+		r.db.Model(r.job).Update("product_video_path", artifact.Key)
+	case models.AssetKindFinalVideo:
+		if r.job.Renders == nil {
+			r.job.Renders = map[string]string{}
+		}
+		r.job.Renders[artifact.AspectRatio] = artifact.Key
+		// The render's task is finished; the next ratio's render has none until it starts one
+		r.job.CompositeTaskID = ""
+		r.db.Model(r.job).Select("renders", "composite_task_id").Updates(r.job)
+	case models.AssetKindSubtitles:
+		if strings.HasSuffix(artifact.Key, ".vtt") {
+			r.job.SubtitlesPath = artifact.Key
+			r.db.Model(r.job).Update("subtitles_path", artifact.Key)
+		}
+	case models.AssetKindStream:
+		// A stream is made from exactly one render
+		if len(artifact.Parents) > 0 {
+			if r.job.Streams == nil {
+				r.job.Streams = map[string]string{}
+			}
+			r.job.Streams[artifact.Parents[0]] = artifact.Key
+			r.db.Model(r.job).Select("streams").Updates(r.job)
+		}
+	case models.AssetKindThumbnail:
+		// Renders run primary first, so the first title card is the primary render's
+		if artifact.FrameSeconds == nil && r.job.CoverPath == "" {
+			r.job.CoverPath = artifact.Key
+			r.db.Model(r.job).Update("cover_path", artifact.Key)
+		}
+	}
+
+	asset := &models.Asset{
+		ProjectID:    r.job.ProjectID,
+		JobID:        r.job.ID,
+		Kind:         artifact.Kind,
+		StorageKey:   artifact.Key,
+		Provider:     artifact.Provider,
+		RemoteTaskID: artifact.TaskID,
+		RemoteURL:    artifact.RemoteURL,
+		AspectRatio:  artifact.AspectRatio,
+		FrameSeconds: artifact.FrameSeconds,
+	}
+	if err := r.assets.Record(context.Background(), asset, artifact.Parents...); err != nil {
+		log.Printf("⚠️  Job %s: failed to record %s asset: %v", r.job.ID, artifact.Kind, err)
+	}
+
+	r.publish(events.Event{Type: events.TypeArtifact, Stage: artifact.Stage, Provider: artifact.Provider, Path: artifact.Key})
+}
+
+func (r *jobReporter) Poll(provider, taskID string, attempt, maxAttempts int, status string) {
+	r.publish(events.Event{
+		Type:        events.TypePoll,
+		Provider:    provider,
+		TaskID:      taskID,
+		Attempt:     attempt,
+		MaxAttempts: maxAttempts,
+		Status:      status,
+		Percent:     r.job.Progress,
+	})
+}
+
+func (r *jobReporter) Task(provider, taskID string) {
+	switch r.job.Stage {
+	case services.StageAvatar:
+		r.job.AvatarTaskID = taskID
+		r.db.Model(r.job).Update("avatar_task_id", taskID)
+	case services.StageProduct:
+		r.job.ProductTaskID = taskID
+		r.db.Model(r.job).Update("product_task_id", taskID)
+	case services.StageComposite:
+		r.job.CompositeTaskID = taskID
+		r.db.Model(r.job).Update("composite_task_id", taskID)
+	}
+	r.publish(events.Event{Type: events.TypeTask, Provider: provider, TaskID: taskID})
+}
+
+// Download needs no event; the URL is recorded with the step's artifact
+func (r *jobReporter) Download(url, path string) {}
+
+func (r *jobReporter) Upload(host, path, url string) {
+	r.publish(events.Event{Type: events.TypeUpload, Provider: host, Path: path, URL: url})
+}
@@ -0,0 +1,237 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dealshare/hacathon/backend/internal/assets"
+	"github.com/dealshare/hacathon/backend/internal/database"
+	"github.com/dealshare/hacathon/backend/internal/events"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/services"
+	"github.com/dealshare/hacathon/backend/internal/storage"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestQueue returns a queue over a fresh database, without starting its workers
+func newTestQueue(t *testing.T, workers int) *Queue {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "jobs.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	local := storage.NewLocal(map[string]string{storage.PrefixVideos: filepath.Join(dir, "videos")}, nil)
+	return NewQueue(db, nil, nil, assets.NewStore(db, local, filepath.Join(dir, "cache")), events.NewBroker(), workers)
+}
+
+// reloadJob reads the job back as a restarted server would
+func reloadJob(t *testing.T, q *Queue, id string) *models.Job {
+	t.Helper()
+	var job models.Job
+	if err := q.db.First(&job, "id = ?", id).Error; err != nil {
+		t.Fatalf("load job: %v", err)
+	}
+	return &job
+}
+
+// seedJob creates a project that is generating a video, and its job
+func seedJob(t *testing.T, q *Queue, status string, attempts int) *models.Job {
+	t.Helper()
+	project := &models.Project{ProductName: "Kettle", Status: models.ProjectStatusVideoGenerating}
+	if err := q.db.Create(project).Error; err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+	job := &models.Job{ProjectID: project.ID, Type: models.JobTypeVideo, Status: status, Attempts: attempts}
+	if err := q.db.Create(job).Error; err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	return job
+}
+
+func projectStatus(t *testing.T, q *Queue, id string) string {
+	t.Helper()
+	var project models.Project
+	if err := q.db.First(&project, "id = ?", id).Error; err != nil {
+		t.Fatalf("load project: %v", err)
+	}
+	return project.Status
+}
+
+func TestHandDoesNotBlock(t *testing.T) {
+	q := newTestQueue(t, 1)
+	for i := 0; i < cap(q.pending); i++ {
+		q.hand(fmt.Sprintf("job-%d", i))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		q.hand("one-too-many") // Stays queued in the database for a worker to find
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("hand() blocked on a full pending channel")
+	}
+}
+
+func TestIdleWorkerClaimsQueuedJobs(t *testing.T) {
+	q := newTestQueue(t, 1)
+	ran := make(chan string, 2)
+	q.runJob = func(ctx context.Context, job *models.Job) error {
+		ran <- job.ID
+		return nil
+	}
+
+	// Neither job was handed to a worker, as when pending was full
+	older := seedJob(t, q, models.JobStatusQueued, 0)
+	newer := seedJob(t, q, models.JobStatusQueued, 0)
+	go q.worker(1)
+
+	for _, want := range []string{older.ID, newer.ID} {
+		select {
+		case got := <-ran:
+			if got != want {
+				t.Errorf("worker ran %s, want %s (oldest first)", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("idle worker did not claim queued job %s", want)
+		}
+	}
+	if status := reloadJob(t, q, older.ID).Status; status != models.JobStatusRunning {
+		t.Errorf("claimed job is %s, want running", status)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	q := newTestQueue(t, 1)
+	interrupted := seedJob(t, q, models.JobStatusRunning, 1)
+	exhausted := seedJob(t, q, models.JobStatusRunning, maxAttempts)
+	waiting := seedJob(t, q, models.JobStatusQueued, 0)
+
+	q.recover()
+
+	want := map[string]string{
+		interrupted.ID: models.JobStatusQueued,
+		exhausted.ID:   models.JobStatusFailed,
+		waiting.ID:     models.JobStatusQueued,
+	}
+	for id, status := range want {
+		if got := reloadJob(t, q, id).Status; got != status {
+			t.Errorf("job %s is %s after recover(), want %s", id, got, status)
+		}
+	}
+	if status := projectStatus(t, q, exhausted.ProjectID); status == models.ProjectStatusVideoGenerating {
+		t.Errorf("project of the failed job is still %s", status)
+	}
+	if status := projectStatus(t, q, interrupted.ProjectID); status != models.ProjectStatusVideoGenerating {
+		t.Errorf("project of the resumed job is %s, want it still generating", status)
+	}
+
+	var handed []string
+	for len(q.pending) > 0 {
+		handed = append(handed, <-q.pending)
+	}
+	if len(handed) != 2 || handed[0] != interrupted.ID || handed[1] != waiting.ID {
+		t.Errorf("recover() handed %v, want %s then %s", handed, interrupted.ID, waiting.ID)
+	}
+}
+
+func TestCancelQueuedJob(t *testing.T) {
+	q := newTestQueue(t, 1)
+	job := seedJob(t, q, models.JobStatusQueued, 0)
+
+	cancelled, err := q.Cancel(job.ProjectID)
+	if err != nil || cancelled.Status != models.JobStatusCancelled {
+		t.Fatalf("Cancel() = %+v, %v, want the job cancelled", cancelled, err)
+	}
+	if status := reloadJob(t, q, job.ID).Status; status != models.JobStatusCancelled {
+		t.Errorf("job is %s, want cancelled", status)
+	}
+	if status := projectStatus(t, q, job.ProjectID); status != models.ProjectStatusCancelled {
+		t.Errorf("project is %s, want cancelled", status)
+	}
+
+	// A worker that was handed the job before it was cancelled leaves it alone
+	q.runJob = func(ctx context.Context, job *models.Job) error {
+		t.Errorf("cancelled job %s was run", job.ID)
+		return nil
+	}
+	if q.run(1, job.ID) {
+		t.Errorf("run() claimed a cancelled job")
+	}
+	if _, err := q.Cancel(job.ProjectID); !errors.Is(err, ErrNoActiveJob) {
+		t.Errorf("second Cancel() error = %v, want ErrNoActiveJob", err)
+	}
+}
+
+func TestCancelRunningJob(t *testing.T) {
+	q := newTestQueue(t, 1)
+	job := seedJob(t, q, models.JobStatusQueued, 0)
+
+	started := make(chan struct{})
+	q.runJob = func(ctx context.Context, job *models.Job) error {
+		close(started)
+		<-ctx.Done() // Like a pipeline step, stops once the run is cancelled
+		return ctx.Err()
+	}
+	finished := make(chan bool)
+	go func() { finished <- q.run(1, job.ID) }()
+	<-started
+
+	if _, err := q.Cancel(job.ProjectID); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	select {
+	case claimed := <-finished:
+		if !claimed {
+			t.Errorf("run() did not report the job as claimed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("running job did not stop after Cancel()")
+	}
+
+	if status := reloadJob(t, q, job.ID).Status; status != models.JobStatusCancelled {
+		t.Errorf("job is %s, want cancelled", status)
+	}
+	if status := projectStatus(t, q, job.ProjectID); status != models.ProjectStatusCancelled {
+		t.Errorf("project is %s, want cancelled", status)
+	}
+}
+
+func TestResumeAfterOneRatioRendered(t *testing.T) {
+	q := newTestQueue(t, 1)
+	job := &models.Job{ProjectID: "p1", Type: models.JobTypeVideo, Status: models.JobStatusRunning}
+	q.db.Create(job)
+	reporter := &jobReporter{db: q.db, assets: q.assets, broker: q.broker, job: job}
+
+	// The 16:9 render finishes at the vendor, then the server stops during the local 9:16 render
+	reporter.Stage(services.StageComposite, 75)
+	reporter.Task("shotstack", "render-16x9")
+	reporter.Artifact(services.Artifact{Stage: services.StageComposite, Kind: models.AssetKindFinalVideo, Key: "videos/final-16x9.mp4", AspectRatio: "16:9"})
+
+	resume := resumeState(reloadJob(t, q, job.ID))
+	if resume.Renders["16:9"] != "videos/final-16x9.mp4" {
+		t.Errorf("Renders = %v, want the finished 16:9 render", resume.Renders)
+	}
+	if resume.CompositeTaskID != "" {
+		t.Errorf("CompositeTaskID = %q; the 9:16 render would re-poll the finished 16:9 task", resume.CompositeTaskID)
+	}
+
+	// Interrupted while the vendor renders 9:16, the resumed run re-polls that task
+	reporter.Task("shotstack", "render-9x16")
+	resume = resumeState(reloadJob(t, q, job.ID))
+	if resume.CompositeTaskID != "render-9x16" || len(resume.Renders) != 1 {
+		t.Errorf("resume state = %+v, want the 9:16 task and one finished render", resume)
+	}
+}
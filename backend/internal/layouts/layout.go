@@ -0,0 +1,338 @@
+package layouts
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Clip sources. Videos come from the pipeline; borders and HTML are drawn overlays.
+const (
+	SourceAvatar  = "avatar"  // The presenter clip
+	SourceProduct = "product" // The product clip
+	SourceBorder  = "border"  // A glowing frame in Color
+	SourceHTML    = "html"    // Arbitrary HTML; rendered by Shotstack only
+)
+
+// Aspect ratios a layout can be rendered in
+const (
+	Landscape = "16:9" // YouTube; the default
+	Portrait  = "9:16" // Reels and Stories
+	Square    = "1:1"  // Feed posts
+)
+
+// AspectRatios lists the supported aspect ratios
+var AspectRatios = []string{Landscape, Portrait, Square}
+
+// ValidAspectRatio reports whether ratio is one of AspectRatios
+func ValidAspectRatio(ratio string) bool {
+	for _, supported := range AspectRatios {
+		if ratio == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// Layout is a composite's arrangement of clips, loaded from YAML or JSON
+type Layout struct {
+	Name        string             `yaml:"name" json:"name"`
+	Description string             `yaml:"description" json:"description,omitempty"`
+	Tracks      []Track            `yaml:"tracks" json:"tracks"`               // Top to bottom, as in Shotstack
+	Variants    map[string][]Track `yaml:"variants" json:"variants,omitempty"` // Tracks to use instead for an aspect ratio, e.g. "9:16"
+}
+
+// For returns the layout as arranged for an aspect ratio: its variant's tracks
+// if it has one, otherwise its own
+func (l *Layout) For(ratio string) *Layout {
+	tracks, ok := l.Variants[ratio]
+	if !ok {
+		return l
+	}
+	adapted := *l
+	adapted.Tracks = tracks
+	adapted.Variants = nil
+	return &adapted
+}
+
+// Track is a layer of the composite
+type Track struct {
+	Clips []Clip `yaml:"clips" json:"clips"`
+}
+
+// Clip places one source on a track. Omitted numbers take sensible defaults:
+// length runs to the end of the video, scale and opacity are 1.
+type Clip struct {
+	Source     string     `yaml:"source" json:"source"`
+	HTML       string     `yaml:"html" json:"html,omitempty"`   // For html clips
+	Color      string     `yaml:"color" json:"color,omitempty"` // For border clips, e.g. "#FFD700"
+	Start      float64    `yaml:"start" json:"start,omitempty"` // Seconds; the presenter's start delay is added to avatar clips
+	Length     float64    `yaml:"length" json:"length,omitempty"`
+	Loop       bool       `yaml:"loop" json:"loop,omitempty"` // Repeat a video source until the clip ends
+	Position   string     `yaml:"position" json:"position,omitempty"`
+	Offset     Offset     `yaml:"offset" json:"offset"`
+	Scale      float64    `yaml:"scale" json:"scale,omitempty"`
+	Fit        string     `yaml:"fit" json:"fit,omitempty"`
+	Opacity    float64    `yaml:"opacity" json:"opacity,omitempty"`
+	Transition Transition `yaml:"transition" json:"transition"`
+	Effect     string     `yaml:"effect" json:"effect,omitempty"`
+}
+
+// Offset moves a clip from its position, as a fraction of the canvas;
+// positive x moves right and positive y moves down
+type Offset struct {
+	X float64 `yaml:"x" json:"x"`
+	Y float64 `yaml:"y" json:"y"`
+}
+
+// Transition animates a clip in and out
+type Transition struct {
+	In  string `yaml:"in" json:"in,omitempty"`
+	Out string `yaml:"out" json:"out,omitempty"`
+}
+
+// Values both compositors can render
+var (
+	namePattern    = regexp.MustCompile(`^[a-z0-9_]+$`)
+	colorPattern   = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+	positions      = set("center", "left", "right", "top", "bottom", "topLeft", "topRight", "bottomLeft", "bottomRight")
+	fits           = set("cover", "contain")
+	transitionsIn  = set("fade", "zoom", "slideLeft", "slideRight")
+	transitionsOut = set("fade")
+	effects        = set("zoomIn")
+)
+
+func set(values ...string) map[string]bool {
+	m := map[string]bool{}
+	for _, value := range values {
+		m[value] = true
+	}
+	return m
+}
+
+// Validate checks every value against what the compositors support
+func (l *Layout) Validate() error {
+	if !namePattern.MatchString(l.Name) {
+		return fmt.Errorf("layout name %q must be lower case letters, digits and underscores", l.Name)
+	}
+	if err := validateTracks(l.Tracks); err != nil {
+		return fmt.Errorf("layout %s: %v", l.Name, err)
+	}
+	for ratio, tracks := range l.Variants {
+		if !ValidAspectRatio(ratio) {
+			return fmt.Errorf("layout %s: unknown aspect ratio %q (expected one of %s)", l.Name, ratio, strings.Join(AspectRatios, ", "))
+		}
+		if err := validateTracks(tracks); err != nil {
+			return fmt.Errorf("layout %s, %s variant: %v", l.Name, ratio, err)
+		}
+	}
+	return nil
+}
+
+func validateTracks(tracks []Track) error {
+	if len(tracks) == 0 {
+		return fmt.Errorf("no tracks")
+	}
+
+	videos := 0
+	for t, track := range tracks {
+		if len(track.Clips) == 0 {
+			return fmt.Errorf("track %d has no clips", t+1)
+		}
+		for c, clip := range track.Clips {
+			if err := clip.validate(); err != nil {
+				return fmt.Errorf("track %d clip %d: %v", t+1, c+1, err)
+			}
+			if clip.IsVideo() {
+				videos++
+			}
+		}
+	}
+	if videos == 0 {
+		return fmt.Errorf("shows neither the avatar nor the product")
+	}
+	return nil
+}
+
+func (c *Clip) validate() error {
+	switch c.Source {
+	case SourceAvatar, SourceProduct:
+		if c.Fit != "" && !fits[c.Fit] {
+			return fmt.Errorf("unknown fit %q", c.Fit)
+		}
+	case SourceBorder:
+		if !colorPattern.MatchString(c.Color) {
+			return fmt.Errorf("border needs a color like \"#FFD700\"")
+		}
+	case SourceHTML:
+		if strings.TrimSpace(c.HTML) == "" {
+			return fmt.Errorf("html clip has no html")
+		}
+	default:
+		return fmt.Errorf("unknown source %q (expected avatar, product, border or html)", c.Source)
+	}
+
+	if c.Loop && !c.IsVideo() {
+		return fmt.Errorf("only avatar and product clips can loop")
+	}
+	if c.Start < 0 || c.Length < 0 {
+		return fmt.Errorf("start and length cannot be negative")
+	}
+	if c.Position != "" && !positions[c.Position] {
+		return fmt.Errorf("unknown position %q", c.Position)
+	}
+	if c.Offset.X < -1 || c.Offset.X > 1 || c.Offset.Y < -1 || c.Offset.Y > 1 {
+		return fmt.Errorf("offsets must be between -1 and 1")
+	}
+	if c.Scale < 0 || c.Scale > 1 {
+		return fmt.Errorf("scale must be between 0 and 1")
+	}
+	if c.Opacity < 0 || c.Opacity > 1 {
+		return fmt.Errorf("opacity must be between 0 and 1")
+	}
+	if c.Transition.In != "" && !transitionsIn[c.Transition.In] {
+		return fmt.Errorf("unknown transition in %q", c.Transition.In)
+	}
+	if c.Transition.Out != "" && !transitionsOut[c.Transition.Out] {
+		return fmt.Errorf("unknown transition out %q", c.Transition.Out)
+	}
+	if c.Effect != "" && !effects[c.Effect] {
+		return fmt.Errorf("unknown effect %q", c.Effect)
+	}
+	return nil
+}
+
+// IsVideo reports whether the clip shows one of the pipeline's videos
+func (c *Clip) IsVideo() bool {
+	return c.Source == SourceAvatar || c.Source == SourceProduct
+}
+
+// Resolved returns the clip with the values omitted from its file filled in
+func (c Clip) Resolved() Clip {
+	if c.Position == "" {
+		c.Position = "center"
+	}
+	if c.Scale == 0 {
+		c.Scale = 1
+	}
+	if c.Opacity == 0 {
+		c.Opacity = 1
+	}
+	if c.Fit == "" && c.IsVideo() {
+		c.Fit = "contain"
+	}
+	return c
+}
+
+// Parse reads a layout from YAML or JSON, chosen by the file name's extension, and validates it
+func Parse(name string, data []byte) (*Layout, error) {
+	var layout Layout
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&layout)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&layout)
+	default:
+		return nil, fmt.Errorf("%s: layouts must be .yaml, .yml or .json files", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	if err := layout.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return &layout, nil
+}
+
+//go:embed builtin/*.yaml
+var builtin embed.FS
+
+// registry holds the built-in layouts and those loaded from LAYOUTS_DIR
+var registry = struct {
+	sync.RWMutex
+	layouts map[string]*Layout
+}{layouts: map[string]*Layout{}}
+
+func init() {
+	if err := loadFS(builtin, "builtin"); err != nil {
+		panic(fmt.Sprintf("invalid built-in layout: %v", err))
+	}
+}
+
+// LoadDir adds every layout file in dir, replacing built-in layouts of the same
+// name. A missing directory is not an error; an invalid file is.
+func LoadDir(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	return loadFS(os.DirFS(dir), ".")
+}
+
+func loadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	loaded := map[string]*Layout{}
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		data, err := fs.ReadFile(fsys, filepath.ToSlash(filepath.Join(dir, entry.Name())))
+		if err != nil {
+			return err
+		}
+		layout, err := Parse(entry.Name(), data)
+		if err != nil {
+			return err
+		}
+		if _, dup := loaded[layout.Name]; dup {
+			return fmt.Errorf("%s: layout %s is defined twice", entry.Name(), layout.Name)
+		}
+		loaded[layout.Name] = layout
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	for name, layout := range loaded {
+		registry.layouts[name] = layout
+	}
+	return nil
+}
+
+// Get returns the named layout
+func Get(name string) (*Layout, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	layout, ok := registry.layouts[name]
+	return layout, ok
+}
+
+// Names lists the available layouts
+func Names() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	names := make([]string, 0, len(registry.layouts))
+	for name := range registry.layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,169 @@
+package layouts
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinLayouts(t *testing.T) {
+	want := []string{"avatar_main", "dual_highlight", "presenter", "product_main", "split"}
+	for _, name := range want {
+		layout, ok := Get(name)
+		if !ok {
+			t.Errorf("built-in layout %s is missing (have %v)", name, Names())
+			continue
+		}
+		for _, ratio := range AspectRatios {
+			if err := layout.For(ratio).Validate(); err != nil {
+				t.Errorf("%s at %s: %v", name, ratio, err)
+			}
+		}
+	}
+}
+
+func TestLayoutFor(t *testing.T) {
+	split, _ := Get("split")
+	tests := []struct {
+		ratio        string
+		wantPosition string
+	}{
+		{Landscape, "left"},
+		{Square, "left"},
+		{Portrait, "top"},
+	}
+	for _, tt := range tests {
+		adapted := split.For(tt.ratio)
+		if got := adapted.Tracks[0].Clips[0].Position; got != tt.wantPosition {
+			t.Errorf("For(%q) first clip position = %q, want %q", tt.ratio, got, tt.wantPosition)
+		}
+		if adapted.Name != "split" {
+			t.Errorf("For(%q) name = %q", tt.ratio, adapted.Name)
+		}
+	}
+	if split.Tracks[0].Clips[0].Position != "left" {
+		t.Errorf("For() changed the layout it adapted")
+	}
+}
+
+func TestParse(t *testing.T) {
+	yamlLayout := func(clip string) string {
+		return "name: custom\ntracks:\n  - clips:\n      - source: avatar\n  - clips:\n      - " + clip + "\n"
+	}
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		wantErr string // Empty when the layout is valid
+	}{
+		{"yaml", "custom.yaml", yamlLayout("{source: product, position: bottomRight, scale: 0.3, offset: {x: -0.05, y: -0.05}}"), ""},
+		{"yml", "custom.yml", yamlLayout("{source: border, color: \"#FFD700\"}"), ""},
+		{"json", "custom.json", `{"name": "custom", "tracks": [{"clips": [{"source": "product", "loop": true}]}]}`, ""},
+		{"unknown extension", "custom.txt", yamlLayout("{source: product}"), "must be .yaml"},
+		{"unknown yaml field", "custom.yaml", yamlLayout("{source: product, rotate: 90}"), "rotate"},
+		{"unknown json field", "custom.json", `{"name": "custom", "tracks": [{"clips": [{"source": "product", "rotate": 90}]}]}`, "rotate"},
+		{"bad name", "custom.yaml", "name: Custom Layout\ntracks:\n  - clips:\n      - source: avatar\n", "lower case"},
+		{"no tracks", "custom.yaml", "name: custom\n", "no tracks"},
+		{"empty track", "custom.yaml", "name: custom\ntracks:\n  - clips: []\n", "has no clips"},
+		{"no video", "custom.yaml", "name: custom\ntracks:\n  - clips:\n      - {source: border, color: \"#FFFFFF\"}\n", "neither the avatar nor the product"},
+		{"unknown source", "custom.yaml", yamlLayout("{source: music}"), "unknown source"},
+		{"border without color", "custom.yaml", yamlLayout("{source: border}"), "needs a color"},
+		{"html without html", "custom.yaml", yamlLayout("{source: html, html: \" \"}"), "has no html"},
+		{"looping border", "custom.yaml", yamlLayout("{source: border, color: \"#FFFFFF\", loop: true}"), "can loop"},
+		{"negative start", "custom.yaml", yamlLayout("{source: product, start: -1}"), "cannot be negative"},
+		{"unknown position", "custom.yaml", yamlLayout("{source: product, position: middle}"), "unknown position"},
+		{"offset off canvas", "custom.yaml", yamlLayout("{source: product, offset: {x: 1.5}}"), "offsets"},
+		{"scale above 1", "custom.yaml", yamlLayout("{source: product, scale: 2}"), "scale"},
+		{"opacity above 1", "custom.yaml", yamlLayout("{source: product, opacity: 1.2}"), "opacity"},
+		{"unknown fit", "custom.yaml", yamlLayout("{source: product, fit: stretch}"), "unknown fit"},
+		{"unknown transition", "custom.yaml", yamlLayout("{source: product, transition: {in: spin}}"), "transition in"},
+		{"unknown transition out", "custom.yaml", yamlLayout("{source: product, transition: {out: zoom}}"), "transition out"},
+		{"unknown effect", "custom.yaml", yamlLayout("{source: product, effect: shake}"), "unknown effect"},
+		{"unknown variant", "custom.yaml", yamlLayout("{source: product}") + "variants:\n  \"4:3\":\n    - clips:\n        - source: avatar\n", "unknown aspect ratio"},
+		{"invalid variant", "custom.yaml", yamlLayout("{source: product}") + "variants:\n  \"9:16\":\n    - clips:\n        - source: music\n", "9:16 variant"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.file, []byte(tt.data))
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: Parse() error = %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: Parse() error = %v, want one mentioning %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestClipWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		clip      Clip
+		start     float64
+		end       float64
+		wantShown bool
+	}{
+		{"whole video", Clip{Source: SourceProduct}, 0, 15, true},
+		{"avatar is delayed", Clip{Source: SourceAvatar}, 2, 15, true},
+		{"avatar with its own start", Clip{Source: SourceAvatar, Start: 1, Length: 5}, 3, 8, true},
+		{"product is not delayed", Clip{Source: SourceProduct, Start: 1, Length: 5}, 1, 6, true},
+		{"length past the end", Clip{Source: SourceProduct, Start: 10, Length: 10}, 10, 15, true},
+		{"starts at the end", Clip{Source: SourceProduct, Start: 15}, 15, 15, false},
+		{"delayed past the end", Clip{Source: SourceAvatar, Start: 14}, 16, 15, false},
+	}
+	for _, tt := range tests {
+		start, end, shown := tt.clip.Window(15, 2)
+		if start != tt.start || end != tt.end || shown != tt.wantShown {
+			t.Errorf("%s: Window() = %v, %v, %v, want %v, %v, %v", tt.name, start, end, shown, tt.start, tt.end, tt.wantShown)
+		}
+	}
+}
+
+func TestShotstack(t *testing.T) {
+	layout := &Layout{Name: "custom", Tracks: []Track{
+		{Clips: []Clip{{Source: SourceBorder, Color: "#FF0000", Length: 4}}},
+		{Clips: []Clip{{Source: SourceAvatar, Loop: true, Position: "bottomRight", Scale: 0.4, Transition: Transition{In: "fade"}}}},
+		{Clips: []Clip{{Source: SourceProduct, Start: 20}}}, // After the end, so the track is dropped
+		{Clips: []Clip{{Source: SourceProduct}}},
+	}}
+	src := Sources{
+		Avatar:      Source{URL: "https://cdn/avatar.mp4", Duration: 4},
+		Product:     Source{URL: "https://cdn/product.mp4", Duration: 6},
+		Duration:    10,
+		AvatarStart: 1,
+		Offset:      2,
+	}
+	tracks := layout.Shotstack(src)
+
+	type segment struct {
+		src           string
+		start, length float64
+	}
+	var got [][]segment
+	for _, track := range tracks {
+		var segments []segment
+		for _, clip := range track.Clips {
+			segments = append(segments, segment{clip.Asset.Src, clip.Start, clip.Length})
+		}
+		got = append(got, segments)
+	}
+	want := [][]segment{
+		{{"", 2, 4}},
+		{{"https://cdn/avatar.mp4", 3, 4}, {"https://cdn/avatar.mp4", 7, 4}, {"https://cdn/avatar.mp4", 11, 1}},
+		{{"https://cdn/product.mp4", 2, 10}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Shotstack() segments = %v, want %v", got, want)
+	}
+
+	border := tracks[0].Clips[0]
+	if border.Asset.Type != "html" || !strings.Contains(border.Asset.HTML, "rgba(255, 0, 0, 0.8)") || border.Transition != nil {
+		t.Errorf("border clip = %+v", border)
+	}
+	avatar := tracks[1].Clips[0]
+	if avatar.Position != "bottomRight" || avatar.Scale != 0.4 || avatar.Opacity != 1 || avatar.Fit != "contain" ||
+		avatar.Transition == nil || avatar.Transition.In != "fade" {
+		t.Errorf("avatar clip = %+v", avatar)
+	}
+	if product := tracks[2].Clips[0]; product.Position != "center" || product.Scale != 1 {
+		t.Errorf("product clip defaults = %+v", product)
+	}
+}
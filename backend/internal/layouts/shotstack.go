@@ -0,0 +1,137 @@
+package layouts
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Source is a video a layout is rendered with
+type Source struct {
+	URL      string
+	Duration float64 // Seconds; looped clips repeat every Duration (0 plays it once)
+}
+
+// Sources are everything a layout needs to become a timeline
+type Sources struct {
+	Avatar      Source
+	Product     Source
+	Duration    float64 // Length of the composite in seconds
+	AvatarStart float64 // Seconds before the presenter appears
+	Offset      float64 // Seconds every clip is delayed by, e.g. to make room for an intro
+}
+
+// ShotstackTrack is a track of a Shotstack timeline
+type ShotstackTrack struct {
+	Clips []ShotstackClip `json:"clips"`
+}
+
+// ShotstackClip is a clip of a Shotstack timeline
+type ShotstackClip struct {
+	Asset      ShotstackAsset `json:"asset"`
+	Start      float64        `json:"start"`
+	Length     float64        `json:"length"`
+	Position   string         `json:"position,omitempty"`
+	Offset     *Offset        `json:"offset,omitempty"`
+	Scale      float64        `json:"scale,omitempty"`
+	Opacity    float64        `json:"opacity,omitempty"`
+	Fit        string         `json:"fit,omitempty"`
+	Transition *Transition    `json:"transition,omitempty"`
+	Effect     string         `json:"effect,omitempty"`
+}
+
+// ShotstackAsset is what a Shotstack clip shows
+type ShotstackAsset struct {
+	Type string `json:"type"` // "video" or "html"
+	Src  string `json:"src,omitempty"`
+	HTML string `json:"html,omitempty"`
+}
+
+// Window returns when a clip is on screen in a composite of the given length.
+// Avatar clips are delayed by avatarStart; ok is false if the clip starts after the end.
+func (c Clip) Window(duration, avatarStart float64) (start, end float64, ok bool) {
+	start = c.Start
+	if c.Source == SourceAvatar {
+		start += avatarStart
+	}
+	end = duration
+	if c.Length > 0 && start+c.Length < duration {
+		end = start + c.Length
+	}
+	return start, end, start < end
+}
+
+// Shotstack renders the layout as Shotstack timeline tracks. Looped clips are
+// repeated back to back until they reach the end of their window.
+func (l *Layout) Shotstack(src Sources) []ShotstackTrack {
+	tracks := make([]ShotstackTrack, 0, len(l.Tracks))
+	for _, track := range l.Tracks {
+		var clips []ShotstackClip
+		for _, clip := range track.Clips {
+			clip = clip.Resolved()
+			start, end, ok := clip.Window(src.Duration, src.AvatarStart)
+			if !ok {
+				continue
+			}
+
+			base := ShotstackClip{
+				Asset:    clip.shotstackAsset(src),
+				Position: clip.Position,
+				Offset:   &Offset{X: clip.Offset.X, Y: clip.Offset.Y},
+				Scale:    clip.Scale,
+				Opacity:  clip.Opacity,
+				Fit:      clip.Fit,
+				Effect:   clip.Effect,
+			}
+			if clip.Transition != (Transition{}) {
+				transition := clip.Transition
+				base.Transition = &transition
+			}
+
+			every := end - start
+			if clip.Loop {
+				if d := src.of(clip.Source).Duration; d > 0 {
+					every = d
+				}
+			}
+			for at := start; at < end; at += every {
+				segment := base
+				segment.Start = at + src.Offset
+				segment.Length = every
+				if at+every > end {
+					segment.Length = end - at
+				}
+				clips = append(clips, segment)
+			}
+		}
+		if len(clips) > 0 {
+			tracks = append(tracks, ShotstackTrack{Clips: clips})
+		}
+	}
+	return tracks
+}
+
+func (s Sources) of(source string) Source {
+	if source == SourceAvatar {
+		return s.Avatar
+	}
+	return s.Product
+}
+
+func (c Clip) shotstackAsset(src Sources) ShotstackAsset {
+	switch c.Source {
+	case SourceBorder:
+		return ShotstackAsset{Type: "html", HTML: borderHTML(c.Color)}
+	case SourceHTML:
+		return ShotstackAsset{Type: "html", HTML: c.HTML}
+	}
+	return ShotstackAsset{Type: "video", Src: src.of(c.Source).URL}
+}
+
+// borderHTML draws a rounded, glowing frame in a #RRGGBB colour
+func borderHTML(color string) string {
+	r, _ := strconv.ParseUint(color[1:3], 16, 8)
+	g, _ := strconv.ParseUint(color[3:5], 16, 8)
+	b, _ := strconv.ParseUint(color[5:7], 16, 8)
+	return fmt.Sprintf("<div style='width: 100%%; height: 100%%; border: 8px solid %s; border-radius: 20px; box-shadow: 0 0 40px rgba(%d, %d, %d, 0.8);'></div>",
+		color, r, g, b)
+}
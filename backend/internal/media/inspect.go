@@ -0,0 +1,279 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // GIF decoder
+	_ "image/jpeg" // JPEG decoder
+	_ "image/png"  // PNG decoder
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	_ "golang.org/x/image/webp" // WebP decoder
+)
+
+// Media kinds
+const (
+	KindImage = "image"
+	KindVideo = "video"
+)
+
+// Limits bound what an upload may contain
+type Limits struct {
+	MaxImageBytes   int64
+	MaxVideoBytes   int64
+	MinDimension    int     // Shortest allowed side of an image or video, in pixels
+	MaxDimension    int     // Longest allowed side, in pixels
+	MaxVideoSeconds float64 // Longest allowed video
+}
+
+// Info describes a validated media file
+type Info struct {
+	Kind     string  `json:"kind"` // KindImage or KindVideo
+	MIME     string  `json:"mime"`
+	Ext      string  `json:"ext"` // Canonical extension for the detected type, e.g. ".jpg"
+	Size     int64   `json:"size"`
+	Width    int     `json:"width,omitempty"`
+	Height   int     `json:"height,omitempty"`
+	Duration float64 `json:"duration,omitempty"` // Seconds, videos only
+	SHA256   string  `json:"sha256"`
+}
+
+// Error is a validation failure that maps to an HTTP 4xx status
+type Error struct {
+	Status  int // 413 too large, 415 unsupported type, 422 corrupt or out of bounds
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func tooLarge(format string, args ...interface{}) error {
+	return &Error{Status: 413, Message: fmt.Sprintf(format, args...)}
+}
+
+func unsupported(format string, args ...interface{}) error {
+	return &Error{Status: 415, Message: fmt.Sprintf(format, args...)}
+}
+
+func invalid(format string, args ...interface{}) error {
+	return &Error{Status: 422, Message: fmt.Sprintf(format, args...)}
+}
+
+// format is a type recognised by its magic bytes
+type format struct {
+	kind, mime, ext string
+}
+
+// sniff identifies the file type from its first bytes, ignoring the file name
+func sniff(head []byte) (format, bool) {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}):
+		return format{KindImage, "image/jpeg", ".jpg"}, true
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return format{KindImage, "image/png", ".png"}, true
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return format{KindImage, "image/gif", ".gif"}, true
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return format{KindImage, "image/webp", ".webp"}, true
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "AVI ":
+		return format{KindVideo, "video/x-msvideo", ".avi"}, true
+	case bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return format{KindVideo, "video/webm", ".webm"}, true
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		// ISO base media: the major brand tells QuickTime, MP4 and still-image formats (HEIC, AVIF) apart
+		switch string(head[8:12]) {
+		case "qt  ":
+			return format{KindVideo, "video/quicktime", ".mov"}, true
+		case "heic", "heix", "hevc", "mif1", "msf1", "avif":
+			return format{}, false
+		}
+		return format{KindVideo, "video/mp4", ".mp4"}, true
+	}
+	return format{}, false
+}
+
+// Inspect validates the file at path against the limits and the allowed kinds
+// and describes it. Failures are *Error values.
+func Inspect(path string, limits Limits, allowedKinds ...string) (*Info, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		if err == io.EOF {
+			return nil, invalid("the file is empty")
+		}
+		return nil, err
+	}
+	detected, ok := sniff(head[:n])
+	if !ok || !contains(allowedKinds, detected.kind) {
+		return nil, unsupported("unsupported media type; expected %s", describe(allowedKinds))
+	}
+
+	// Hash the whole file; the size comes from what was actually read
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{
+		Kind:   detected.kind,
+		MIME:   detected.mime,
+		Ext:    detected.ext,
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	}
+
+	if detected.kind == KindImage {
+		if limits.MaxImageBytes > 0 && size > limits.MaxImageBytes {
+			return nil, tooLarge("images may be at most %d MB", limits.MaxImageBytes>>20)
+		}
+		if err := inspectImage(file, info, limits); err != nil {
+			return nil, err
+		}
+		return info, nil
+	}
+
+	if limits.MaxVideoBytes > 0 && size > limits.MaxVideoBytes {
+		return nil, tooLarge("videos may be at most %d MB", limits.MaxVideoBytes>>20)
+	}
+	if err := probeVideo(path, info, limits); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// inspectImage checks the dimensions from the header before decoding the whole
+// image, so an oversized (decompression bomb) image is never expanded in memory
+func inspectImage(file *os.File, info *Info, limits Limits) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return invalid("the image could not be read: %v", err)
+	}
+	info.Width, info.Height = cfg.Width, cfg.Height
+	if err := checkDimensions(cfg.Width, cfg.Height, limits); err != nil {
+		return err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, _, err := image.Decode(file); err != nil {
+		return invalid("the image is corrupted: %v", err)
+	}
+	return nil
+}
+
+// probeTimeout bounds ffprobe on hostile or very large files
+const probeTimeout = 30 * time.Second
+
+// RequireProbe reports an error when ffprobe, which validates videos, is not installed
+func RequireProbe() error {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		return fmt.Errorf("videos are validated with ffprobe, which is not installed: %v", err)
+	}
+	return nil
+}
+
+// probeVideo reads the video's dimensions and duration with ffprobe. Without
+// ffprobe installed, videos are refused rather than accepted unchecked.
+func probeVideo(path string, info *Info, limits Limits) error {
+	if err := RequireProbe(); err != nil {
+		log.Printf("⚠️  Refusing %s: %v", info.MIME, err)
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=width,height:format=duration",
+		"-of", "json",
+		path,
+	).Output()
+	if err != nil {
+		return invalid("the video could not be read; it may be corrupted")
+	}
+
+	var probe struct {
+		Streams []struct {
+			Width  int `json:"width"`
+			Height int `json:"height"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+	if len(probe.Streams) == 0 {
+		return invalid("the file has no video stream")
+	}
+
+	info.Width, info.Height = probe.Streams[0].Width, probe.Streams[0].Height
+	info.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	if err := checkDimensions(info.Width, info.Height, limits); err != nil {
+		return err
+	}
+	if limits.MaxVideoSeconds > 0 && info.Duration > limits.MaxVideoSeconds {
+		return invalid("videos may be at most %.0f seconds long (got %.0f)", limits.MaxVideoSeconds, info.Duration)
+	}
+	return nil
+}
+
+func checkDimensions(width, height int, limits Limits) error {
+	if limits.MinDimension > 0 && (width < limits.MinDimension || height < limits.MinDimension) {
+		return invalid("media must be at least %dx%d pixels (got %dx%d)", limits.MinDimension, limits.MinDimension, width, height)
+	}
+	if limits.MaxDimension > 0 && (width > limits.MaxDimension || height > limits.MaxDimension) {
+		return invalid("media may be at most %dx%d pixels (got %dx%d)", limits.MaxDimension, limits.MaxDimension, width, height)
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(kinds []string) string {
+	if contains(kinds, KindVideo) {
+		return "a JPEG, PNG, GIF or WebP image, or an MP4, MOV, WebM or AVI video"
+	}
+	return "a JPEG, PNG, GIF or WebP image"
+}
+
+// AsError returns the validation error inside err, if there is one
+func AsError(err error) (*Error, bool) {
+	var mediaErr *Error
+	ok := errors.As(err, &mediaErr)
+	return mediaErr, ok
+}
@@ -0,0 +1,110 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSniff(t *testing.T) {
+	ftyp := func(brand string) []byte { return []byte("\x00\x00\x00\x18ftyp" + brand + "\x00\x00\x00\x00") }
+	tests := []struct {
+		name   string
+		head   []byte
+		want   format
+		wantOK bool
+	}{
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0, 0x10, 'J', 'F', 'I', 'F'}, format{KindImage, "image/jpeg", ".jpg"}, true},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), format{KindImage, "image/png", ".png"}, true},
+		{"gif87a", []byte("GIF87a\x01\x00"), format{KindImage, "image/gif", ".gif"}, true},
+		{"gif89a", []byte("GIF89a\x01\x00"), format{KindImage, "image/gif", ".gif"}, true},
+		{"webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), format{KindImage, "image/webp", ".webp"}, true},
+		{"avi", []byte("RIFF\x24\x00\x00\x00AVI LIST"), format{KindVideo, "video/x-msvideo", ".avi"}, true},
+		{"wav", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), format{}, false},
+		{"webm", []byte{0x1A, 0x45, 0xDF, 0xA3, 0x9F, 0x42, 0x86, 0x81}, format{KindVideo, "video/webm", ".webm"}, true},
+		{"mp4", ftyp("isom"), format{KindVideo, "video/mp4", ".mp4"}, true},
+		{"m4v", ftyp("mp42"), format{KindVideo, "video/mp4", ".mp4"}, true},
+		{"quicktime", ftyp("qt  "), format{KindVideo, "video/quicktime", ".mov"}, true},
+		{"heic", ftyp("heic"), format{}, false},
+		{"avif", ftyp("avif"), format{}, false},
+		{"html", []byte("<!DOCTYPE html><script>"), format{}, false},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg">`), format{}, false},
+		{"pdf", []byte("%PDF-1.7"), format{}, false},
+		{"truncated riff", []byte("RIFF\x24\x00"), format{}, false},
+		{"empty", nil, format{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := sniff(tt.head)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: sniff() = %+v, %v, want %+v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	dir := t.TempDir()
+	writePNG := func(name string, width, height int) string {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+			t.Fatalf("encode png: %v", err)
+		}
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, buf.Bytes(), 0644)
+		return path
+	}
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, data, 0644)
+		return path
+	}
+	photo := writePNG("photo.png", 400, 300)
+	photoBytes, _ := os.ReadFile(photo)
+	limits := Limits{MaxImageBytes: 1 << 20, MinDimension: 200, MaxDimension: 4096}
+
+	tests := []struct {
+		name       string
+		path       string
+		limits     Limits
+		kinds      []string
+		wantStatus int // 0 when the file is accepted
+	}{
+		{"png", photo, limits, []string{KindImage}, 0},
+		{"png named as jpg", write("photo.jpg", photoBytes), limits, []string{KindImage}, 0},
+		{"too small", writePNG("small.png", 100, 300), limits, []string{KindImage}, 422},
+		{"too large in pixels", writePNG("wide.png", 5000, 300), limits, []string{KindImage}, 422},
+		{"too large in bytes", photo, Limits{MaxImageBytes: 100}, []string{KindImage}, 413},
+		{"truncated png", write("truncated.png", photoBytes[:len(photoBytes)/2]), limits, []string{KindImage}, 422},
+		{"html named as png", write("page.png", []byte("<html><script>alert(1)</script></html>")), limits, []string{KindImage}, 415},
+		{"video where only images are allowed", write("clip.mp4", []byte("\x00\x00\x00\x18ftypisom\x00\x00\x00\x00")), limits, []string{KindImage}, 415},
+		{"empty", write("empty.png", nil), limits, []string{KindImage}, 422},
+	}
+	for _, tt := range tests {
+		info, err := Inspect(tt.path, tt.limits, tt.kinds...)
+		if tt.wantStatus == 0 {
+			if err != nil {
+				t.Errorf("%s: Inspect() error = %v", tt.name, err)
+			} else if info.MIME != "image/png" || info.Ext != ".png" || info.Width != 400 || info.Height != 300 || len(info.SHA256) != 64 {
+				t.Errorf("%s: Inspect() = %+v", tt.name, info)
+			}
+			continue
+		}
+		mediaErr, ok := AsError(err)
+		if !ok || mediaErr.Status != tt.wantStatus {
+			t.Errorf("%s: Inspect() error = %v, want status %d", tt.name, err, tt.wantStatus)
+		}
+	}
+}
+
+func TestInspectVideoWithoutProbe(t *testing.T) {
+	if RequireProbe() == nil {
+		t.Skip("ffprobe is installed")
+	}
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	os.WriteFile(path, []byte("\x00\x00\x00\x18ftypisom\x00\x00\x00\x00"), 0644)
+	if info, err := Inspect(path, Limits{}, KindImage, KindVideo); err == nil {
+		t.Errorf("Inspect() of an unprobed video = %+v, want an error", info)
+	}
+}
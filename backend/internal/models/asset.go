@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Asset kinds
+const (
+	AssetKindProductImage   = "product_image"   // Uploaded product photo
+	AssetKindPersonMedia    = "person_media"    // Uploaded presenter photo or video
+	AssetKindConvertedImage = "converted_image" // PNG copy made for a vendor that does not take the original format
+	AssetKindAvatarVideo    = "avatar_video"    // Talking avatar clip
+	AssetKindProductVideo   = "product_video"   // Product showcase clip
+	AssetKindFinalVideo     = "final_video"     // Composited video
+	AssetKindWebsite        = "website"         // Generated landing page (its index.html)
+	AssetKindSubtitles      = "subtitles"       // Captions timed to the presenter's speech, as .srt or .vtt
+	AssetKindThumbnail      = "thumbnail"       // Candidate cover of a final video: one of its frames, or its title card
+	AssetKindStream         = "stream"          // HLS master playlist of a final video; its renditions are stored next to it
+)
+
+// Asset is a stored file belonging to a project: an upload, an intermediate clip or
+// a final output. ParentIDs link it to the assets it was made from.
+type Asset struct {
+	ID              string    `json:"id" gorm:"primaryKey"`
+	ProjectID       string    `json:"project_id" gorm:"uniqueIndex:idx_assets_project_key"`
+	JobID           string    `json:"job_id,omitempty" gorm:"index"` // Job that produced it; empty for uploads
+	Kind            string    `json:"kind"`                          // One of the AssetKind constants
+	StorageKey      string    `json:"storage_key" gorm:"uniqueIndex:idx_assets_project_key"`
+	MimeType        string    `json:"mime_type"`
+	SizeBytes       int64     `json:"size_bytes"`
+	Width           int       `json:"width,omitempty"`
+	Height          int       `json:"height,omitempty"`
+	DurationSeconds float64   `json:"duration_seconds,omitempty"`
+	AspectRatio     string    `json:"aspect_ratio,omitempty"`   // Composites and their thumbnails, e.g. "9:16"
+	FrameSeconds    *float64  `json:"frame_seconds,omitempty"`  // Thumbnails: where in the video the frame is; nil for title cards
+	Checksum        string    `json:"checksum"`                 // SHA-256 of the contents
+	Provider        string    `json:"provider,omitempty"`       // Provider that produced it; empty for uploads and server-side files
+	RemoteTaskID    string    `json:"remote_task_id,omitempty"` // The provider's task
+	RemoteURL       string    `json:"remote_url,omitempty"`     // Where the provider served it; vendors expire these
+	ParentIDs       []string  `json:"parent_ids" gorm:"serializer:json"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func (a *Asset) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BrandKit is a workspace's look, applied to every video and website it generates:
+// the logo (the video watermark and end card), the palette and fonts of its
+// websites, and bumper videos played before and after its composites
+type BrandKit struct {
+	ID                string    `json:"id" gorm:"primaryKey"`
+	WorkspaceID       string    `json:"workspace_id" gorm:"uniqueIndex"`
+	LogoPath          string    `json:"logo_path,omitempty"`  // Storage key of the logo image
+	PrimaryColor      string    `json:"primary_color"`        // #RRGGBB; also the end card's background
+	SecondaryColor    string    `json:"secondary_color"`      // #RRGGBB; the other end of gradients
+	HeadingFont       string    `json:"heading_font"`         // Google Fonts family, e.g. "Poppins"
+	BodyFont          string    `json:"body_font"`            // Google Fonts family
+	IntroPath         string    `json:"intro_path,omitempty"` // Storage key of a video played before the composite
+	OutroPath         string    `json:"outro_path,omitempty"` // Storage key of a video played after it, instead of the end card
+	WatermarkPosition string    `json:"watermark_position"`   // "topLeft", "topRight", "bottomLeft" or "bottomRight"
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func (b *BrandKit) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Job statuses
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// Job types
+const (
+	JobTypeVideo       = "video"       // Full pipeline: avatar, product clip and composite
+	JobTypeRecomposite = "recomposite" // Composite only, from the clips of an earlier run
+)
+
+// Job is a persisted unit of background work, e.g. generating a project's video
+type Job struct {
+	ID               string            `json:"id" gorm:"primaryKey"`
+	ProjectID        string            `json:"project_id" gorm:"index"`
+	Type             string            `json:"type"`                // One of the JobType constants
+	Status           string            `json:"status"`              // "queued", "running", "completed", "failed", "cancelled"
+	Stage            string            `json:"stage"`               // "queued", "avatar", "product", "composite", "done"
+	Progress         int               `json:"progress"`            // 0-100
+	Options          string            `json:"options,omitempty"`   // JSON-encoded request options
+	ScriptID         string            `json:"script_id,omitempty"` // The approved script the avatar clip speaks
+	ScriptVersion    int               `json:"script_version,omitempty"`
+	Error            string            `json:"error,omitempty"`
+	AvatarVideoPath  string            `json:"avatar_video_path,omitempty"`
+	ProductVideoPath string            `json:"product_video_path,omitempty"`
+	AvatarTaskID     string            `json:"avatar_task_id,omitempty"` // Remote task IDs, re-polled when a run is resumed
+	ProductTaskID    string            `json:"product_task_id,omitempty"`
+	CompositeTaskID  string            `json:"composite_task_id,omitempty"`
+	Attempts         int               `json:"attempts"`                                 // Runs started, including resumes after a restart
+	ResultPath       string            `json:"result_path,omitempty"`                    // The primary render
+	Renders          map[string]string `json:"renders,omitempty" gorm:"serializer:json"` // Final video per aspect ratio, filled in as each finishes
+	SubtitlesPath    string            `json:"subtitles_path,omitempty"`                 // WebVTT captions; the .srt copy has the same name
+	CoverPath        string            `json:"cover_path,omitempty"`                     // The primary render's title card, the default cover
+	Streams          map[string]string `json:"streams,omitempty" gorm:"serializer:json"` // HLS master playlist per render, by the render's key
+	StartedAt        *time.Time        `json:"started_at,omitempty"`
+	FinishedAt       *time.Time        `json:"finished_at,omitempty"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == "" {
+		j.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// IsActive reports whether the job is still waiting or running
+func (j *Job) IsActive() bool {
+	return j.Status == JobStatusQueued || j.Status == JobStatusRunning
+}
@@ -9,6 +9,8 @@ import (
 
 type Project struct {
 	ID                  string    `json:"id" gorm:"primaryKey"`
+	WorkspaceID         string    `json:"workspace_id,omitempty" gorm:"index"` // Empty = the server's own credentials
+	OwnerID             string    `json:"owner_id,omitempty" gorm:"index"`     // User who uploaded the project
 	ProductImagePath    string    `json:"product_image_path"`
 	PersonMediaPath     string    `json:"person_media_path"`
 	PersonMediaType     string    `json:"person_media_type"` // "image" or "video"
@@ -16,13 +18,19 @@ type Project struct {
 	ProductDescription  string    `json:"product_description"`
 	ProductCategory     string    `json:"product_category"`
 	ProductPrice        string    `json:"product_price"`
-	GeneratedScript     string    `json:"generated_script,omitempty"`     // AI-generated script
-	GeneratedVideoPath  string    `json:"generated_video_path,omitempty"`
+	GeneratedScript     string    `json:"generated_script,omitempty"`     // Text of the active script
+	ActiveScriptID      string    `json:"active_script_id,omitempty"`     // The Script videos are generated from
+	GeneratedVideoPath  string    `json:"generated_video_path,omitempty"` // The primary render
+	GeneratedVideos     map[string]string `json:"generated_videos,omitempty" gorm:"serializer:json"` // Every render by aspect ratio, e.g. "9:16"
+	SubtitlesPath       string    `json:"subtitles_path,omitempty"` // WebVTT captions of the video; the .srt copy has the same name
+	Streams             map[string]string `json:"streams,omitempty" gorm:"serializer:json"` // HLS master playlist of each render, by the render's key
+	CoverPath           string    `json:"cover_path,omitempty"`    // Thumbnail used as the website poster, Open Graph image and Instagram cover
+	CoverFrameSeconds   *float64  `json:"cover_frame_seconds,omitempty"` // Where in the video the cover frame is; nil for title cards
 	WebsitePath         string    `json:"website_path,omitempty"`
 	WebsiteURL          string    `json:"website_url,omitempty"`
 	InstagramPostID     string    `json:"instagram_post_id,omitempty"`     // Instagram post ID after upload
 	InstagramPostURL    string    `json:"instagram_post_url,omitempty"`    // Instagram post URL
-	Status              string    `json:"status"` // "uploaded", "video_generating", "video_complete", "website_generating", "website_complete", "deployed"
+	Status              string    `json:"status"` // One of the ProjectStatus constants; change it with Transition
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
 }
@@ -34,3 +42,11 @@ func (p *Project) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// VideoFor returns the project's render in an aspect ratio, or the primary render if there is none
+func (p *Project) VideoFor(ratio string) string {
+	if path, ok := p.GeneratedVideos[ratio]; ok {
+		return path
+	}
+	return p.GeneratedVideoPath
+}
+
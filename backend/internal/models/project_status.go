@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Project statuses
+const (
+	ProjectStatusUploaded           = "uploaded"
+	ProjectStatusVideoGenerating    = "video_generating"
+	ProjectStatusVideoComplete      = "video_complete"
+	ProjectStatusWebsiteGenerating  = "website_generating"
+	ProjectStatusWebsiteComplete    = "website_complete"
+	ProjectStatusInstagramUploading = "instagram_uploading"
+	ProjectStatusInstagramPosted    = "instagram_posted"
+	ProjectStatusCancelled          = "cancelled" // Video generation was stopped by the user
+)
+
+// projectTransitions lists the statuses a project may move to from each status.
+// The in-progress statuses may fall back to a settled status when their step fails.
+var projectTransitions = map[string][]string{
+	ProjectStatusUploaded: {
+		ProjectStatusVideoGenerating, ProjectStatusWebsiteGenerating,
+	},
+	ProjectStatusVideoGenerating: {
+		ProjectStatusVideoComplete, ProjectStatusUploaded, ProjectStatusCancelled,
+	},
+	ProjectStatusVideoComplete: {
+		ProjectStatusVideoGenerating, ProjectStatusWebsiteGenerating, ProjectStatusInstagramUploading,
+	},
+	ProjectStatusWebsiteGenerating: {
+		ProjectStatusWebsiteComplete, ProjectStatusUploaded, ProjectStatusVideoComplete, ProjectStatusInstagramPosted,
+		ProjectStatusCancelled,
+	},
+	ProjectStatusWebsiteComplete: {
+		ProjectStatusVideoGenerating, ProjectStatusWebsiteGenerating, ProjectStatusInstagramUploading,
+	},
+	ProjectStatusInstagramUploading: {
+		ProjectStatusInstagramPosted, ProjectStatusVideoComplete, ProjectStatusWebsiteComplete,
+	},
+	ProjectStatusInstagramPosted: {
+		ProjectStatusVideoGenerating, ProjectStatusWebsiteGenerating, ProjectStatusInstagramUploading,
+	},
+	ProjectStatusCancelled: {
+		ProjectStatusVideoGenerating, ProjectStatusWebsiteGenerating,
+	},
+}
+
+// CanTransition reports whether the project may move to the given status
+func (p *Project) CanTransition(to string) bool {
+	for _, allowed := range projectTransitions[p.Status] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition validates and persists a status change, together with the named
+// columns of p, e.g. "website_path". The update only applies if the stored status
+// is still the one this copy was loaded with, so concurrent requests cannot both
+// start the same step, nor overwrite each other's results.
+func (p *Project) Transition(tx *gorm.DB, to string, columns ...string) error {
+	if !p.CanTransition(to) {
+		return fmt.Errorf("project cannot move from %q to %q", p.Status, to)
+	}
+
+	updated := *p
+	updated.Status = to
+	result := tx.Model(&Project{}).
+		Where("id = ? AND status = ?", p.ID, p.Status).
+		Select(append([]string{"status"}, columns...)).
+		Updates(&updated)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update project status: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("project status changed concurrently, expected %q", p.Status)
+	}
+
+	p.Status = to
+	return nil
+}
+
+// SettledStatus is the status to fall back to when a video run fails
+func (p *Project) SettledStatus() string {
+	if p.GeneratedVideoPath != "" {
+		return ProjectStatusVideoComplete
+	}
+	return ProjectStatusUploaded
+}
@@ -0,0 +1,120 @@
+package models
+
+import "testing"
+
+func TestProjectCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{ProjectStatusUploaded, ProjectStatusVideoGenerating, true},
+		{ProjectStatusUploaded, ProjectStatusWebsiteGenerating, true},
+		{ProjectStatusUploaded, ProjectStatusVideoComplete, false},
+		{ProjectStatusUploaded, ProjectStatusInstagramUploading, false},
+		{ProjectStatusVideoGenerating, ProjectStatusVideoComplete, true},
+		{ProjectStatusVideoGenerating, ProjectStatusCancelled, true},
+		{ProjectStatusVideoGenerating, ProjectStatusUploaded, true},
+		{ProjectStatusVideoGenerating, ProjectStatusVideoGenerating, false},
+		{ProjectStatusVideoGenerating, ProjectStatusInstagramUploading, false},
+		{ProjectStatusVideoComplete, ProjectStatusInstagramUploading, true},
+		{ProjectStatusVideoComplete, ProjectStatusInstagramPosted, false},
+		{ProjectStatusWebsiteGenerating, ProjectStatusWebsiteComplete, true},
+		{ProjectStatusWebsiteGenerating, ProjectStatusWebsiteGenerating, false},
+		{ProjectStatusInstagramUploading, ProjectStatusInstagramPosted, true},
+		{ProjectStatusInstagramUploading, ProjectStatusInstagramUploading, false},
+		{ProjectStatusInstagramPosted, ProjectStatusVideoGenerating, true},
+		{ProjectStatusCancelled, ProjectStatusVideoGenerating, true},
+		{ProjectStatusCancelled, ProjectStatusVideoComplete, false},
+		{"", ProjectStatusVideoGenerating, false},
+		{ProjectStatusUploaded, "published", false},
+	}
+	for _, tt := range tests {
+		p := &Project{Status: tt.from}
+		if got := p.CanTransition(tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q -> %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestProjectTransition(t *testing.T) {
+	tests := []struct {
+		name       string
+		stored     string // Status in the database
+		loaded     string // Status of the copy being transitioned
+		to         string
+		wantErr    bool
+		wantStatus string // Status in the database afterwards
+	}{
+		{"allowed", ProjectStatusUploaded, ProjectStatusUploaded, ProjectStatusVideoGenerating, false, ProjectStatusVideoGenerating},
+		{"not allowed", ProjectStatusUploaded, ProjectStatusUploaded, ProjectStatusInstagramPosted, true, ProjectStatusUploaded},
+		{"stale copy", ProjectStatusVideoGenerating, ProjectStatusUploaded, ProjectStatusVideoGenerating, true, ProjectStatusVideoGenerating},
+		{"changed behind its back", ProjectStatusCancelled, ProjectStatusVideoGenerating, ProjectStatusVideoComplete, true, ProjectStatusCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			stored := &Project{Status: tt.stored}
+			if err := db.Create(stored).Error; err != nil {
+				t.Fatalf("create project: %v", err)
+			}
+
+			loaded := &Project{ID: stored.ID, Status: tt.loaded}
+			err := loaded.Transition(db, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Transition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && loaded.Status != tt.to {
+				t.Errorf("loaded.Status = %q, want %q", loaded.Status, tt.to)
+			}
+
+			var reloaded Project
+			db.First(&reloaded, "id = ?", stored.ID)
+			if reloaded.Status != tt.wantStatus {
+				t.Errorf("stored status = %q, want %q", reloaded.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestProjectTransitionColumns(t *testing.T) {
+	db := newTestDB(t)
+	project := &Project{Status: ProjectStatusVideoGenerating, WebsitePath: "site/index.html"}
+	if err := db.Create(project).Error; err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+
+	project.GeneratedVideoPath = "video.mp4"
+	project.GeneratedVideos = map[string]string{"9:16": "video.mp4", "1:1": "video_1x1.mp4"}
+	project.WebsitePath = "" // Not named, so not saved
+	if err := project.Transition(db, ProjectStatusVideoComplete, "generated_video_path", "generated_videos"); err != nil {
+		t.Fatalf("Transition() error = %v", err)
+	}
+
+	var reloaded Project
+	db.First(&reloaded, "id = ?", project.ID)
+	if reloaded.Status != ProjectStatusVideoComplete {
+		t.Errorf("status = %q, want %q", reloaded.Status, ProjectStatusVideoComplete)
+	}
+	if reloaded.GeneratedVideoPath != "video.mp4" || reloaded.GeneratedVideos["1:1"] != "video_1x1.mp4" {
+		t.Errorf("named columns not saved: %q, %v", reloaded.GeneratedVideoPath, reloaded.GeneratedVideos)
+	}
+	if reloaded.WebsitePath != "site/index.html" {
+		t.Errorf("website_path = %q, want it left alone", reloaded.WebsitePath)
+	}
+}
+
+func TestSettledStatus(t *testing.T) {
+	tests := []struct {
+		video string
+		want  string
+	}{
+		{"", ProjectStatusUploaded},
+		{"video.mp4", ProjectStatusVideoComplete},
+	}
+	for _, tt := range tests {
+		p := &Project{GeneratedVideoPath: tt.video}
+		if got := p.SettledStatus(); got != tt.want {
+			t.Errorf("SettledStatus() with video %q = %q, want %q", tt.video, got, tt.want)
+		}
+	}
+}
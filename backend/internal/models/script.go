@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dealshare/hacathon/backend/internal/compliance"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Script review statuses. Only an approved script can be rendered or published.
+const (
+	ScriptStatusDraft    = "draft"
+	ScriptStatusInReview = "in_review"
+	ScriptStatusApproved = "approved"
+	ScriptStatusRejected = "rejected"
+)
+
+// scriptTransitions lists the review statuses a script may move to from each status.
+// Approved and rejected scripts are final; changes are made in a new version.
+var scriptTransitions = map[string][]string{
+	ScriptStatusDraft:    {ScriptStatusInReview},
+	ScriptStatusInReview: {ScriptStatusApproved, ScriptStatusRejected, ScriptStatusDraft},
+}
+
+// Script is one version of one variant of a project's presenter script. Versions
+// are never changed: editing a script saves a new version of it, which is reviewed
+// again. The project's active script is the one its videos are generated from.
+type Script struct {
+	ID              string               `json:"id" gorm:"primaryKey"`
+	ProjectID       string               `json:"project_id" gorm:"index"`
+	RootID          string               `json:"root_id" gorm:"index"`  // The first version; every version of the script shares it
+	PreviousID      string               `json:"previous_id,omitempty"` // The version this one was edited from
+	Version         int                  `json:"version"`               // 1 for a written variant, counting up with each edit
+	Text            string               `json:"text"`
+	Tone            string               `json:"tone"`                            // "professional", "emotional", "benefits" or "hype"
+	DurationSeconds int                  `json:"duration_seconds"`                // Target length read aloud
+	Audience        string               `json:"audience,omitempty"`              // Who it was written for
+	Language        string               `json:"language"`                        // Language code, e.g. "en"
+	Provider        string               `json:"provider,omitempty"`              // Script writer that wrote it; empty for human edits
+	AuthorID        string               `json:"author_id,omitempty"`             // User who generated or edited it
+	Status          string               `json:"status"`                          // One of the ScriptStatus constants; change it with Transition
+	ReviewerID      string               `json:"reviewer_id,omitempty"`           // User who approved or rejected it
+	ReviewComment   string               `json:"review_comment,omitempty"`        // The reviewer's reasons
+	ApprovedBy      string               `json:"approved_by,omitempty"`           // User who approved it; never its author
+	Findings        []compliance.Finding `json:"findings" gorm:"serializer:json"` // Compliance findings on the text; hard ones block approval
+	SubmittedAt     *time.Time           `json:"submitted_at,omitempty"`
+	ReviewedAt      *time.Time           `json:"reviewed_at,omitempty"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+func (s *Script) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	if s.RootID == "" {
+		s.RootID = s.ID
+	}
+	if s.Version == 0 {
+		s.Version = 1
+	}
+	if s.Status == "" {
+		s.Status = ScriptStatusDraft
+	}
+	return nil
+}
+
+// Approved reports whether the script may be rendered and published
+func (s *Script) Approved() bool {
+	return s.Status == ScriptStatusApproved
+}
+
+// CanTransition reports whether the script may move to the given review status
+func (s *Script) CanTransition(to string) bool {
+	for _, allowed := range scriptTransitions[s.Status] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition validates and persists a review status change together with the
+// review fields in changes. Like Project.Transition, it only applies if the stored
+// status is still the one this copy was loaded with.
+func (s *Script) Transition(tx *gorm.DB, to string, changes map[string]interface{}) error {
+	if !s.CanTransition(to) {
+		return fmt.Errorf("script cannot move from %q to %q", s.Status, to)
+	}
+
+	updates := map[string]interface{}{"status": to}
+	for column, value := range changes {
+		updates[column] = value
+	}
+	result := tx.Model(&Script{}).
+		Where("id = ? AND status = ?", s.ID, s.Status).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update script status: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("script status changed concurrently, expected %q", s.Status)
+	}
+
+	s.Status = to
+	return tx.First(s, "id = ?", s.ID).Error
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "models.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&Project{}, &Script{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestScriptCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{ScriptStatusDraft, ScriptStatusInReview, true},
+		{ScriptStatusDraft, ScriptStatusApproved, false},
+		{ScriptStatusDraft, ScriptStatusRejected, false},
+		{ScriptStatusInReview, ScriptStatusApproved, true},
+		{ScriptStatusInReview, ScriptStatusRejected, true},
+		{ScriptStatusInReview, ScriptStatusDraft, true},
+		{ScriptStatusApproved, ScriptStatusDraft, false},
+		{ScriptStatusApproved, ScriptStatusInReview, false},
+		{ScriptStatusApproved, ScriptStatusRejected, false},
+		{ScriptStatusRejected, ScriptStatusApproved, false},
+		{ScriptStatusRejected, ScriptStatusInReview, false},
+		{"", ScriptStatusApproved, false},
+	}
+	for _, tt := range tests {
+		s := &Script{Status: tt.from}
+		if got := s.CanTransition(tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q -> %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestScriptBeforeCreate(t *testing.T) {
+	db := newTestDB(t)
+	first := &Script{ProjectID: "p1", Text: "Meet the kettle."}
+	if err := db.Create(first).Error; err != nil {
+		t.Fatalf("create script: %v", err)
+	}
+	edit := &Script{ProjectID: "p1", RootID: first.RootID, PreviousID: first.ID, Version: 2, Text: "Meet the new kettle."}
+	if err := db.Create(edit).Error; err != nil {
+		t.Fatalf("create edit: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		script      *Script
+		wantRoot    string
+		wantVersion int
+	}{
+		{"first version", first, first.ID, 1},
+		{"edit", edit, first.ID, 2},
+	}
+	for _, tt := range tests {
+		if tt.script.RootID != tt.wantRoot || tt.script.Version != tt.wantVersion || tt.script.Status != ScriptStatusDraft {
+			t.Errorf("%s: root %q version %d status %q, want root %q version %d status %q", tt.name,
+				tt.script.RootID, tt.script.Version, tt.script.Status, tt.wantRoot, tt.wantVersion, ScriptStatusDraft)
+		}
+	}
+}
+
+func TestScriptTransition(t *testing.T) {
+	tests := []struct {
+		name       string
+		stored     string
+		loaded     string
+		to         string
+		wantErr    bool
+		wantStatus string
+	}{
+		{"submit", ScriptStatusDraft, ScriptStatusDraft, ScriptStatusInReview, false, ScriptStatusInReview},
+		{"approve", ScriptStatusInReview, ScriptStatusInReview, ScriptStatusApproved, false, ScriptStatusApproved},
+		{"approve a draft", ScriptStatusDraft, ScriptStatusDraft, ScriptStatusApproved, true, ScriptStatusDraft},
+		{"reject after approval", ScriptStatusApproved, ScriptStatusInReview, ScriptStatusRejected, true, ScriptStatusApproved},
+		{"approve after withdrawal", ScriptStatusDraft, ScriptStatusInReview, ScriptStatusApproved, true, ScriptStatusDraft},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			stored := &Script{ProjectID: "p1", Status: tt.stored}
+			if err := db.Create(stored).Error; err != nil {
+				t.Fatalf("create script: %v", err)
+			}
+
+			loaded := &Script{ID: stored.ID, Status: tt.loaded}
+			err := loaded.Transition(db, tt.to, map[string]interface{}{"reviewer_id": "reviewer-1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Transition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var reloaded Script
+			db.First(&reloaded, "id = ?", stored.ID)
+			if reloaded.Status != tt.wantStatus {
+				t.Errorf("stored status = %q, want %q", reloaded.Status, tt.wantStatus)
+			}
+			wantReviewer := ""
+			if !tt.wantErr {
+				wantReviewer = "reviewer-1"
+			}
+			if reloaded.ReviewerID != wantReviewer {
+				t.Errorf("reviewer_id = %q, want %q", reloaded.ReviewerID, wantReviewer)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// User roles, each allowing everything the previous one does
+const (
+	RoleViewer    = "viewer"    // Read projects, jobs and progress
+	RoleEditor    = "editor"    // Upload, generate videos and websites, cancel jobs, edit scripts
+	RoleReviewer  = "reviewer"  // Approve or reject scripts
+	RolePublisher = "publisher" // Post to Instagram, manage users and workspace credentials
+)
+
+var roleRank = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleReviewer: 3, RolePublisher: 4}
+
+// ValidRole reports whether role is one of the Role constants
+func ValidRole(role string) bool {
+	return roleRank[role] > 0
+}
+
+// User is an account that signs in with a password or an API token.
+// A user belongs to one workspace, or to none for agency-level accounts.
+type User struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	Email        string    `json:"email" gorm:"uniqueIndex"`
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"` // One of the Role constants
+	WorkspaceID  string    `json:"workspace_id,omitempty" gorm:"index"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == "" {
+		u.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// HasRole reports whether the user's role includes the given one
+func (u *User) HasRole(role string) bool {
+	return roleRank[u.Role] >= roleRank[role] && roleRank[role] > 0
+}
+
+// CanAccessProject reports whether the project is the user's own, belongs to the
+// user's workspace, or belongs to a workspace the user manages
+func (u *User) CanAccessProject(p *Project) bool {
+	if p.OwnerID == u.ID {
+		return true
+	}
+	if p.WorkspaceID == "" {
+		return false
+	}
+	return p.WorkspaceID == u.WorkspaceID || u.CanManageWorkspace(p.WorkspaceID)
+}
+
+// ManagesEveryWorkspace reports whether the user is an agency-level publisher
+func (u *User) ManagesEveryWorkspace() bool {
+	return u.WorkspaceID == "" && u.HasRole(RolePublisher)
+}
+
+// CanManageWorkspace reports whether the user may change the workspace's credentials
+// and members, and create its projects from outside it. Agency-level publishers
+// manage every workspace; other publishers only their own.
+func (u *User) CanManageWorkspace(workspaceID string) bool {
+	if !u.HasRole(RolePublisher) {
+		return false
+	}
+	return u.WorkspaceID == "" || u.WorkspaceID == workspaceID
+}
+
+// APIToken is a long-lived credential for scripts and integrations. It acts with
+// its user's role; only a hash of the token is stored.
+type APIToken struct {
+	ID         string     `json:"id" gorm:"primaryKey"`
+	UserID     string     `json:"user_id" gorm:"index"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (t *APIToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
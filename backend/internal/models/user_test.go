@@ -0,0 +1,86 @@
+package models
+
+import "testing"
+
+func TestUserHasRole(t *testing.T) {
+	tests := []struct {
+		role, required string
+		want           bool
+	}{
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleEditor, false},
+		{RoleEditor, RoleViewer, true},
+		{RoleEditor, RoleReviewer, false},
+		{RoleReviewer, RoleEditor, true},
+		{RoleReviewer, RolePublisher, false},
+		{RolePublisher, RoleReviewer, true},
+		{"", RoleViewer, false},
+		{"admin", RoleViewer, false},
+		{RolePublisher, "admin", false},
+	}
+	for _, tt := range tests {
+		u := &User{Role: tt.role}
+		if got := u.HasRole(tt.required); got != tt.want {
+			t.Errorf("HasRole(%q has %q) = %v, want %v", tt.role, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestUserCanManageWorkspace(t *testing.T) {
+	tests := []struct {
+		name      string
+		user      User
+		workspace string
+		want      bool
+	}{
+		{"agency publisher", User{Role: RolePublisher}, "ws-1", true},
+		{"agency reviewer", User{Role: RoleReviewer}, "ws-1", false},
+		{"publisher in the workspace", User{Role: RolePublisher, WorkspaceID: "ws-1"}, "ws-1", true},
+		{"publisher in another workspace", User{Role: RolePublisher, WorkspaceID: "ws-2"}, "ws-1", false},
+		{"editor in the workspace", User{Role: RoleEditor, WorkspaceID: "ws-1"}, "ws-1", false},
+	}
+	for _, tt := range tests {
+		if got := tt.user.CanManageWorkspace(tt.workspace); got != tt.want {
+			t.Errorf("%s: CanManageWorkspace(%q) = %v, want %v", tt.name, tt.workspace, got, tt.want)
+		}
+	}
+}
+
+func TestUserCanAccessProject(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    User
+		project Project
+		want    bool
+	}{
+		{"owner", User{ID: "u1", Role: RoleViewer}, Project{OwnerID: "u1"}, true},
+		{"owner outside the project's workspace", User{ID: "u1", Role: RoleEditor, WorkspaceID: "ws-2"}, Project{OwnerID: "u1", WorkspaceID: "ws-1"}, true},
+		{"member of the workspace", User{ID: "u2", Role: RoleViewer, WorkspaceID: "ws-1"}, Project{OwnerID: "u1", WorkspaceID: "ws-1"}, true},
+		{"member of another workspace", User{ID: "u2", Role: RolePublisher, WorkspaceID: "ws-2"}, Project{OwnerID: "u1", WorkspaceID: "ws-1"}, false},
+		{"agency publisher, workspace project", User{ID: "u2", Role: RolePublisher}, Project{OwnerID: "u1", WorkspaceID: "ws-1"}, true},
+		{"agency publisher, someone's own project", User{ID: "u2", Role: RolePublisher}, Project{OwnerID: "u1"}, false},
+		{"agency editor, workspace project", User{ID: "u2", Role: RoleEditor}, Project{OwnerID: "u1", WorkspaceID: "ws-1"}, false},
+		{"agency editor, someone's own project", User{ID: "u2", Role: RoleEditor}, Project{OwnerID: "u1"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.user.CanAccessProject(&tt.project); got != tt.want {
+			t.Errorf("%s: CanAccessProject() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUserManagesEveryWorkspace(t *testing.T) {
+	tests := []struct {
+		user User
+		want bool
+	}{
+		{User{Role: RolePublisher}, true},
+		{User{Role: RolePublisher, WorkspaceID: "ws-1"}, false},
+		{User{Role: RoleReviewer}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.user.ManagesEveryWorkspace(); got != tt.want {
+			t.Errorf("ManagesEveryWorkspace(%+v) = %v, want %v", tt.user, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package music is the library of royalty-free background tracks a composite
+// can be scored with. Tracks are local files described by MUSIC_DIR/library.yaml.
+package music
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile lists the library's tracks, next to the audio files it names
+const ManifestFile = "library.yaml"
+
+// Track is a background music file tagged by mood
+type Track struct {
+	Name   string   `yaml:"name" json:"name"`
+	Title  string   `yaml:"title" json:"title,omitempty"`
+	File   string   `yaml:"file" json:"-"` // Relative to the library directory
+	Moods  []string `yaml:"moods" json:"moods"`
+	Credit string   `yaml:"credit" json:"credit,omitempty"` // Attribution the licence asks for
+	Path   string   `yaml:"-" json:"-"`                     // Absolute path of File
+}
+
+// None picks no music
+const None = "none"
+
+var (
+	namePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+	extensions  = map[string]bool{".mp3": true, ".m4a": true, ".aac": true, ".wav": true, ".ogg": true, ".flac": true}
+)
+
+// library holds the tracks loaded from MUSIC_DIR
+var library = struct {
+	sync.RWMutex
+	tracks map[string]*Track
+}{tracks: map[string]*Track{}}
+
+// LoadDir replaces the library with the tracks in dir's manifest. A missing
+// directory or manifest leaves the library empty; an invalid manifest, or one
+// naming a missing file, is an error.
+func LoadDir(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var manifest struct {
+		Tracks []Track `yaml:"tracks"`
+	}
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&manifest); err != nil {
+		return fmt.Errorf("%s: %v", ManifestFile, err)
+	}
+
+	loaded := map[string]*Track{}
+	for i := range manifest.Tracks {
+		track := manifest.Tracks[i]
+		if err := track.validate(); err != nil {
+			return fmt.Errorf("%s: track %d: %v", ManifestFile, i+1, err)
+		}
+		if _, dup := loaded[track.Name]; dup {
+			return fmt.Errorf("%s: track %s is listed twice", ManifestFile, track.Name)
+		}
+		path, err := filepath.Abs(filepath.Join(dir, track.File))
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("%s: track %s: %v", ManifestFile, track.Name, err)
+		}
+		track.Path = path
+		loaded[track.Name] = &track
+	}
+
+	library.Lock()
+	defer library.Unlock()
+	library.tracks = loaded
+	return nil
+}
+
+func (t *Track) validate() error {
+	if !namePattern.MatchString(t.Name) || t.Name == None {
+		return fmt.Errorf("name %q must be lower case letters, digits and underscores, and not %q", t.Name, None)
+	}
+	if t.File == "" || filepath.IsAbs(t.File) || strings.Contains(filepath.ToSlash(t.File), "..") {
+		return fmt.Errorf("%s: file must be a path inside the music directory", t.Name)
+	}
+	if !extensions[strings.ToLower(filepath.Ext(t.File))] {
+		return fmt.Errorf("%s: file must be .mp3, .m4a, .aac, .wav, .ogg or .flac", t.Name)
+	}
+	if len(t.Moods) == 0 {
+		return fmt.Errorf("%s: needs at least one mood", t.Name)
+	}
+	for i, mood := range t.Moods {
+		t.Moods[i] = strings.ToLower(strings.TrimSpace(mood))
+		if !namePattern.MatchString(t.Moods[i]) {
+			return fmt.Errorf("%s: mood %q must be lower case letters, digits and underscores", t.Name, mood)
+		}
+	}
+	return nil
+}
+
+// Get returns the named track
+func Get(name string) (*Track, bool) {
+	library.RLock()
+	defer library.RUnlock()
+	track, ok := library.tracks[name]
+	return track, ok
+}
+
+// Tracks lists the library by name
+func Tracks() []*Track {
+	library.RLock()
+	defer library.RUnlock()
+	tracks := make([]*Track, 0, len(library.tracks))
+	for _, track := range library.tracks {
+		tracks = append(tracks, track)
+	}
+	sort.Slice(tracks, func(i, j int) bool { return tracks[i].Name < tracks[j].Name })
+	return tracks
+}
+
+// Moods lists every mood some track is tagged with
+func Moods() []string {
+	seen := map[string]bool{}
+	moods := []string{}
+	for _, track := range Tracks() {
+		for _, mood := range track.Moods {
+			if !seen[mood] {
+				seen[mood] = true
+				moods = append(moods, mood)
+			}
+		}
+	}
+	sort.Strings(moods)
+	return moods
+}
+
+// Pick resolves a request's music choice: a track name, or a mood, from whose
+// tracks one is picked at random. Empty or "none" picks no track.
+func Pick(choice string) (*Track, error) {
+	choice = strings.ToLower(strings.TrimSpace(choice))
+	if choice == "" || choice == None {
+		return nil, nil
+	}
+	if track, ok := Get(choice); ok {
+		return track, nil
+	}
+
+	var matches []*Track
+	for _, track := range Tracks() {
+		for _, mood := range track.Moods {
+			if mood == choice {
+				matches = append(matches, track)
+				break
+			}
+		}
+	}
+	if len(matches) == 0 {
+		if len(Tracks()) == 0 {
+			return nil, fmt.Errorf("no music library is installed (add tracks to %s in MUSIC_DIR)", ManifestFile)
+		}
+		return nil, fmt.Errorf("unknown music %q: expected a track or a mood (%s)", choice, strings.Join(Moods(), ", "))
+	}
+	return matches[rand.Intn(len(matches))], nil
+}
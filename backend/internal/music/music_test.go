@@ -0,0 +1,124 @@
+package music
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeLibrary creates a music directory with the manifest and empty audio files
+func writeLibrary(t *testing.T, manifest string, files ...string) string {
+	dir := t.TempDir()
+	for _, file := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0755)
+		os.WriteFile(filepath.Join(dir, file), nil, 0644)
+	}
+	if manifest != "" {
+		os.WriteFile(filepath.Join(dir, ManifestFile), []byte(manifest), 0644)
+	}
+	return dir
+}
+
+const testManifest = `tracks:
+  - name: sunny_day
+    title: Sunny Day
+    file: upbeat/sunny.mp3
+    moods: [Upbeat, happy]
+    credit: "Music by A. Composer"
+  - name: slow_burn
+    file: calm.m4a
+    moods: [calm]
+`
+
+func TestLoadDir(t *testing.T) {
+	dir := writeLibrary(t, testManifest, "upbeat/sunny.mp3", "calm.m4a")
+	if err := LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+
+	track, ok := Get("sunny_day")
+	if !ok {
+		t.Fatalf("sunny_day was not loaded (have %v)", Tracks())
+	}
+	if track.Path != filepath.Join(dir, "upbeat", "sunny.mp3") || track.Credit != "Music by A. Composer" {
+		t.Errorf("sunny_day = %+v", track)
+	}
+	if got := strings.Join(Moods(), ","); got != "calm,happy,upbeat" {
+		t.Errorf("Moods() = %s, want calm,happy,upbeat (lower-cased and sorted)", got)
+	}
+	if tracks := Tracks(); len(tracks) != 2 || tracks[0].Name != "slow_burn" {
+		t.Errorf("Tracks() = %v, want both tracks by name", tracks)
+	}
+
+	// A directory without a manifest is not an error
+	if err := LoadDir(t.TempDir()); err != nil {
+		t.Errorf("LoadDir() without a manifest error = %v", err)
+	}
+	if err := LoadDir(writeLibrary(t, "tracks: []\n")); err != nil || len(Tracks()) != 0 {
+		t.Errorf("LoadDir(empty manifest) = %v with %d tracks left", err, len(Tracks()))
+	}
+}
+
+func TestLoadDirRejectsBadManifests(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{"unknown field", "tracks:\n  - name: a\n    file: a.mp3\n    moods: [calm]\n    bpm: 120\n", "bpm"},
+		{"bad name", "tracks:\n  - name: Sunny Day\n    file: a.mp3\n    moods: [calm]\n", "lower case"},
+		{"reserved name", "tracks:\n  - name: none\n    file: a.mp3\n    moods: [calm]\n", "lower case"},
+		{"outside the directory", "tracks:\n  - name: a\n    file: ../a.mp3\n    moods: [calm]\n", "inside the music directory"},
+		{"not audio", "tracks:\n  - name: a\n    file: a.txt\n    moods: [calm]\n", "must be .mp3"},
+		{"no moods", "tracks:\n  - name: a\n    file: a.mp3\n", "at least one mood"},
+		{"missing file", "tracks:\n  - name: a\n    file: b.mp3\n    moods: [calm]\n", "b.mp3"},
+		{"duplicate", "tracks:\n  - name: a\n    file: a.mp3\n    moods: [calm]\n  - name: a\n    file: a.mp3\n    moods: [calm]\n", "listed twice"},
+	}
+	for _, tt := range tests {
+		err := LoadDir(writeLibrary(t, tt.manifest, "a.mp3", "a.txt"))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: LoadDir() error = %v, want one mentioning %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPick(t *testing.T) {
+	if err := LoadDir(writeLibrary(t, "tracks: []\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Pick("calm"); err == nil || !strings.Contains(err.Error(), "no music library") {
+		t.Errorf("Pick() without a library error = %v", err)
+	}
+
+	if err := LoadDir(writeLibrary(t, testManifest, "upbeat/sunny.mp3", "calm.m4a")); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		choice  string
+		want    string // Empty for no track
+		wantErr bool
+	}{
+		{"", "", false},
+		{"none", "", false},
+		{" None ", "", false},
+		{"sunny_day", "sunny_day", false},
+		{"CALM", "slow_burn", false},
+		{"happy", "sunny_day", false},
+		{"jazz", "", true},
+	}
+	for _, tt := range tests {
+		track, err := Pick(tt.choice)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Pick(%q) error = %v, wantErr %v", tt.choice, err, tt.wantErr)
+			continue
+		}
+		got := ""
+		if track != nil {
+			got = track.Name
+		}
+		if got != tt.want {
+			t.Errorf("Pick(%q) = %q, want %q", tt.choice, got, tt.want)
+		}
+	}
+}
@@ -1,19 +1,29 @@
 package router
 
 import (
+	"github.com/dealshare/hacathon/backend/internal/auth"
 	"github.com/dealshare/hacathon/backend/internal/handlers"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/storage"
 	"github.com/gin-gonic/gin"
 )
 
-func Setup(h *handlers.Handlers) *gin.Engine {
+func Setup(h *handlers.Handlers, allowedOrigins []string) *gin.Engine {
 	r := gin.Default()
 
-	// CORS middleware
+	// CORS middleware: only the configured frontends may call the API from a browser
+	origins := map[string]bool{}
+	for _, origin := range allowedOrigins {
+		origins[origin] = true
+	}
 	r.Use(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		if origin := c.GetHeader("Origin"); origins[origin] {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+			c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+			c.Writer.Header().Add("Vary", "Origin")
+		}
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -23,21 +33,76 @@ func Setup(h *handlers.Handlers) *gin.Engine {
 		c.Next()
 	})
 
-	// API routes
-	api := r.Group("/api/v1")
+	// Public routes
+	public := r.Group("/api/v1/auth")
+	{
+		public.POST("/register", h.Register)
+		public.POST("/login", h.Login)
+	}
+
+	// API routes: every request is authenticated, and the role decides what it may do
+	api := r.Group("/api/v1", h.Auth().Middleware())
 	{
-		api.POST("/upload", h.UploadMedia)
+		// viewer
+		api.GET("/auth/me", h.Me)
+		api.POST("/auth/tokens", h.CreateAPIToken)
+		api.GET("/auth/tokens", h.GetAPITokens)
+		api.DELETE("/auth/tokens/:id", h.DeleteAPIToken)
 		api.GET("/projects", h.GetProjects)
 		api.GET("/projects/:id", h.GetProject)
-		api.POST("/projects/:id/generate-video", h.GenerateVideo)
-		api.POST("/projects/:id/generate-website", h.GenerateWebsite)
-		api.POST("/projects/:id/upload-to-instagram", h.UploadToInstagram)
+		api.GET("/projects/:id/events", h.ProjectEvents)
+		api.POST("/projects/:id/events/ticket", h.CreateEventsTicket)
+		api.GET("/projects/:id/assets", h.GetAssets)
+		api.GET("/projects/:id/covers", h.GetCovers)
+		api.GET("/projects/:id/scripts", h.GetScripts)
+		api.GET("/projects/:id/scripts/:scriptId/versions", h.GetScriptVersions)
+		api.GET("/projects/:id/compliance", h.GetCompliance)
+		api.GET("/projects/:id/assets/:assetId", h.GetAsset)
+		api.GET("/projects/:id/assets/:assetId/download", h.DownloadAsset)
+		api.GET("/jobs/:id", h.GetJob)
+		api.GET("/music", h.GetMusic)
+		api.GET("/workspaces", h.GetWorkspaces)
+		api.GET("/workspaces/:id", h.GetWorkspace)
+		api.GET("/workspaces/:id/brand-kit", h.GetBrandKit)
+
+		editor := api.Group("", auth.RequireRole(models.RoleEditor))
+		editor.POST("/upload", h.UploadMedia)
+		editor.POST("/projects/:id/generate-video", h.GenerateVideo)
+		editor.POST("/projects/:id/recomposite", h.Recomposite)
+		editor.POST("/projects/:id/cancel", h.CancelGeneration)
+		editor.POST("/projects/:id/generate-website", h.GenerateWebsite)
+		editor.PUT("/projects/:id/cover", h.SetCover)
+		editor.POST("/projects/:id/scripts", h.GenerateScripts)
+		editor.PUT("/projects/:id/script", h.SetActiveScript)
+		editor.POST("/projects/:id/scripts/:scriptId/versions", h.EditScript)
+		editor.POST("/projects/:id/scripts/:scriptId/submit", h.SubmitScript)
+		editor.POST("/projects/:id/scripts/:scriptId/withdraw", h.WithdrawScript)
+
+		reviewer := api.Group("", auth.RequireRole(models.RoleReviewer))
+		reviewer.POST("/projects/:id/scripts/:scriptId/review", h.ReviewScript)
+
+		publisher := api.Group("", auth.RequireRole(models.RolePublisher))
+		publisher.POST("/projects/:id/upload-to-instagram", h.UploadToInstagram)
+		publisher.POST("/workspaces", h.CreateWorkspace)
+		publisher.PUT("/workspaces/:id/credentials", h.UpdateWorkspaceCredentials)
+		publisher.PUT("/workspaces/:id/brand-kit", h.UpdateBrandKit)
+		publisher.POST("/users", h.CreateUser)
+		publisher.GET("/users", h.GetUsers)
+	}
+
+	// Serve stored files (uploads, generated videos and websites)
+	for route, prefix := range map[string]string{
+		"/static/uploads/*path":            storage.PrefixUploads,
+		"/static/generated/videos/*path":   storage.PrefixVideos,
+		"/static/generated/websites/*path": storage.PrefixWebsites,
+	} {
+		r.GET(route, h.ServeStored(prefix))
+		r.HEAD(route, h.ServeStored(prefix))
 	}
 
-	// Serve static files (generated videos and websites)
-	r.Static("/static/uploads", "./uploads")
-	r.Static("/static/generated/videos", "./generated/videos")
-	r.Static("/static/generated/websites", "./generated/websites")
+	// Signed media URLs for vendors; the signature stands in for authentication
+	r.GET(storage.MediaRoute+"*path", h.ServeSignedMedia)
+	r.HEAD(storage.MediaRoute+"*path", h.ServeSignedMedia)
 
 	return r
 }
@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Envelope encrypts records at rest with envelope encryption: every record gets
+// its own random data key, and only that data key is encrypted with the master key.
+// Rotating the master key therefore means re-wrapping data keys, not re-encrypting data.
+type Envelope struct {
+	master cipher.AEAD
+}
+
+// sealed is the stored form of an envelope-encrypted record
+type sealed struct {
+	Version    int    `json:"version"`
+	WrappedKey []byte `json:"wrapped_key"` // Data key encrypted with the master key
+	KeyNonce   []byte `json:"key_nonce"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// NewEnvelope takes the base64-encoded 32-byte master key (e.g. from `openssl rand -base64 32`)
+func NewEnvelope(masterKey string) (*Envelope, error) {
+	key, err := base64.StdEncoding.DecodeString(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("master key is not valid base64: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("master key must be 32 bytes, got %d", len(key))
+	}
+	master, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{master: master}, nil
+}
+
+// Seal encrypts plaintext under a fresh data key and returns the record to store.
+// The record only opens with the same context, e.g. the ID of the row it is stored
+// in, so it cannot be copied to another row and decrypted there.
+func (e *Envelope) Seal(plaintext, context []byte) (string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %v", err)
+	}
+	data, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	record := sealed{Version: 1}
+	if record.KeyNonce, err = randomNonce(e.master); err != nil {
+		return "", err
+	}
+	if record.Nonce, err = randomNonce(data); err != nil {
+		return "", err
+	}
+	record.WrappedKey = e.master.Seal(nil, record.KeyNonce, dataKey, context)
+	record.Ciphertext = data.Seal(nil, record.Nonce, plaintext, context)
+
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// Open decrypts a record produced by Seal with the same context
+func (e *Envelope) Open(stored string, context []byte) ([]byte, error) {
+	var record sealed
+	if err := json.Unmarshal([]byte(stored), &record); err != nil {
+		return nil, fmt.Errorf("invalid encrypted record: %v", err)
+	}
+	if record.Version != 1 {
+		return nil, fmt.Errorf("unsupported encrypted record version %d", record.Version)
+	}
+
+	dataKey, err := e.master.Open(nil, record.KeyNonce, record.WrappedKey, context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: wrong master key, record of another owner or corrupted record")
+	}
+	data, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := data.Open(nil, record.Nonce, record.Ciphertext, context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt record: corrupted ciphertext")
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func randomNonce(aead cipher.AEAD) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	return nonce, nil
+}
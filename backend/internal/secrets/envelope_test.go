@@ -0,0 +1,106 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testMasterKey(fill byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{fill}, 32))
+}
+
+func TestNewEnvelope(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"32 bytes", testMasterKey(1), false},
+		{"16 bytes", base64.StdEncoding.EncodeToString(make([]byte, 16)), true},
+		{"64 bytes", base64.StdEncoding.EncodeToString(make([]byte, 64)), true},
+		{"not base64", "not base64!", true},
+		{"empty", "", true},
+	}
+	for _, tt := range tests {
+		_, err := NewEnvelope(tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: NewEnvelope() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	e, err := NewEnvelope(testMasterKey(1))
+	if err != nil {
+		t.Fatalf("NewEnvelope: %v", err)
+	}
+	for _, plaintext := range []string{"", "sk-live-123", strings.Repeat("credential ", 1000)} {
+		stored, err := e.Seal([]byte(plaintext), []byte("workspace-1"))
+		if err != nil {
+			t.Fatalf("Seal() error = %v", err)
+		}
+		if plaintext != "" && strings.Contains(stored, plaintext) {
+			t.Errorf("Seal() stored the plaintext as is")
+		}
+		opened, err := e.Open(stored, []byte("workspace-1"))
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		if string(opened) != plaintext {
+			t.Errorf("Open() = %q, want %q", opened, plaintext)
+		}
+	}
+
+	first, _ := e.Seal([]byte("same"), nil)
+	second, _ := e.Seal([]byte("same"), nil)
+	if first == second {
+		t.Errorf("Seal() of the same plaintext twice gave the same record")
+	}
+}
+
+func TestEnvelopeOpenRejects(t *testing.T) {
+	e, _ := NewEnvelope(testMasterKey(1))
+	other, _ := NewEnvelope(testMasterKey(2))
+	stored, err := e.Seal([]byte("sk-live-123"), []byte("workspace-1"))
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	another, _ := e.Seal([]byte("sk-live-456"), []byte("workspace-1"))
+
+	modify := func(change func(r, another *sealed)) string {
+		var r, a sealed
+		json.Unmarshal([]byte(stored), &r)
+		json.Unmarshal([]byte(another), &a)
+		change(&r, &a)
+		out, _ := json.Marshal(r)
+		return string(out)
+	}
+
+	tests := []struct {
+		name     string
+		envelope *Envelope
+		stored   string
+		context  string
+	}{
+		{"wrong master key", other, stored, "workspace-1"},
+		{"another workspace", e, stored, "workspace-2"},
+		{"no context", e, stored, ""},
+		{"flipped ciphertext", e, modify(func(r, _ *sealed) { r.Ciphertext[0] ^= 1 }), "workspace-1"},
+		{"flipped wrapped key", e, modify(func(r, _ *sealed) { r.WrappedKey[0] ^= 1 }), "workspace-1"},
+		{"flipped nonce", e, modify(func(r, _ *sealed) { r.Nonce[0] ^= 1 }), "workspace-1"},
+		{"flipped key nonce", e, modify(func(r, _ *sealed) { r.KeyNonce[0] ^= 1 }), "workspace-1"},
+		{"another record's data key", e, modify(func(r, a *sealed) { r.WrappedKey, r.KeyNonce = a.WrappedKey, a.KeyNonce }), "workspace-1"},
+		{"truncated ciphertext", e, modify(func(r, _ *sealed) { r.Ciphertext = r.Ciphertext[:len(r.Ciphertext)-1] }), "workspace-1"},
+		{"unknown version", e, modify(func(r, _ *sealed) { r.Version = 2 }), "workspace-1"},
+		{"not json", e, "sk-live-123", "workspace-1"},
+		{"empty", e, "", "workspace-1"},
+	}
+	for _, tt := range tests {
+		if plaintext, err := tt.envelope.Open(tt.stored, []byte(tt.context)); err == nil {
+			t.Errorf("%s: Open() = %q, want an error", tt.name, plaintext)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package secrets
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Keystore is a local file of secrets encrypted with a passphrase
+// (scrypt key derivation, AES-256-GCM). Manage it with cmd/keystore.
+type Keystore struct {
+	path   string
+	values map[string]string
+}
+
+// keystoreFile is the on-disk format
+type keystoreFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// scrypt parameters recommended for interactive use
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	keystoreSalt = 16
+)
+
+// OpenKeystore decrypts the keystore at path. A missing file is an empty keystore.
+func OpenKeystore(path, passphrase string) (*Keystore, error) {
+	ks := &Keystore{path: path, values: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ks, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %v", err)
+	}
+
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid keystore %s: %v", path, err)
+	}
+	if file.Version != 1 {
+		return nil, fmt.Errorf("unsupported keystore version %d", file.Version)
+	}
+
+	gcm, err := keystoreCipher(passphrase, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore %s: wrong passphrase or corrupted file", path)
+	}
+	if err := json.Unmarshal(plaintext, &ks.values); err != nil {
+		return nil, fmt.Errorf("invalid keystore contents: %v", err)
+	}
+	return ks, nil
+}
+
+func (k *Keystore) Name() string { return "keystore:" + k.path }
+
+func (k *Keystore) Lookup(key string) (string, bool, error) {
+	value, ok := k.values[key]
+	return value, ok && value != "", nil
+}
+
+// Set stores a secret; call Save to write it to disk
+func (k *Keystore) Set(key, value string) {
+	k.values[key] = value
+}
+
+// Delete removes a secret; call Save to write it to disk
+func (k *Keystore) Delete(key string) {
+	delete(k.values, key)
+}
+
+// Keys lists the stored secret names, never their values
+func (k *Keystore) Keys() []string {
+	keys := make([]string, 0, len(k.values))
+	for key := range k.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Save encrypts the keystore with a fresh salt and nonce and writes it atomically
+func (k *Keystore) Save(passphrase string) error {
+	plaintext, err := json.Marshal(k.values)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, keystoreSalt)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %v", err)
+	}
+	gcm, err := keystoreCipher(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	data, err := json.MarshalIndent(keystoreFile{
+		Version:    1,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(k.path), 0700); err != nil {
+		return fmt.Errorf("failed to create keystore directory: %v", err)
+	}
+	tmp := k.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write keystore: %v", err)
+	}
+	return os.Rename(tmp, k.path)
+}
+
+func keystoreCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive keystore key: %v", err)
+	}
+	return newGCM(key)
+}
@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets", "keystore.json")
+	ks, err := OpenKeystore(path, "correct horse")
+	if err != nil {
+		t.Fatalf("OpenKeystore() of a missing file: %v", err)
+	}
+	ks.Set("SHOTSTACK_API_KEY", "sk-shot")
+	ks.Set("GEMINI_API_KEY", "sk-gem")
+	ks.Set("REMOVED", "x")
+	ks.Delete("REMOVED")
+	if err := ks.Save("correct horse"); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "sk-shot") {
+		t.Errorf("keystore file contains a secret in plain text")
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Errorf("Stat() error = %v", err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("keystore file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	reopened, err := OpenKeystore(path, "correct horse")
+	if err != nil {
+		t.Fatalf("OpenKeystore() error = %v", err)
+	}
+	if got, want := reopened.Keys(), []string{"GEMINI_API_KEY", "SHOTSTACK_API_KEY"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{"SHOTSTACK_API_KEY", "sk-shot", true},
+		{"GEMINI_API_KEY", "sk-gem", true},
+		{"REMOVED", "", false},
+		{"shotstack_api_key", "", false},
+	}
+	for _, tt := range tests {
+		got, ok, err := reopened.Lookup(tt.key)
+		if err != nil || got != tt.want || ok != tt.wantOK {
+			t.Errorf("Lookup(%q) = %q, %v, %v, want %q, %v", tt.key, got, ok, err, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestOpenKeystoreRejects(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keystore.json")
+	ks, _ := OpenKeystore(path, "correct horse")
+	ks.Set("SHOTSTACK_API_KEY", "sk-shot")
+	if err := ks.Save("correct horse"); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+
+	write := func(name string, change func(f *keystoreFile)) string {
+		var f keystoreFile
+		json.Unmarshal(data, &f)
+		change(&f)
+		out, _ := json.Marshal(f)
+		target := filepath.Join(dir, name)
+		os.WriteFile(target, out, 0600)
+		return target
+	}
+	garbage := filepath.Join(dir, "garbage.json")
+	os.WriteFile(garbage, []byte("SHOTSTACK_API_KEY=sk-shot"), 0600)
+
+	tests := []struct {
+		name       string
+		path       string
+		passphrase string
+	}{
+		{"wrong passphrase", path, "wrong horse"},
+		{"flipped ciphertext", write("flipped.json", func(f *keystoreFile) { f.Ciphertext[0] ^= 1 }), "correct horse"},
+		{"other salt", write("salt.json", func(f *keystoreFile) { f.Salt[0] ^= 1 }), "correct horse"},
+		{"unknown version", write("version.json", func(f *keystoreFile) { f.Version = 2 }), "correct horse"},
+		{"not json", garbage, "correct horse"},
+	}
+	for _, tt := range tests {
+		if _, err := OpenKeystore(tt.path, tt.passphrase); err == nil {
+			t.Errorf("%s: OpenKeystore() succeeded, want an error", tt.name)
+		}
+	}
+}
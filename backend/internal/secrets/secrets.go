@@ -0,0 +1,132 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Source looks up named secrets such as "SHOTSTACK_API_KEY"
+type Source interface {
+	Name() string
+	// Lookup returns the secret and whether the source has it
+	Lookup(key string) (string, bool, error)
+}
+
+// Env reads secrets from environment variables (and therefore from .env)
+type Env struct{}
+
+func (Env) Name() string { return "env" }
+
+func (Env) Lookup(key string) (string, bool, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return "", false, nil
+	}
+	return value, true, nil
+}
+
+// Files reads one secret per file, the way Docker and Kubernetes mount secrets
+// (e.g. /run/secrets/shotstack_api_key). Both the exact and the lower-case key are tried.
+type Files struct {
+	Dir string
+}
+
+func (f Files) Name() string { return "file:" + f.Dir }
+
+func (f Files) Lookup(key string) (string, bool, error) {
+	for _, name := range []string{key, strings.ToLower(key)} {
+		data, err := os.ReadFile(filepath.Join(f.Dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read secret %s: %v", name, err)
+		}
+		if value := strings.TrimSpace(string(data)); value != "" {
+			return value, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// Chain asks each source in order and returns the first secret found
+type Chain []Source
+
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, source := range c {
+		names[i] = source.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (c Chain) Lookup(key string) (string, bool, error) {
+	for _, source := range c {
+		value, ok, err := source.Lookup(key)
+		if err != nil {
+			return "", false, err
+		}
+		if ok {
+			return value, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// First returns the first of several alternative keys that is set, e.g. a key and its legacy name
+func First(source Source, keys ...string) (string, error) {
+	for _, key := range keys {
+		value, ok, err := source.Lookup(key)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return value, nil
+		}
+	}
+	return "", nil
+}
+
+// Options selects and configures the secret sources
+type Options struct {
+	Sources      []string // In lookup order: "env", "file", "keystore"
+	Dir          string   // Directory for the "file" source
+	KeystorePath string   // Encrypted keystore for the "keystore" source
+}
+
+// Open builds the lookup chain. The keystore passphrase is itself read from the
+// environment or a mounted file as SECRETS_KEYSTORE_PASSPHRASE.
+func Open(opts Options) (Chain, error) {
+	var chain Chain
+	for _, name := range opts.Sources {
+		switch strings.TrimSpace(name) {
+		case "":
+			continue
+		case "env":
+			chain = append(chain, Env{})
+		case "file":
+			chain = append(chain, Files{Dir: opts.Dir})
+		case "keystore":
+			passphrase, err := First(Chain{Env{}, Files{Dir: opts.Dir}}, "SECRETS_KEYSTORE_PASSPHRASE")
+			if err != nil {
+				return nil, err
+			}
+			if passphrase == "" {
+				return nil, fmt.Errorf("the keystore secret source needs SECRETS_KEYSTORE_PASSPHRASE")
+			}
+			keystore, err := OpenKeystore(opts.KeystorePath, passphrase)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, keystore)
+		default:
+			return nil, fmt.Errorf("unknown secret source %q (available: env, file, keystore)", name)
+		}
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no secret sources configured")
+	}
+	return chain, nil
+}
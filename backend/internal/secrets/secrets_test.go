@@ -0,0 +1,56 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChainLookup(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "shotstack_api_key"), []byte("from-file\n"), 0600)
+	os.WriteFile(filepath.Join(dir, "EMPTY_KEY"), []byte("  \n"), 0600)
+	t.Setenv("SHOTSTACK_API_KEY", "from-env")
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("V0_API_KEY", "")
+
+	tests := []struct {
+		name   string
+		chain  Chain
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{"env first", Chain{Env{}, Files{Dir: dir}}, "SHOTSTACK_API_KEY", "from-env", true},
+		{"file first", Chain{Files{Dir: dir}, Env{}}, "SHOTSTACK_API_KEY", "from-file", true},
+		{"empty env var is unset", Chain{Env{}}, "GEMINI_API_KEY", "", false},
+		{"empty file is unset", Chain{Files{Dir: dir}}, "EMPTY_KEY", "", false},
+		{"missing everywhere", Chain{Env{}, Files{Dir: dir}}, "V0_API_KEY", "", false},
+	}
+	for _, tt := range tests {
+		got, ok, err := tt.chain.Lookup(tt.key)
+		if err != nil || got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: Lookup(%q) = %q, %v, %v, want %q, %v", tt.name, tt.key, got, ok, err, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{"env and file", Options{Sources: []string{"env", " file"}, Dir: t.TempDir()}, false},
+		{"unknown source", Options{Sources: []string{"env", "vault"}}, true},
+		{"no sources", Options{Sources: []string{"", " "}}, true},
+		{"keystore without passphrase", Options{Sources: []string{"keystore"}, Dir: t.TempDir()}, true},
+	}
+	t.Setenv("SECRETS_KEYSTORE_PASSPHRASE", "")
+	for _, tt := range tests {
+		_, err := Open(tt.opts)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Open() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
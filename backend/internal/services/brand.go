@@ -0,0 +1,318 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/storage"
+)
+
+// The look of websites without a brand kit
+const (
+	defaultPrimaryColor   = "#667eea"
+	defaultSecondaryColor = "#764ba2"
+	defaultFont           = "Inter"
+	defaultWatermark      = "bottomRight"
+)
+
+// Watermark and end card placement in composites
+const (
+	watermarkScale   = 0.18 // Logo width as a fraction of the frame's shorter side
+	watermarkMargin  = 0.03 // Gap to the frame's edges, as a fraction of its shorter side
+	watermarkOpacity = 0.8
+	endCardSeconds   = 3.0
+	endCardLogoScale = 0.4 // Logo width on the end card, as a fraction of the frame's shorter side
+)
+
+var (
+	brandColorPattern  = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+	brandFontPattern   = regexp.MustCompile(`^[A-Za-z0-9 ]{1,40}$`)
+	watermarkPositions = []string{"topLeft", "topRight", "bottomLeft", "bottomRight"}
+)
+
+// ValidateBrandKit checks the kit's colours, fonts and watermark position.
+// Empty values are allowed and take the defaults.
+func ValidateBrandKit(kit *models.BrandKit) error {
+	for field, color := range map[string]string{"primary_color": kit.PrimaryColor, "secondary_color": kit.SecondaryColor} {
+		if color != "" && !brandColorPattern.MatchString(color) {
+			return fmt.Errorf("%s must be a colour like \"#FF6600\"", field)
+		}
+	}
+	for field, font := range map[string]string{"heading_font": kit.HeadingFont, "body_font": kit.BodyFont} {
+		if font != "" && !brandFontPattern.MatchString(font) {
+			return fmt.Errorf("%s must be a Google Fonts family name, e.g. \"Poppins\"", field)
+		}
+	}
+	if kit.WatermarkPosition != "" {
+		valid := false
+		for _, position := range watermarkPositions {
+			valid = valid || kit.WatermarkPosition == position
+		}
+		if !valid {
+			return fmt.Errorf("watermark_position must be one of %s", strings.Join(watermarkPositions, ", "))
+		}
+	}
+	return nil
+}
+
+// Theme is the palette and fonts a website is styled with
+type Theme struct {
+	Primary     string // #RRGGBB
+	Secondary   string // #RRGGBB
+	HeadingFont string
+	BodyFont    string
+	LogoURL     string // Shown in the navigation instead of the product's initial; may be empty
+}
+
+// ThemeFor returns the theme of a workspace's websites: its brand kit with the
+// default look filling in whatever the kit leaves out. A nil kit is the default look.
+func ThemeFor(kit *models.BrandKit) Theme {
+	theme := Theme{
+		Primary:     defaultPrimaryColor,
+		Secondary:   defaultSecondaryColor,
+		HeadingFont: defaultFont,
+		BodyFont:    defaultFont,
+	}
+	if kit == nil {
+		return theme
+	}
+	pick := func(value, fallback string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	}
+	theme.Primary = pick(kit.PrimaryColor, theme.Primary)
+	theme.Secondary = pick(kit.SecondaryColor, theme.Secondary)
+	theme.HeadingFont = pick(kit.HeadingFont, theme.HeadingFont)
+	theme.BodyFont = pick(kit.BodyFont, theme.BodyFont)
+	theme.LogoURL = storage.PublicPath(kit.LogoPath)
+	return theme
+}
+
+// shades are the tints of a colour the websites use, lightest first, with the
+// amount of white (positive) or black (negative) mixed into the colour
+var shades = []struct {
+	name string
+	mix  float64
+}{
+	{"50", 0.92}, {"100", 0.84}, {"200", 0.68}, {"300", 0.5}, {"400", 0.3},
+	{"500", 0.12}, {"600", 0}, {"700", -0.15}, {"800", -0.3}, {"900", -0.45},
+}
+
+// CSSVariables declares the theme as CSS custom properties: --primary,
+// --primary-dark, --secondary, --font-heading and --font-body, plus tints
+// --brand-50 … --brand-900 of the primary colour and --accent-50 … --accent-900
+// of the secondary one (the x-600 tint is the colour itself)
+func (t Theme) CSSVariables() string {
+	var b strings.Builder
+	b.WriteString(":root {\n")
+	fmt.Fprintf(&b, "    --primary: %s;\n", t.Primary)
+	fmt.Fprintf(&b, "    --primary-dark: %s;\n", mixColor(t.Primary, -0.15))
+	fmt.Fprintf(&b, "    --secondary: %s;\n", t.Secondary)
+	fmt.Fprintf(&b, "    --font-heading: '%s', -apple-system, BlinkMacSystemFont, 'Segoe UI', sans-serif;\n", t.HeadingFont)
+	fmt.Fprintf(&b, "    --font-body: '%s', -apple-system, BlinkMacSystemFont, 'Segoe UI', sans-serif;\n", t.BodyFont)
+	for _, palette := range []struct{ name, color string }{{"brand", t.Primary}, {"accent", t.Secondary}} {
+		for _, shade := range shades {
+			fmt.Fprintf(&b, "    --%s-%s: %s;\n", palette.name, shade.name, mixColor(palette.color, shade.mix))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// TailwindColors maps Tailwind's brand-* and accent-* colour classes to the
+// theme's CSS variables, for the tailwind.config of the v0-style pages
+func (t Theme) TailwindColors() string {
+	var palettes []string
+	for _, name := range []string{"brand", "accent"} {
+		var entries []string
+		for _, shade := range shades {
+			entries = append(entries, fmt.Sprintf("%s: 'var(--%s-%s)'", shade.name, name, shade.name))
+		}
+		palettes = append(palettes, fmt.Sprintf("%s: { %s }", name, strings.Join(entries, ", ")))
+	}
+	return strings.Join(palettes, ",\n                        ")
+}
+
+// FontsLink is the Google Fonts stylesheet that loads the theme's fonts
+func (t Theme) FontsLink() string {
+	families := []string{t.HeadingFont}
+	if t.BodyFont != t.HeadingFont {
+		families = append(families, t.BodyFont)
+	}
+	query := make([]string, len(families))
+	for i, family := range families {
+		query[i] = "family=" + strings.ReplaceAll(url.QueryEscape(family), "%20", "+") + ":wght@300;400;500;600;700;900"
+	}
+	return fmt.Sprintf(`<link href="https://fonts.googleapis.com/css2?%s&display=swap" rel="stylesheet">`, strings.Join(query, "&"))
+}
+
+// mixColor mixes a #RRGGBB colour with white (amount > 0) or black (amount < 0)
+func mixColor(color string, amount float64) string {
+	target := 255.0
+	if amount < 0 {
+		target, amount = 0, -amount
+	}
+	mixed := "#"
+	for i := 1; i < 7; i += 2 {
+		channel, _ := strconv.ParseUint(color[i:i+2], 16, 8)
+		mixed += fmt.Sprintf("%02x", int(float64(channel)+(target-float64(channel))*amount+0.5))
+	}
+	return mixed
+}
+
+// Branding is a brand kit as applied to a composite, with local file paths
+type Branding struct {
+	Logo      string // Watermark and end card image; empty for neither
+	Intro     string // Video played before the composite
+	Outro     string // Video played after it, instead of the end card
+	Color     string // End card and letterbox colour, #RRGGBB
+	Watermark string // Corner the logo sits in, e.g. "bottomRight"
+}
+
+// endCard reports whether the composite closes on a card showing the logo
+func (b Branding) endCard() bool {
+	return b.Outro == "" && b.Logo != ""
+}
+
+// bumpers reports whether anything is played before or after the composite
+func (b Branding) bumpers() bool {
+	return b.Intro != "" || b.Outro != "" || b.endCard()
+}
+
+// branding fetches the files of the request's brand kit for the compositor
+func (p *Pipeline) branding(kit *models.BrandKit) (Branding, error) {
+	if kit == nil {
+		return Branding{}, nil
+	}
+	brand := Branding{Color: kit.PrimaryColor, Watermark: kit.WatermarkPosition}
+	if brand.Color == "" {
+		brand.Color = defaultPrimaryColor
+	}
+	if brand.Watermark == "" {
+		brand.Watermark = defaultWatermark
+	}
+
+	files := []struct {
+		name string
+		key  string
+		path *string
+	}{
+		{"logo", kit.LogoPath, &brand.Logo},
+		{"intro", kit.IntroPath, &brand.Intro},
+		{"outro", kit.OutroPath, &brand.Outro},
+	}
+	for _, file := range files {
+		if file.key == "" {
+			continue
+		}
+		local, err := p.generator.localFile(file.key)
+		if err != nil {
+			return Branding{}, fmt.Errorf("brand kit %s unavailable: %v", file.name, err)
+		}
+		*file.path = local
+	}
+	return brand, nil
+}
+
+// shotstackBranding is a brand laid out on a Shotstack timeline around a composite
+type shotstackBranding struct {
+	Offset    float64       // Length of the intro, by which the composite's own clips are delayed
+	Watermark []interface{} // The logo, above the composite
+	Bumpers   []interface{} // The intro, and the outro or the end card's logo
+	Card      []interface{} // The end card's background, below its logo
+}
+
+// shotstackBranding lays the brand out around a composite of the given length,
+// making its files reachable through host
+func (vg *VideoGenerator) shotstackBranding(brand Branding, host MediaHost, duration float64) (shotstackBranding, error) {
+	var layout shotstackBranding
+	hosted := map[string]string{}
+	for _, file := range []struct{ name, path string }{{"logo", brand.Logo}, {"intro", brand.Intro}, {"outro", brand.Outro}} {
+		if file.path == "" {
+			continue
+		}
+		url, err := host.Host(file.path)
+		if err != nil {
+			return layout, fmt.Errorf("failed to upload brand %s: %v", file.name, err)
+		}
+		hosted[file.name] = url
+	}
+
+	bumper := func(url string, start, length float64) map[string]interface{} {
+		return map[string]interface{}{
+			"asset":  map[string]interface{}{"type": "video", "src": url},
+			"start":  start,
+			"length": length,
+			"fit":    "contain",
+		}
+	}
+
+	if brand.Intro != "" {
+		layout.Offset = vg.bumperLength(brand.Intro)
+		layout.Bumpers = append(layout.Bumpers, bumper(hosted["intro"], 0, layout.Offset))
+	}
+	end := layout.Offset + duration
+
+	if brand.Logo != "" {
+		// Shotstack offsets are fractions of the frame, with y pointing up
+		offsetX, offsetY := watermarkMargin, watermarkMargin
+		if strings.HasSuffix(brand.Watermark, "Right") {
+			offsetX = -offsetX
+		}
+		if strings.HasPrefix(brand.Watermark, "top") {
+			offsetY = -offsetY
+		}
+		layout.Watermark = append(layout.Watermark, map[string]interface{}{
+			"asset":    map[string]interface{}{"type": "image", "src": hosted["logo"]},
+			"start":    layout.Offset,
+			"length":   duration,
+			"position": brand.Watermark,
+			"offset":   map[string]interface{}{"x": offsetX, "y": offsetY},
+			"fit":      "contain",
+			"scale":    watermarkScale,
+			"opacity":  watermarkOpacity,
+		})
+	}
+
+	switch {
+	case brand.Outro != "":
+		layout.Bumpers = append(layout.Bumpers, bumper(hosted["outro"], end, vg.bumperLength(brand.Outro)))
+	case brand.endCard():
+		layout.Card = append(layout.Card, map[string]interface{}{
+			"asset": map[string]interface{}{
+				"type":       "html",
+				"html":       "<div></div>",
+				"background": brand.Color,
+			},
+			"start":  end,
+			"length": endCardSeconds,
+		})
+		layout.Bumpers = append(layout.Bumpers, map[string]interface{}{
+			"asset":      map[string]interface{}{"type": "image", "src": hosted["logo"]},
+			"start":      end,
+			"length":     endCardSeconds,
+			"fit":        "contain",
+			"scale":      endCardLogoScale,
+			"transition": map[string]interface{}{"in": "fade"},
+		})
+	}
+	return layout, nil
+}
+
+// bumperLength is how long a bumper video plays, assuming endCardSeconds when
+// its length cannot be read
+func (vg *VideoGenerator) bumperLength(path string) float64 {
+	length, err := vg.getVideoDuration(path)
+	if err != nil || length <= 0 {
+		fmt.Printf("⚠️  Could not detect the length of %s, assuming %.0f seconds\n", filepath.Base(path), endCardSeconds)
+		return endCardSeconds
+	}
+	return length
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dealshare/hacathon/backend/internal/models"
+)
+
+func TestValidateBrandKit(t *testing.T) {
+	tests := []struct {
+		name    string
+		kit     models.BrandKit
+		wantErr string // Empty when the kit is valid
+	}{
+		{"empty kit takes the defaults", models.BrandKit{}, ""},
+		{"full kit", models.BrandKit{PrimaryColor: "#FF6600", SecondaryColor: "#1a1a2e", HeadingFont: "Playfair Display", BodyFont: "Inter", WatermarkPosition: "topLeft"}, ""},
+		{"short colour", models.BrandKit{PrimaryColor: "#F60"}, "primary_color"},
+		{"named colour", models.BrandKit{SecondaryColor: "orange"}, "secondary_color"},
+		{"font with markup", models.BrandKit{HeadingFont: "Inter');}"}, "heading_font"},
+		{"font name too long", models.BrandKit{BodyFont: strings.Repeat("A", 41)}, "body_font"},
+		{"unknown watermark position", models.BrandKit{WatermarkPosition: "center"}, "watermark_position"},
+	}
+	for _, tt := range tests {
+		err := ValidateBrandKit(&tt.kit)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: ValidateBrandKit() error = %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: ValidateBrandKit() error = %v, want one mentioning %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestThemeFor(t *testing.T) {
+	want := Theme{Primary: defaultPrimaryColor, Secondary: defaultSecondaryColor, HeadingFont: defaultFont, BodyFont: defaultFont}
+	if got := ThemeFor(nil); got != want {
+		t.Errorf("ThemeFor(nil) = %+v, want the default look", got)
+	}
+
+	kit := &models.BrandKit{PrimaryColor: "#FF6600", HeadingFont: "Poppins", LogoPath: "uploads/brand/logo.png"}
+	want = Theme{Primary: "#FF6600", Secondary: defaultSecondaryColor, HeadingFont: "Poppins", BodyFont: defaultFont, LogoURL: "/static/uploads/brand/logo.png"}
+	if got := ThemeFor(kit); got != want {
+		t.Errorf("ThemeFor(partial kit) = %+v, want %+v", got, want)
+	}
+}
+
+func TestMixColor(t *testing.T) {
+	tests := []struct {
+		color  string
+		amount float64
+		want   string
+	}{
+		{"#FF6600", 0, "#ff6600"},
+		{"#000000", 0.5, "#808080"},
+		{"#000000", 1, "#ffffff"},
+		{"#FFFFFF", -1, "#000000"},
+		{"#667eea", -0.15, "#576bc7"},
+	}
+	for _, tt := range tests {
+		if got := mixColor(tt.color, tt.amount); got != tt.want {
+			t.Errorf("mixColor(%s, %v) = %s, want %s", tt.color, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestThemeStyles(t *testing.T) {
+	theme := Theme{Primary: "#FF6600", Secondary: "#1A1A2E", HeadingFont: "Playfair Display", BodyFont: "Inter"}
+
+	css := theme.CSSVariables()
+	for _, want := range []string{
+		"--primary: #FF6600;",
+		"--secondary: #1A1A2E;",
+		"--brand-600: #ff6600;",
+		"--accent-600: #1a1a2e;",
+		"--font-heading: 'Playfair Display',",
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("CSSVariables() is missing %q:\n%s", want, css)
+		}
+	}
+
+	link := theme.FontsLink()
+	if !strings.Contains(link, "family=Playfair+Display:wght@") || !strings.Contains(link, "&family=Inter:wght@") {
+		t.Errorf("FontsLink() = %s", link)
+	}
+	theme.BodyFont = theme.HeadingFont
+	if link := theme.FontsLink(); strings.Count(link, "family=") != 1 {
+		t.Errorf("FontsLink() loads the same family twice: %s", link)
+	}
+}
+
+func TestWatermarkFilters(t *testing.T) {
+	tests := []struct {
+		position string
+		frame    canvas
+		overlay  string
+	}{
+		{"topLeft", canvas{1280, 720}, "overlay=x=21:y=21:"},
+		{"bottomRight", canvas{1280, 720}, "overlay=x=main_w-overlay_w-21:y=main_h-overlay_h-21:"},
+		{"topRight", canvas{720, 1280}, "overlay=x=main_w-overlay_w-21:y=21:"},
+	}
+	for _, tt := range tests {
+		filters := watermarkFilters(3, "v", tt.position, tt.frame)
+		if len(filters) != 2 {
+			t.Fatalf("watermarkFilters() = %v", filters)
+		}
+		// The logo is sized from the frame's shorter side, so it matches across ratios
+		if filters[0] != "[3:v]scale=128:-2,format=rgba,colorchannelmixer=aa=0.80[logo]" {
+			t.Errorf("%s: logo filter = %s", tt.position, filters[0])
+		}
+		if !strings.HasPrefix(filters[1], "[v][logo]"+tt.overlay) || !strings.HasSuffix(filters[1], "[watermarked]") {
+			t.Errorf("%s: overlay filter = %s, want %s", tt.position, filters[1], tt.overlay)
+		}
+	}
+}
+
+func TestBrandingBumpers(t *testing.T) {
+	tests := []struct {
+		name            string
+		brand           Branding
+		endCard, bumper bool
+	}{
+		{"no brand", Branding{}, false, false},
+		{"logo closes on an end card", Branding{Logo: "logo.png"}, true, true},
+		{"outro replaces the end card", Branding{Logo: "logo.png", Outro: "outro.mp4"}, false, true},
+		{"intro only", Branding{Intro: "intro.mp4"}, false, true},
+	}
+	for _, tt := range tests {
+		if got := tt.brand.endCard(); got != tt.endCard {
+			t.Errorf("%s: endCard() = %v, want %v", tt.name, got, tt.endCard)
+		}
+		if got := tt.brand.bumpers(); got != tt.bumper {
+			t.Errorf("%s: bumpers() = %v, want %v", tt.name, got, tt.bumper)
+		}
+	}
+}
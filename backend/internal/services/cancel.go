@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// TaskCanceller is implemented by providers whose vendor API can stop a remote
+// task, so a cancelled run does not keep rendering (and billing) in the background
+type TaskCanceller interface {
+	CancelTask(ctx context.Context, taskID string) error
+}
+
+// cancelTimeout bounds the vendor call made after a run has been cancelled
+const cancelTimeout = 30 * time.Second
+
+// WithContext returns a copy of the generator whose HTTP requests, polling
+// sleeps and ffmpeg processes stop as soon as ctx is done
+func (vg *VideoGenerator) WithContext(ctx context.Context) *VideoGenerator {
+	clone := *vg
+	clone.ctx = ctx
+	return &clone
+}
+
+// context returns the run's context, or Background for generators without one
+func (vg *VideoGenerator) context() context.Context {
+	if vg.ctx != nil {
+		return vg.ctx
+	}
+	return context.Background()
+}
+
+// sleep waits between polls, returning early with the context's error on cancellation
+func (vg *VideoGenerator) sleep(d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-vg.context().Done():
+		return vg.context().Err()
+	}
+}
+
+// head issues a HEAD request bound to the run's context
+func (vg *VideoGenerator) head(url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(vg.context(), "HEAD", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return vg.client.Do(req)
+}
+
+// cancelRemoteTask sends a DELETE for a vendor task and treats "already gone" as success
+func (vg *VideoGenerator) cancelRemoteTask(ctx context.Context, vendor, apiURL string, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create %s cancel request: %v", vendor, err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := vg.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s cancel request failed: %v", vendor, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s cancel error (%s): %s", vendor, resp.Status, string(bodyBytes))
+	}
+
+	fmt.Printf("🛑 Cancelled %s task: %s\n", vendor, apiURL)
+	return nil
+}
+
+// cancelDIDTask deletes a D-ID talk, which stops it if it is still rendering
+func (vg *VideoGenerator) cancelDIDTask(ctx context.Context, talkID string) error {
+	return vg.cancelRemoteTask(ctx, "D-ID", fmt.Sprintf("https://api.d-id.com/talks/%s", talkID), map[string]string{
+		"Authorization": vg.didAuthHeader(),
+	})
+}
+
+// cancelRunwayMLTask cancels a running RunwayML task (or deletes a finished one)
+func (vg *VideoGenerator) cancelRunwayMLTask(ctx context.Context, taskID string) error {
+	return vg.cancelRemoteTask(ctx, "RunwayML", fmt.Sprintf("https://api.dev.runwayml.com/v1/tasks/%s", taskID), map[string]string{
+		"Authorization":    "Bearer " + vg.config.RunwayMLAPIKey,
+		"X-Runway-Version": "2024-11-06",
+	})
+}
+
+// cancelSynthesiaTask deletes a Synthesia video, which stops it if it is still rendering
+func (vg *VideoGenerator) cancelSynthesiaTask(ctx context.Context, videoID string) error {
+	return vg.cancelRemoteTask(ctx, "Synthesia", fmt.Sprintf("https://api.synthesia.io/v2/videos/%s", videoID), map[string]string{
+		"Authorization": vg.config.AIAPIKey,
+	})
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dealshare/hacathon/backend/internal/config"
+)
+
+func TestCancelRemoteTask(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"cancelled", http.StatusNoContent, false},
+		{"already gone", http.StatusNotFound, false},
+		{"vendor error", http.StatusInternalServerError, true},
+		{"not allowed", http.StatusForbidden, true},
+	}
+	for _, tt := range tests {
+		var method, auth string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method, auth = r.Method, r.Header.Get("Authorization")
+			w.WriteHeader(tt.status)
+			w.Write([]byte("task is locked"))
+		}))
+
+		vg := NewVideoGenerator(&config.Config{})
+		err := vg.cancelRemoteTask(context.Background(), "Vendor", server.URL+"/tasks/t1", map[string]string{"Authorization": "Bearer key"})
+		server.Close()
+
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: cancelRemoteTask() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil && !strings.Contains(err.Error(), "task is locked") {
+			t.Errorf("%s: error %q does not carry the vendor's message", tt.name, err)
+		}
+		if method != "DELETE" || auth != "Bearer key" {
+			t.Errorf("%s: vendor got %s with Authorization %q", tt.name, method, auth)
+		}
+	}
+}
+
+func TestSleepStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	vg := NewVideoGenerator(&config.Config{}).WithContext(ctx)
+
+	cancel()
+	start := time.Now()
+	if err := vg.sleep(time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("sleep() = %v, want context.Canceled", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("sleep() kept waiting after the run was cancelled")
+	}
+
+	if err := NewVideoGenerator(&config.Config{}).sleep(time.Millisecond); err != nil {
+		t.Errorf("sleep() without a context = %v", err)
+	}
+}
+
+// cancellableAvatar records the remote tasks the pipeline cancels
+type cancellableAvatar struct{ cancelled []string }
+
+func (p *cancellableAvatar) Name() string                                 { return "cancellable" }
+func (p *cancellableAvatar) GenerateAvatar(AvatarRequest) (string, error) { return "", nil }
+func (p *cancellableAvatar) CancelTask(ctx context.Context, taskID string) error {
+	p.cancelled = append(p.cancelled, taskID)
+	return nil
+}
+
+// newCancelPipeline returns a pipeline whose run is cancelled by the returned function
+func newCancelPipeline() (*Pipeline, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tracker := &taskTracker{}
+	vg := NewVideoGenerator(&config.Config{}).WithContext(ctx).WithProgress(tracker)
+	return &Pipeline{generator: vg, tasks: tracker}, cancel
+}
+
+func TestStepCancelsRemoteTask(t *testing.T) {
+	p, cancel := newCancelPipeline()
+	provider := &cancellableAvatar{}
+
+	_, err := p.step(provider, "", "", func() (string, error) {
+		p.generator.reportTask(provider.Name(), "talk-1")
+		cancel() // The user cancels while the vendor is rendering
+		return "", errors.New("request aborted")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("step() error = %v, want context.Canceled", err)
+	}
+	if len(provider.cancelled) != 1 || provider.cancelled[0] != "talk-1" {
+		t.Errorf("cancelled tasks = %v, want [talk-1]", provider.cancelled)
+	}
+}
+
+func TestStepKeepsFailuresOfLiveRuns(t *testing.T) {
+	p, cancel := newCancelPipeline()
+	defer cancel()
+	provider := &cancellableAvatar{}
+
+	_, err := p.step(provider, "", "", func() (string, error) {
+		p.generator.reportTask(provider.Name(), "talk-1")
+		return "", errors.New("vendor rejected the image")
+	})
+	if err == nil || err.Error() != "vendor rejected the image" {
+		t.Errorf("step() error = %v, want the provider's error", err)
+	}
+	if len(provider.cancelled) != 0 {
+		t.Errorf("a failed step of a live run cancelled %v", provider.cancelled)
+	}
+}
+
+func TestStepWithoutCancellableProvider(t *testing.T) {
+	p, cancel := newCancelPipeline()
+
+	_, err := p.step(fakeAvatar{}, "", "", func() (string, error) {
+		p.generator.reportTask("test_avatar", "job-9")
+		cancel()
+		return "", errors.New("request aborted")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("step() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/subtitles"
+	"github.com/google/uuid"
+)
+
+// Caption modes
+const (
+	CaptionsBurned  = "burned"  // Burned into the composite and saved as .srt and .vtt files (the default)
+	CaptionsSidecar = "sidecar" // Saved as .srt and .vtt files only
+	CaptionsOff     = "off"
+)
+
+// ValidateCaptions checks a caption mode; empty means burned
+func ValidateCaptions(mode string) error {
+	switch mode {
+	case "", CaptionsBurned, CaptionsSidecar, CaptionsOff:
+		return nil
+	}
+	return fmt.Errorf("unknown captions mode %q (expected %s, %s or %s)", mode, CaptionsBurned, CaptionsSidecar, CaptionsOff)
+}
+
+// captions times the script against the presenter's clip, which is as long as its
+// speech (D-ID renders the clip from the generated audio). When the clip's length
+// cannot be read it is estimated from the script. In a composite the speech starts
+// at the presenter's start delay, and captions stop when the video does.
+func (p *Pipeline) captions(req VideoRequest, avatarVideoPath string, composite bool) Captions {
+	if req.Captions == CaptionsOff || strings.TrimSpace(req.Script) == "" {
+		return Captions{}
+	}
+
+	spoken, err := p.generator.getVideoDuration(avatarVideoPath)
+	if err != nil || spoken <= 0 {
+		spoken = subtitles.EstimateDuration(req.Script)
+		fmt.Printf("⚠️  Could not read the presenter's speech length, estimating %.1fs from the script\n", spoken)
+	}
+
+	var cues []subtitles.Cue
+	if composite {
+		cues = subtitles.Trim(subtitles.Build(req.Script, req.Timing.AvatarStart, spoken), req.Timing.length())
+	} else {
+		cues = subtitles.Build(req.Script, 0, spoken)
+	}
+	if len(cues) == 0 {
+		return Captions{}
+	}
+
+	stage := StageAvatar
+	if composite {
+		stage = StageComposite
+	}
+	srtPath, err := p.storeSubtitles(stage, cues, avatarVideoPath)
+	if err != nil {
+		// Captions are an extra; the video is still worth finishing without them
+		fmt.Printf("⚠️  Failed to save subtitles: %v\n", err)
+		return Captions{}
+	}
+	fmt.Printf("💬 %d captions over %.1fs of speech\n", len(cues), spoken)
+	return Captions{Cues: cues, SRTPath: srtPath}
+}
+
+// storeSubtitles saves the cues as .srt and .vtt files next to the videos, reports
+// both as assets made from the presenter's clip, and returns the .srt file's path
+func (p *Pipeline) storeSubtitles(stage string, cues []subtitles.Cue, avatarVideoPath string) (string, error) {
+	vg := p.generator
+	os.MkdirAll(vg.config.GeneratedVideoPath, 0755)
+	base := filepath.Join(vg.config.GeneratedVideoPath, "captions-"+uuid.New().String())
+
+	var parents []string
+	if key, err := vg.storageKey(avatarVideoPath); err == nil {
+		parents = []string{key}
+	}
+
+	files := []struct{ ext, content string }{
+		{".srt", subtitles.SRT(cues)},
+		{".vtt", subtitles.VTT(cues)},
+	}
+	for _, file := range files {
+		path := base + file.ext
+		if err := os.WriteFile(path, []byte(file.content), 0644); err != nil {
+			return "", err
+		}
+		key, err := vg.storeVideo(path)
+		if err != nil {
+			return "", err
+		}
+		vg.reportArtifact(Artifact{Stage: stage, Kind: models.AssetKindSubtitles, Key: key, Parents: parents})
+	}
+	return base + ".srt", nil
+}
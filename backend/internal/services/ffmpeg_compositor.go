@@ -0,0 +1,564 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dealshare/hacathon/backend/internal/layouts"
+	"github.com/google/uuid"
+)
+
+// Output settings shared by every local composite (matches the Shotstack "hd" render).
+// The width and height are for 16:9; other aspect ratios swap or square them.
+const (
+	compositeWidth    = 1280
+	compositeHeight   = 720
+	compositeFPS      = 30
+	compositeDuration = 15.0 // Default length; Timing.Duration overrides it
+)
+
+// Audio mix. Music sits under the voice and is ducked by a sidechain compressor
+// while the presenter speaks; the mix is then normalised to the loudness social
+// platforms play videos at.
+const (
+	musicVolume    = 0.35  // Level of the music bed before ducking
+	musicFadeIn    = 1.0   // Seconds
+	musicFadeOut   = 2.0   // Seconds
+	targetLoudness = -14.0 // Integrated loudness in LUFS (YouTube, Instagram, TikTok)
+	targetPeak     = -1.5  // True peak in dBTP
+	targetRange    = 11.0  // Loudness range in LU
+)
+
+// ffmpegLayer is one layer of a local composite, positioned like a Shotstack clip.
+// Offsets are fractions of the canvas measured from the anchor position;
+// positive x moves right and positive y moves down.
+type ffmpegLayer struct {
+	source     string // "product", "avatar" or "border"
+	fit        string // "cover" or "contain"
+	position   string // "center", "left", "right", "top", "bottom" or a corner such as "topLeft"
+	offsetX    float64
+	offsetY    float64
+	scale      float64 // Fraction of the canvas the layer's box occupies
+	opacity    float64
+	transition string  // "fade", "zoom", "slideLeft", "slideRight" or ""
+	effect     string  // "zoomIn" or ""
+	color      string  // Border colour for "border" layers, as 0xRRGGBB
+	start, end float64 // When the layer is on screen
+	canvas     canvas
+}
+
+// canvas is the frame size of a composite
+type canvas struct{ width, height int }
+
+// canvasFor returns the frame size of an aspect ratio, the same as Shotstack's "hd" output
+func canvasFor(ratio string) canvas {
+	switch ratio {
+	case layouts.Portrait:
+		return canvas{compositeHeight, compositeWidth}
+	case layouts.Square:
+		return canvas{compositeHeight, compositeHeight}
+	}
+	return canvas{compositeWidth, compositeHeight}
+}
+
+// ffmpegLayers flattens a layout into layers listed bottom to top. HTML clips
+// need a browser to render, so they are left out of local composites.
+func ffmpegLayers(layout *layouts.Layout, timing Timing, frame canvas) []ffmpegLayer {
+	var layers []ffmpegLayer
+	for t := len(layout.Tracks) - 1; t >= 0; t-- {
+		for _, clip := range layout.Tracks[t].Clips {
+			if clip.Source == layouts.SourceHTML {
+				fmt.Printf("⚠️  Layout %s: skipping HTML clip, which only Shotstack can render\n", layout.Name)
+				continue
+			}
+			clip = clip.Resolved()
+			start, end, ok := clip.Window(timing.length(), timing.AvatarStart)
+			if !ok {
+				continue
+			}
+			layers = append(layers, ffmpegLayer{
+				source:     clip.Source,
+				fit:        clip.Fit,
+				position:   clip.Position,
+				offsetX:    clip.Offset.X,
+				offsetY:    clip.Offset.Y,
+				scale:      clip.Scale,
+				opacity:    clip.Opacity,
+				transition: clip.Transition.In,
+				effect:     clip.Effect,
+				color:      strings.Replace(clip.Color, "#", "0x", 1),
+				start:      start,
+				end:        end,
+				canvas:     frame,
+			})
+		}
+	}
+	return layers
+}
+
+// compositeWithFFmpeg renders the layout locally, so neither clip leaves the server
+func (vg *VideoGenerator) compositeWithFFmpeg(productVideoPath, avatarVideoPath, layout string, timing Timing, ratio string, captions Captions, musicPath string, brand Branding) (string, error) {
+	fmt.Printf("\n🎨 Compositing videos locally with ffmpeg...\n")
+
+	spec := resolveLayout(layout).For(ratio)
+	frame := canvasFor(ratio)
+	layers := ffmpegLayers(spec, timing, frame)
+	fmt.Printf("📐 Layout: %s at %s (%d layers)\n", spec.Name, ratio, len(layers))
+	duration := timing.length()
+	fmt.Printf("⏱️  Duration: %.1fs, presenter from %.1fs\n", duration, timing.AvatarStart)
+
+	if _, err := os.Stat(productVideoPath); os.IsNotExist(err) {
+		return "", fmt.Errorf("product video file does not exist: %s", productVideoPath)
+	}
+	if _, err := os.Stat(avatarVideoPath); os.IsNotExist(err) {
+		return "", fmt.Errorf("avatar video file does not exist: %s", avatarVideoPath)
+	}
+
+	avatarDuration, err := vg.getVideoDuration(avatarVideoPath)
+	if err != nil {
+		fmt.Printf("⚠️  Could not detect avatar duration: %v, assuming 5 seconds\n", err)
+		avatarDuration = 5.0
+	}
+	if avatarDuration > 0 {
+		fmt.Printf("🔄 Person video (%.2fs) looped %d times to fill %.0fs\n",
+			avatarDuration, int(math.Ceil((duration-timing.AvatarStart)/avatarDuration)), duration)
+	}
+
+	// Inputs are looped so short clips fill the whole timeline, like the looped Shotstack clips.
+	// The music and the logo, if any, follow the two clips.
+	inputs := map[string]int{"product": 0, "avatar": 1}
+	next := 2
+	for _, extra := range []struct{ name, path string }{{"music", musicPath}, {"logo", brand.Logo}} {
+		if extra.path != "" {
+			inputs[extra.name] = next
+			next++
+		}
+	}
+	sizes := map[string][2]int{
+		"product": vg.videoDimensions(productVideoPath),
+		"avatar":  vg.videoDimensions(avatarVideoPath),
+	}
+
+	var graph []string
+	graph = append(graph, fmt.Sprintf("color=c=black:s=%dx%d:r=%d:d=%s[base]",
+		frame.width, frame.height, compositeFPS, formatSeconds(duration)))
+
+	current := "base"
+	for i, layer := range layers {
+		label := fmt.Sprintf("l%d", i)
+		var chain string
+		var w, h int
+		start := layer.start
+
+		if layer.source == "border" {
+			w, h = even(float64(frame.width)*layer.scale), even(float64(frame.height)*layer.scale)
+			chain = fmt.Sprintf("color=c=black@0.0:s=%dx%d:r=%d:d=%s,format=rgba,drawbox=x=0:y=0:w=iw:h=ih:color=%s@%.2f:t=8",
+				w, h, compositeFPS, formatSeconds(duration), layer.color, layer.opacity)
+		} else {
+			w, h = layer.size(sizes[layer.source])
+			chain = fmt.Sprintf("[%d:v]fps=%d,%s,setsar=1", inputs[layer.source], compositeFPS, layer.fitFilter(w, h))
+			if layer.effect == "zoomIn" {
+				// Slow push-in over the whole clip
+				chain += fmt.Sprintf(",zoompan=z='min(1+0.0007*on,1.3)':x='iw/2-(iw/zoom/2)':y='ih/2-(ih/zoom/2)':d=1:s=%dx%d:fps=%d",
+					w, h, compositeFPS)
+			}
+			chain += ",format=rgba"
+			if layer.opacity < 1.0 {
+				chain += fmt.Sprintf(",colorchannelmixer=aa=%.2f", layer.opacity)
+			}
+			// ffmpeg has no zoom-in transition for overlays, so "zoom" fades in like Shotstack's default
+			if layer.transition == "fade" || layer.transition == "zoom" {
+				chain += fmt.Sprintf(",fade=t=in:st=%s:d=1:alpha=1", formatSeconds(start))
+			}
+		}
+		graph = append(graph, chain+"["+label+"]")
+
+		x, y := layer.place(w, h)
+		next := fmt.Sprintf("v%d", i)
+		graph = append(graph, fmt.Sprintf("[%s][%s]overlay=x='%s':y=%d:eof_action=pass%s[%s]",
+			current, label, layer.slideX(x, start), y, layer.enable(duration), next))
+		current = next
+	}
+
+	if brand.Logo != "" {
+		graph = append(graph, watermarkFilters(inputs["logo"], current, brand.Watermark, frame)...)
+		current = "watermarked"
+	}
+
+	if len(captions.Cues) > 0 {
+		captionDir, err := os.MkdirTemp("", "captions-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create caption directory: %v", err)
+		}
+		defer os.RemoveAll(captionDir)
+
+		filters, err := vg.captionFilters(captionDir, captions.Cues, frame)
+		if err != nil {
+			return "", err
+		}
+		fmt.Printf("💬 Burning in %d captions\n", len(filters))
+		graph = append(graph, fmt.Sprintf("[%s]%s[captioned]", current, strings.Join(filters, ",")))
+		current = "captioned"
+	}
+	graph = append(graph, fmt.Sprintf("[%s]format=yuv420p[out]", current))
+
+	voice, err := vg.hasAudio(avatarVideoPath)
+	if err != nil {
+		fmt.Printf("⚠️  Could not detect the presenter's audio: %v, assuming it speaks\n", err)
+		voice = true
+	}
+	if musicPath != "" {
+		fmt.Printf("🎵 Mixing %s under the voice\n", filepath.Base(musicPath))
+	}
+	audio := audioFilters(voice, musicPath != "", inputs["music"], duration)
+	graph = append(graph, audio...)
+
+	os.MkdirAll(vg.config.GeneratedVideoPath, 0755)
+	outputPath := filepath.Join(vg.config.GeneratedVideoPath, fmt.Sprintf("%s.mp4", uuid.New().String()))
+
+	// The presenter's clip (and voice) is shifted to start at AvatarStart
+	args := []string{
+		"-y",
+		"-stream_loop", "-1", "-i", productVideoPath,
+		"-stream_loop", "-1", "-itsoffset", formatSeconds(timing.AvatarStart), "-i", avatarVideoPath,
+	}
+	if musicPath != "" {
+		args = append(args, "-stream_loop", "-1", "-i", musicPath)
+	}
+	if brand.Logo != "" {
+		args = append(args, "-loop", "1", "-i", brand.Logo)
+	}
+	args = append(args,
+		"-filter_complex", strings.Join(graph, ";"),
+		"-map", "[out]",
+	)
+	if len(audio) > 0 {
+		// The presenter's voice and the music; product clips are silent
+		args = append(args, "-map", "[aout]")
+	}
+	args = append(args,
+		"-t", formatSeconds(duration),
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "23",
+		"-c:a", "aac", "-b:a", "128k", "-ar", "48000",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+
+	fmt.Printf("🎬 Running ffmpeg (%dx%d, %d fps, %.0fs)...\n", frame.width, frame.height, compositeFPS, duration)
+	if err := vg.runFFmpeg(args, outputPath); err != nil {
+		return "", fmt.Errorf("ffmpeg compositing failed: %v", err)
+	}
+	if brand.bumpers() {
+		if err := vg.addBumpers(outputPath, brand, frame, len(audio) > 0); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := vg.storeVideo(outputPath); err != nil {
+		return "", err
+	}
+	fmt.Printf("✅ Local composite saved at %s\n", outputPath)
+	return outputPath, nil
+}
+
+// audioFilters mixes the composite's sound into [aout]: the presenter's voice
+// (input 1), the music (musicInput) faded in and out and ducked under the voice,
+// or both. The result is normalised to targetLoudness. Without either there is
+// no audio and no filters.
+func audioFilters(voice, music bool, musicInput int, duration float64) []string {
+	// The voice starts at the presenter's delay; async resampling pads the gap with silence
+	voiceIn := "[1:a]aresample=48000:async=1:first_pts=0,aformat=channel_layouts=stereo"
+	bed := fmt.Sprintf("[%d:a]aresample=48000,aformat=channel_layouts=stereo,volume=%g,afade=t=in:st=0:d=%g,afade=t=out:st=%s:d=%g",
+		musicInput, musicVolume, musicFadeIn, formatSeconds(math.Max(duration-musicFadeOut, 0)), musicFadeOut)
+	loudnorm := loudnormFilter()
+
+	switch {
+	case voice && music:
+		return []string{
+			voiceIn + ",asplit=2[voice][speech]",
+			bed + "[bed]",
+			// Pull the music down while speech is present, and back up in the pauses
+			"[bed][speech]sidechaincompress=threshold=0.02:ratio=8:attack=20:release=400[ducked]",
+			"[voice][ducked]amix=inputs=2:duration=longest:dropout_transition=0:normalize=0," + loudnorm + "[aout]",
+		}
+	case music:
+		return []string{bed + "," + loudnorm + "[aout]"}
+	case voice:
+		return []string{voiceIn + "," + loudnorm + "[aout]"}
+	}
+	return nil
+}
+
+// watermarkFilters overlays the logo (input logo) in a corner of the current
+// video, leaving the result in [watermarked]
+func watermarkFilters(logo int, current, position string, frame canvas) []string {
+	short := float64(min(frame.width, frame.height))
+	margin := int(short * watermarkMargin)
+	x, y := strconv.Itoa(margin), strconv.Itoa(margin)
+	if strings.HasSuffix(position, "Right") {
+		x = fmt.Sprintf("main_w-overlay_w-%d", margin)
+	}
+	if strings.HasPrefix(position, "bottom") {
+		y = fmt.Sprintf("main_h-overlay_h-%d", margin)
+	}
+	return []string{
+		fmt.Sprintf("[%d:v]scale=%d:-2,format=rgba,colorchannelmixer=aa=%.2f[logo]", logo, even(short*watermarkScale), watermarkOpacity),
+		fmt.Sprintf("[%s][logo]overlay=x=%s:y=%s:shortest=1[watermarked]", current, x, y),
+	}
+}
+
+// addBumpers puts the brand's intro before the composite at videoPath and its
+// outro, or else an end card with its logo, after it, replacing the file. Bumpers
+// are fitted to the frame on the brand's colour; silent ones get silence.
+func (vg *VideoGenerator) addBumpers(videoPath string, brand Branding, frame canvas, voiced bool) error {
+	fmt.Printf("🏷️  Adding brand bumpers\n")
+	var args, graph []string
+	var segments strings.Builder
+	input, count := 0, 0
+
+	fit := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2:color=%s,fps=%d,setsar=1,format=yuv420p",
+		frame.width, frame.height, frame.width, frame.height, strings.Replace(brand.Color, "#", "0x", 1), compositeFPS)
+	silence := "anullsrc=r=48000:cl=stereo,atrim=duration=0.1" // concat pads it to the segment's length
+	addVideo := func(path string, hasAudio bool) {
+		args = append(args, "-i", path)
+		graph = append(graph, fmt.Sprintf("[%d:v]%s[v%d]", input, fit, input))
+		if hasAudio {
+			graph = append(graph, fmt.Sprintf("[%d:a]aresample=48000,aformat=channel_layouts=stereo[a%d]", input, input))
+		} else {
+			graph = append(graph, fmt.Sprintf("%s[a%d]", silence, input))
+		}
+		fmt.Fprintf(&segments, "[v%d][a%d]", input, input)
+		input++
+		count++
+	}
+	bumperAudio := func(path string) bool {
+		voiced, err := vg.hasAudio(path)
+		if err != nil {
+			fmt.Printf("⚠️  Could not detect audio in %s: %v, treating it as silent\n", filepath.Base(path), err)
+		}
+		return voiced
+	}
+
+	if brand.Intro != "" {
+		addVideo(brand.Intro, bumperAudio(brand.Intro))
+	}
+	addVideo(videoPath, voiced)
+	if brand.Outro != "" {
+		addVideo(brand.Outro, bumperAudio(brand.Outro))
+	} else if brand.endCard() {
+		// The logo, fading in on the brand's colour
+		args = append(args,
+			"-f", "lavfi", "-i", fmt.Sprintf("color=c=%s:s=%dx%d:r=%d:d=%s",
+				strings.Replace(brand.Color, "#", "0x", 1), frame.width, frame.height, compositeFPS, formatSeconds(endCardSeconds)),
+			"-loop", "1", "-t", formatSeconds(endCardSeconds), "-i", brand.Logo,
+		)
+		card, logo := input, input+1
+		graph = append(graph,
+			fmt.Sprintf("[%d:v]scale=%d:-2[cardlogo]", logo, even(float64(min(frame.width, frame.height))*endCardLogoScale)),
+			fmt.Sprintf("[%d:v][cardlogo]overlay=x=(main_w-overlay_w)/2:y=(main_h-overlay_h)/2:shortest=1,fade=t=in:st=0:d=0.5,setsar=1,format=yuv420p[v%d]", card, card),
+			fmt.Sprintf("%s[a%d]", silence, card),
+		)
+		fmt.Fprintf(&segments, "[v%d][a%d]", card, card)
+		count++
+	}
+
+	graph = append(graph,
+		fmt.Sprintf("%sconcat=n=%d:v=1:a=1[outv][outa]", segments.String(), count),
+		"[outa]"+loudnormFilter()+"[aout]",
+	)
+
+	branded := strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + "-branded" + filepath.Ext(videoPath)
+	args = append([]string{"-y"}, args...)
+	args = append(args,
+		"-filter_complex", strings.Join(graph, ";"),
+		"-map", "[outv]", "-map", "[aout]",
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "23",
+		"-c:a", "aac", "-b:a", "128k", "-ar", "48000",
+		"-movflags", "+faststart",
+		branded,
+	)
+	if err := vg.runFFmpeg(args, branded); err != nil {
+		return fmt.Errorf("adding brand bumpers failed: %v", err)
+	}
+	return os.Rename(branded, videoPath)
+}
+
+// loudnormFilter normalises audio to targetLoudness. loudnorm resamples to
+// 192kHz internally, so it is resampled back to 48kHz.
+func loudnormFilter() string {
+	return fmt.Sprintf("loudnorm=I=%g:TP=%g:LRA=%g,aresample=48000", targetLoudness, targetPeak, targetRange)
+}
+
+// hasAudio reports whether a media file has an audio stream
+func (vg *VideoGenerator) hasAudio(path string) (bool, error) {
+	cmd := exec.CommandContext(vg.context(), "ffprobe",
+		"-v", "error",
+		"-select_streams", "a",
+		"-show_entries", "stream=index",
+		"-of", "csv=p=0",
+		path,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("ffprobe failed: %v", err)
+	}
+	return strings.TrimSpace(string(output)) != "", nil
+}
+
+// normalizeLoudness brings a finished video's audio to targetLoudness in place,
+// copying the video stream, for composites rendered without the local audio mix
+func (vg *VideoGenerator) normalizeLoudness(videoPath string) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		fmt.Printf("⚠️  ffmpeg is not installed, so the audio of %s is not loudness-normalised\n", filepath.Base(videoPath))
+		return nil
+	}
+	if voiced, err := vg.hasAudio(videoPath); err == nil && !voiced {
+		return nil
+	}
+
+	normalized := strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + "-loudnorm" + filepath.Ext(videoPath)
+	args := []string{
+		"-y", "-i", videoPath,
+		"-map", "0:v", "-map", "0:a?",
+		"-c:v", "copy",
+		"-af", loudnormFilter(),
+		"-c:a", "aac", "-b:a", "128k", "-ar", "48000",
+		"-movflags", "+faststart",
+		normalized,
+	}
+	if err := vg.runFFmpeg(args, normalized); err != nil {
+		return fmt.Errorf("loudness normalisation failed: %v", err)
+	}
+	if err := os.Rename(normalized, videoPath); err != nil {
+		return err
+	}
+	fmt.Printf("🔊 Audio normalised to %g LUFS\n", targetLoudness)
+	return nil
+}
+
+// videoDimensions returns the width and height of the first video stream.
+// When ffprobe cannot read them, the canvas aspect ratio is assumed.
+func (vg *VideoGenerator) videoDimensions(videoPath string) [2]int {
+	cmd := exec.CommandContext(vg.context(), "ffprobe",
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=width,height",
+		"-of", "csv=p=0:s=x",
+		videoPath,
+	)
+
+	output, err := cmd.Output()
+	if err == nil {
+		parts := strings.Split(strings.TrimSpace(string(output)), "x")
+		if len(parts) == 2 {
+			w, errW := strconv.Atoi(parts[0])
+			h, errH := strconv.Atoi(parts[1])
+			if errW == nil && errH == nil && w > 0 && h > 0 {
+				return [2]int{w, h}
+			}
+		}
+	}
+
+	fmt.Printf("⚠️  Could not detect dimensions of %s, assuming %dx%d\n", videoPath, compositeWidth, compositeHeight)
+	return [2]int{compositeWidth, compositeHeight}
+}
+
+// size returns the rendered size of the layer for a source of the given dimensions
+func (l ffmpegLayer) size(source [2]int) (int, int) {
+	boxW := float64(l.canvas.width) * l.scale
+	boxH := float64(l.canvas.height) * l.scale
+	if l.fit == "cover" {
+		return even(boxW), even(boxH)
+	}
+
+	ratio := math.Min(boxW/float64(source[0]), boxH/float64(source[1]))
+	return even(float64(source[0]) * ratio), even(float64(source[1]) * ratio)
+}
+
+// fitFilter scales (and for "cover", crops) the source to exactly w x h
+func (l ffmpegLayer) fitFilter(w, h int) string {
+	if l.fit == "cover" {
+		return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d", w, h, w, h)
+	}
+	return fmt.Sprintf("scale=%d:%d", w, h)
+}
+
+// place returns the top-left corner of a w x h layer, kept inside the canvas
+func (l ffmpegLayer) place(w, h int) (int, int) {
+	x := float64(l.canvas.width-w) / 2
+	switch l.position {
+	case "left", "topLeft", "bottomLeft":
+		x = 0
+	case "right", "topRight", "bottomRight":
+		x = float64(l.canvas.width - w)
+	}
+	y := float64(l.canvas.height-h) / 2
+	switch l.position {
+	case "top", "topLeft", "topRight":
+		y = 0
+	case "bottom", "bottomLeft", "bottomRight":
+		y = float64(l.canvas.height - h)
+	}
+	x += l.offsetX * float64(l.canvas.width)
+	y += l.offsetY * float64(l.canvas.height)
+
+	return clamp(int(math.Round(x)), 0, l.canvas.width-w), clamp(int(math.Round(y)), 0, l.canvas.height-h)
+}
+
+// enable limits the overlay to the layer's window when it does not span the whole video
+func (l ffmpegLayer) enable(duration float64) string {
+	if l.start <= 0 && l.end >= duration {
+		return ""
+	}
+	return fmt.Sprintf(":enable='between(t,%s,%s)'", formatSeconds(l.start), formatSeconds(l.end))
+}
+
+// slideX returns the overlay x expression, animating the first half second after the
+// layer comes in at start. As in Shotstack, slideLeft moves the layer leftwards into
+// place and slideRight rightwards.
+func (l ffmpegLayer) slideX(x int, start float64) string {
+	switch l.transition {
+	case "slideLeft":
+		return fmt.Sprintf("%d+%d*max(0,1-(t-%s)/0.5)", x, l.canvas.width, formatSeconds(start))
+	case "slideRight":
+		return fmt.Sprintf("%d-%d*max(0,1-(t-%s)/0.5)", x, l.canvas.width, formatSeconds(start))
+	}
+	return strconv.Itoa(x)
+}
+
+// even rounds down to an even pixel count, as required by yuv420p
+func even(v float64) int {
+	n := int(v)
+	return n - n%2
+}
+
+func clamp(v, lo, hi int) int {
+	if hi < lo {
+		return lo
+	}
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+func formatSeconds(s float64) string {
+	return strconv.FormatFloat(s, 'f', -1, 64)
+}
+
+// tail returns the last n lines of s, used to keep ffmpeg errors readable
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dealshare/hacathon/backend/internal/layouts"
+)
+
+var landscape = canvas{1280, 720}
+
+func TestLayerSize(t *testing.T) {
+	tests := []struct {
+		name         string
+		layer        ffmpegLayer
+		source       [2]int
+		wantW, wantH int
+	}{
+		{"contain fills the width", ffmpegLayer{fit: "contain", scale: 1, canvas: landscape}, [2]int{1920, 1080}, 1280, 720},
+		{"contain keeps a portrait source's shape", ffmpegLayer{fit: "contain", scale: 1, canvas: landscape}, [2]int{1080, 1920}, 404, 720},
+		{"contain scaled down", ffmpegLayer{fit: "contain", scale: 0.5, canvas: landscape}, [2]int{1080, 1080}, 360, 360},
+		{"cover fills the box", ffmpegLayer{fit: "cover", scale: 0.5, canvas: landscape}, [2]int{1080, 1920}, 640, 360},
+		{"sizes are even", ffmpegLayer{fit: "cover", scale: 0.33, canvas: landscape}, [2]int{1920, 1080}, 422, 236},
+	}
+	for _, tt := range tests {
+		w, h := tt.layer.size(tt.source)
+		if w != tt.wantW || h != tt.wantH {
+			t.Errorf("%s: size() = %dx%d, want %dx%d", tt.name, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestLayerPlace(t *testing.T) {
+	tests := []struct {
+		position     string
+		offsetX      float64
+		offsetY      float64
+		wantX, wantY int
+	}{
+		{"center", 0, 0, 480, 260},
+		{"left", 0, 0, 0, 260},
+		{"right", 0, 0, 960, 260},
+		{"top", 0, 0, 480, 0},
+		{"bottom", 0, 0, 480, 520},
+		{"topLeft", 0, 0, 0, 0},
+		{"topRight", 0, 0, 960, 0},
+		{"bottomLeft", 0, 0, 0, 520},
+		{"bottomRight", 0, 0, 960, 520},
+		{"bottomRight", -0.05, -0.05, 896, 484}, // Inset from the corner
+		{"center", 0.25, 0, 800, 260},
+		{"left", -0.5, 0, 0, 260},       // Kept on the canvas
+		{"bottom", 0, 0.5, 480, 520},    // Kept on the canvas
+		{"topRight", 0.5, -0.5, 960, 0}, // Kept on the canvas
+	}
+	for _, tt := range tests {
+		layer := ffmpegLayer{position: tt.position, offsetX: tt.offsetX, offsetY: tt.offsetY, canvas: landscape}
+		x, y := layer.place(320, 200)
+		if x != tt.wantX || y != tt.wantY {
+			t.Errorf("place(%s, offset %v,%v) = %d,%d, want %d,%d", tt.position, tt.offsetX, tt.offsetY, x, y, tt.wantX, tt.wantY)
+		}
+	}
+
+	oversized := ffmpegLayer{position: "right", canvas: landscape}
+	if x, y := oversized.place(1400, 800); x != 0 || y != 0 {
+		t.Errorf("place() of a layer larger than the canvas = %d,%d, want 0,0", x, y)
+	}
+}
+
+func TestLayerAnimation(t *testing.T) {
+	tests := []struct {
+		name   string
+		layer  ffmpegLayer
+		wantX  string
+		enable string
+	}{
+		{"static, whole video", ffmpegLayer{canvas: landscape, start: 0, end: 15}, "100", ""},
+		{"slides in from the right", ffmpegLayer{transition: "slideLeft", canvas: landscape, start: 2, end: 15}, "100+1280*max(0,1-(t-2)/0.5)", ":enable='between(t,2,15)'"},
+		{"slides in from the left", ffmpegLayer{transition: "slideRight", canvas: landscape, start: 0, end: 4.5}, "100-1280*max(0,1-(t-0)/0.5)", ":enable='between(t,0,4.5)'"},
+	}
+	for _, tt := range tests {
+		if got := tt.layer.slideX(100, tt.layer.start); got != tt.wantX {
+			t.Errorf("%s: slideX() = %q, want %q", tt.name, got, tt.wantX)
+		}
+		if got := tt.layer.enable(15); got != tt.enable {
+			t.Errorf("%s: enable() = %q, want %q", tt.name, got, tt.enable)
+		}
+	}
+}
+
+func TestFFmpegLayers(t *testing.T) {
+	layout := &layouts.Layout{Name: "custom", Tracks: []layouts.Track{
+		{Clips: []layouts.Clip{{Source: layouts.SourceHTML, HTML: "<h1>Sale</h1>"}}},
+		{Clips: []layouts.Clip{{Source: layouts.SourceBorder, Color: "#FFD700", Scale: 0.5}}},
+		{Clips: []layouts.Clip{{Source: layouts.SourceAvatar, Start: 1, Length: 4}}},
+		{Clips: []layouts.Clip{{Source: layouts.SourceProduct, Fit: "cover"}}},
+	}}
+	layers := ffmpegLayers(layout, Timing{Duration: 10, AvatarStart: 2}, landscape)
+
+	tests := []struct {
+		source, fit, color string
+		start, end         float64
+	}{
+		{"product", "cover", "", 0, 10},
+		{"avatar", "contain", "", 3, 7},
+		{"border", "", "0xFFD700", 0, 10},
+	}
+	if len(layers) != len(tests) {
+		t.Fatalf("ffmpegLayers() returned %d layers, want %d (HTML is skipped)", len(layers), len(tests))
+	}
+	for i, tt := range tests {
+		l := layers[i]
+		if l.source != tt.source || l.fit != tt.fit || l.color != tt.color || l.start != tt.start || l.end != tt.end {
+			t.Errorf("layer %d = %+v, want %+v", i, l, tt)
+		}
+	}
+}
+
+func TestCanvasFor(t *testing.T) {
+	tests := []struct {
+		ratio string
+		want  canvas
+	}{
+		{layouts.Landscape, canvas{1280, 720}},
+		{layouts.Portrait, canvas{720, 1280}},
+		{layouts.Square, canvas{720, 720}},
+		{"", canvas{1280, 720}},
+	}
+	for _, tt := range tests {
+		if got := canvasFor(tt.ratio); got != tt.want {
+			t.Errorf("canvasFor(%q) = %v, want %v", tt.ratio, got, tt.want)
+		}
+	}
+}
+
+func TestAspectRatios(t *testing.T) {
+	tests := []struct {
+		ratios  []string
+		want    []string
+		wantErr bool
+	}{
+		{nil, []string{layouts.Landscape}, false},
+		{[]string{layouts.Portrait}, []string{layouts.Portrait}, false},
+		{[]string{layouts.Square, layouts.Portrait, layouts.Square}, []string{layouts.Square, layouts.Portrait}, false},
+		{[]string{layouts.Portrait, "4:3"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := AspectRatios(tt.ratios)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("AspectRatios(%v) = %v, %v, want %v, wantErr %v", tt.ratios, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPortraitLayers(t *testing.T) {
+	split, _ := layouts.Get("split")
+	frame := canvasFor(layouts.Portrait)
+	layers := ffmpegLayers(split.For(layouts.Portrait), Timing{Duration: 10}, frame)
+
+	tests := []struct {
+		source       string
+		wantX, wantY int
+	}{
+		{"product", 180, 640},
+		{"avatar", 180, 0},
+	}
+	for i, tt := range tests {
+		w, h := layers[i].size([2]int{1080, 1920})
+		x, y := layers[i].place(w, h)
+		if layers[i].source != tt.source || w != 360 || h != 640 || x != tt.wantX || y != tt.wantY {
+			t.Errorf("layer %d: %s %dx%d at %d,%d, want %s 360x640 at %d,%d",
+				i, layers[i].source, w, h, x, y, tt.source, tt.wantX, tt.wantY)
+		}
+	}
+}
@@ -26,6 +26,11 @@ func NewGeminiService(apiKey string) *GeminiService {
 	}
 }
 
+// Name identifies the service as a ScriptWriter provider
+func (g *GeminiService) Name() string {
+	return "gemini"
+}
+
 // GenerateMarketingScript generates a 15-second marketing script using Gemini Pro
 func (g *GeminiService) GenerateMarketingScript(productName, productDescription, productCategory, productPrice string) (string, error) {
 	fmt.Printf("\n🤖 Generating script with Google Gemini Pro...\n")
@@ -186,7 +191,7 @@ func (g *GeminiService) callGeminiAPI(prompt string) (string, error) {
 			if message, ok := errorData["message"].(string); ok {
 				errorMsg = fmt.Sprintf("Gemini API error: %s", message)
 			}
-			return "", fmt.Errorf(errorMsg)
+			return "", fmt.Errorf("%s", errorMsg)
 		}
 		return "", fmt.Errorf("no candidates in response. Full response: %s", string(bodyBytes))
 	}
@@ -253,6 +258,87 @@ Return ONLY the script:`, productName, productDescription)
 	return g.callGeminiAPI(prompt)
 }
 
+// GenerateScriptVariants writes several distinct scripts for the product in one
+// call, in the tone, length, language and for the audience opts ask for
+func (g *GeminiService) GenerateScriptVariants(productName, productDescription, productCategory, productPrice string, opts ScriptOptions) ([]string, error) {
+	language, _ := scriptLanguage(opts.Language)
+	fmt.Printf("\n🤖 Generating %d %s %ds %s scripts with Google Gemini...\n", opts.Variants, opts.Tone, opts.Duration, language.Name)
+
+	prompt := fmt.Sprintf(`You are an expert marketing copywriter specializing in short-form video scripts for Instagram Reels and TikTok.
+
+Write %d different %d-second marketing scripts for a presenter to read aloud about this product:
+
+`, opts.Variants, opts.Duration)
+	if productName != "" {
+		prompt += fmt.Sprintf("Product Name: %s\n", productName)
+	}
+	if productDescription != "" {
+		prompt += fmt.Sprintf("Description: %s\n", productDescription)
+	}
+	if productCategory != "" {
+		prompt += fmt.Sprintf("Category: %s\n", productCategory)
+	}
+	if productPrice != "" {
+		prompt += fmt.Sprintf("Price: %s\n", productPrice)
+	}
+	if opts.Audience != "" {
+		prompt += fmt.Sprintf("Target audience: %s\n", opts.Audience)
+	}
+
+	prompt += fmt.Sprintf(`
+REQUIREMENTS:
+1. Each script takes about %d seconds to read aloud (roughly %d words in English)
+2. Write in %s
+3. Tone: %s
+4. Start with an attention-grabbing hook and end with a call-to-action
+5. Mention the product name; include the price if provided
+6. Make the scripts clearly different from each other: different hooks, angles and calls-to-action
+7. Plain spoken text only: no quotation marks, emojis, hashtags, stage directions or speaker labels
+
+Return ONLY valid JSON, no commentary:
+{"scripts": ["first script", "second script"]}`, opts.Duration, opts.targetWords(), language.Name, scriptToneGuides[opts.Tone])
+
+	response, err := g.callGeminiAPI(prompt)
+	if err != nil {
+		return nil, fmt.Errorf("Gemini API call failed: %v", err)
+	}
+
+	var result struct {
+		Scripts []string `json:"scripts"`
+	}
+	if err := json.Unmarshal([]byte(trimCodeFence(response)), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse scripts JSON: %v", err)
+	}
+
+	var scripts []string
+	for _, script := range result.Scripts {
+		script = strings.Trim(strings.TrimSpace(script), "\"")
+		if script != "" && len(scripts) < opts.Variants {
+			scripts = append(scripts, script)
+		}
+	}
+	if len(scripts) == 0 {
+		return nil, fmt.Errorf("Gemini returned no scripts")
+	}
+
+	fmt.Printf("✅ %d scripts generated\n", len(scripts))
+	for i, script := range scripts {
+		fmt.Printf("   %d. %s\n", i+1, script)
+	}
+	return scripts, nil
+}
+
+// trimCodeFence removes the markdown code block Gemini sometimes wraps JSON in
+func trimCodeFence(response string) string {
+	response = strings.TrimSpace(response)
+	if strings.HasPrefix(response, "```") {
+		response = strings.TrimPrefix(response, "```json")
+		response = strings.TrimPrefix(response, "```")
+		response = strings.TrimSuffix(response, "```")
+	}
+	return strings.TrimSpace(response)
+}
+
 // GenerateInstagramCaption generates an Instagram caption using Gemini
 func (g *GeminiService) GenerateInstagramCaption(productName, productDescription, productPrice string) (string, error) {
 	prompt := fmt.Sprintf(`Create an engaging Instagram Reels caption for:
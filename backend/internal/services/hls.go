@@ -0,0 +1,193 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/storage"
+)
+
+// HLS output. Segments start on keyframes forced every hlsSegmentSeconds, so
+// every rendition cuts at the same times and players can switch between them.
+const (
+	hlsSegmentSeconds = 4
+	hlsMasterPlaylist = "master.m3u8"
+	hlsMinShortSide   = 240 // Renditions are never made smaller than this
+)
+
+// hlsBitrates are the video bitrates (kbps) of common rendition sizes by the
+// frame's shorter side; other sizes are scaled from the closest one below
+var hlsBitrates = map[int]int{240: 400, 360: 800, 480: 1400, 720: 2800, 1080: 5000, 1440: 9000, 2160: 16000}
+
+// hlsRendition is one rung of the ladder
+type hlsRendition struct {
+	name          string // e.g. "720p"
+	width, height int
+	videoKbps     int
+	audioKbps     int
+}
+
+// stream transcodes a final render into an HLS ladder stored next to it, and
+// reports its master playlist as a stream asset made from the render. The MP4
+// stays the fallback, so failures are only logged.
+func (p *Pipeline) stream(videoPath, ratio string) {
+	vg := p.generator
+	if len(vg.config.HLSRenditions) == 0 {
+		return
+	}
+	renderKey, err := vg.storageKey(videoPath)
+	if err != nil {
+		fmt.Printf("⚠️  Skipping HLS: %v\n", err)
+		return
+	}
+
+	masterKey, err := vg.transcodeHLS(videoPath)
+	if err != nil {
+		fmt.Printf("⚠️  HLS transcoding failed, the MP4 is served alone: %v\n", err)
+		return
+	}
+	vg.reportArtifact(Artifact{Stage: StageComposite, Kind: models.AssetKindStream, Key: masterKey, Parents: []string{renderKey}, AspectRatio: ratio})
+}
+
+// transcodeHLS renders the video's HLS ladder into a directory named after it
+// (master.m3u8, then <rendition>.m3u8 and <rendition>_NNN.ts for each rung),
+// stores every file, and returns the master playlist's key
+func (vg *VideoGenerator) transcodeHLS(videoPath string) (string, error) {
+	frame := vg.videoDimensions(videoPath)
+	renditions := hlsLadder(vg.config.HLSRenditions, frame[0], frame[1])
+	voiced, err := vg.hasAudio(videoPath)
+	if err != nil {
+		fmt.Printf("⚠️  Could not detect audio in %s: %v, assuming it has some\n", filepath.Base(videoPath), err)
+		voiced = true
+	}
+
+	name := "hls-" + strings.TrimSuffix(filepath.Base(videoPath), filepath.Ext(videoPath))
+	dir := filepath.Join(vg.config.GeneratedVideoPath, name)
+	os.RemoveAll(dir) // Left over from an interrupted run
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	names := make([]string, len(renditions))
+	for i, r := range renditions {
+		names[i] = r.name
+	}
+	fmt.Printf("📺 Transcoding HLS ladder: %s\n", strings.Join(names, ", "))
+
+	split := fmt.Sprintf("[0:v]split=%d", len(renditions))
+	var scales, streams []string
+	for i, r := range renditions {
+		split += fmt.Sprintf("[s%d]", i)
+		scales = append(scales, fmt.Sprintf("[s%d]scale=%d:%d,setsar=1[v%d]", i, r.width, r.height, i))
+	}
+	args := []string{"-y", "-i", videoPath, "-filter_complex", split + ";" + strings.Join(scales, ";")}
+	for i, r := range renditions {
+		n := fmt.Sprint(i)
+		args = append(args,
+			"-map", "[v"+n+"]",
+			"-c:v:"+n, "libx264",
+			"-b:v:"+n, fmt.Sprintf("%dk", r.videoKbps),
+			"-maxrate:v:"+n, fmt.Sprintf("%dk", r.videoKbps*107/100),
+			"-bufsize:v:"+n, fmt.Sprintf("%dk", r.videoKbps*3/2),
+		)
+		stream := fmt.Sprintf("v:%d,name:%s", i, r.name)
+		if voiced {
+			args = append(args, "-map", "0:a:0", "-c:a:"+n, "aac", "-b:a:"+n, fmt.Sprintf("%dk", r.audioKbps))
+			stream = fmt.Sprintf("v:%d,a:%d,name:%s", i, i, r.name)
+		}
+		streams = append(streams, stream)
+	}
+	args = append(args,
+		"-preset", "veryfast",
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", hlsSegmentSeconds),
+		"-sc_threshold", "0",
+		"-ar", "48000",
+		"-f", "hls",
+		"-hls_time", fmt.Sprint(hlsSegmentSeconds),
+		"-hls_playlist_type", "vod",
+		"-hls_flags", "independent_segments",
+		"-hls_segment_filename", filepath.Join(dir, "%v_%03d.ts"),
+		"-master_pl_name", hlsMasterPlaylist, // Written next to the rendition playlists
+		"-var_stream_map", strings.Join(streams, " "),
+		filepath.Join(dir, "%v.m3u8"),
+	)
+	if err := vg.runFFmpeg(args, filepath.Join(dir, hlsMasterPlaylist)); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	// The master playlist goes last, so a stored one always has its renditions
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && entry.Name() != hlsMasterPlaylist {
+			files = append(files, entry.Name())
+		}
+	}
+	files = append(files, hlsMasterPlaylist)
+
+	var masterKey string
+	for _, file := range files {
+		key := storage.VideoKey(name, file)
+		if err := storage.PutFile(vg.context(), vg.storage, key, filepath.Join(dir, file)); err != nil {
+			return "", fmt.Errorf("failed to store %s: %v", file, err)
+		}
+		masterKey = key
+	}
+	fmt.Printf("✅ HLS ladder stored: %s\n", masterKey)
+	return masterKey, nil
+}
+
+// hlsLadder sizes the configured renditions for a width x height video, largest
+// first. Renditions larger than the video are dropped; if that drops them all,
+// the video's own size is the only one.
+func hlsLadder(sizes []int, width, height int) []hlsRendition {
+	short, long := min(width, height), max(width, height)
+	var kept []int
+	seen := map[int]bool{}
+	for _, size := range sizes {
+		if size <= short && size >= hlsMinShortSide && !seen[size] {
+			seen[size] = true
+			kept = append(kept, size)
+		}
+	}
+	if len(kept) == 0 {
+		kept = []int{short}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(kept)))
+
+	ladder := make([]hlsRendition, len(kept))
+	for i, size := range kept {
+		scaledLong := even(float64(long) * float64(size) / float64(short))
+		r := hlsRendition{name: fmt.Sprintf("%dp", size), videoKbps: hlsBitrate(size), audioKbps: 128}
+		if size < 720 {
+			r.audioKbps = 96
+		}
+		r.width, r.height = scaledLong, even(float64(size))
+		if height > width {
+			r.width, r.height = even(float64(size)), scaledLong
+		}
+		ladder[i] = r
+	}
+	return ladder
+}
+
+// hlsBitrate picks a video bitrate for a rendition size, scaling the closest
+// known size below it by area
+func hlsBitrate(size int) int {
+	base := 240
+	for known := range hlsBitrates {
+		if known <= size && known > base {
+			base = known
+		}
+	}
+	scale := float64(size) / float64(base)
+	return int(float64(hlsBitrates[base]) * scale * scale)
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHLSLadder(t *testing.T) {
+	tests := []struct {
+		name          string
+		sizes         []int
+		width, height int
+		want          []hlsRendition
+	}{
+		{"landscape", []int{1080, 720, 360, 720, 100}, 1280, 720, []hlsRendition{
+			{"720p", 1280, 720, 2800, 128},
+			{"360p", 640, 360, 800, 96},
+		}},
+		{"portrait", []int{480, 720}, 720, 1280, []hlsRendition{
+			{"720p", 720, 1280, 2800, 128},
+			{"480p", 480, 852, 1400, 96},
+		}},
+		{"every rendition larger than the video", []int{1080}, 640, 360, []hlsRendition{
+			{"360p", 640, 360, 800, 96},
+		}},
+		{"no renditions configured", nil, 540, 540, []hlsRendition{
+			{"540p", 540, 540, 1771, 96},
+		}},
+	}
+	for _, tt := range tests {
+		if got := hlsLadder(tt.sizes, tt.width, tt.height); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: hlsLadder() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHLSBitrate(t *testing.T) {
+	tests := map[int]int{
+		240:  400,
+		720:  2800,
+		1080: 5000,
+		540:  1771, // 480p's bitrate scaled by area
+		1000: 5401,
+		200:  277, // Below the smallest known size
+	}
+	for size, want := range tests {
+		if got := hlsBitrate(size); got != want {
+			t.Errorf("hlsBitrate(%d) = %d, want %d", size, got, want)
+		}
+	}
+}
@@ -5,12 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
-	"os"
+	"strconv"
 	"time"
 )
 
+// ReelCover is a Reel's cover: an image Instagram downloads, or a frame of the
+// video itself. URL wins if both are set; neither lets Instagram pick a frame.
+type ReelCover struct {
+	URL         string   // Signed URL of the cover image (cover_url)
+	FrameOffset *float64 // Seconds into the video of the cover frame (thumb_offset)
+}
+
 // InstagramService handles Instagram Graph API integration
 type InstagramService struct {
 	accessToken string
@@ -28,15 +34,16 @@ func NewInstagramService(accessToken string) *InstagramService {
 }
 
 // UploadVideoToInstagram uploads a video to Instagram as a Reel
+// videoURL is a signed media URL Instagram downloads the video from
+// cover sets the Reel's cover image
 // Steps:
-// 1. Upload video to Instagram's hosting
-// 2. Create media container
-// 3. Publish the container
-func (is *InstagramService) UploadVideoToInstagram(videoPath, caption, instagramUserID string) (string, string, error) {
+// 1. Create media container
+// 2. Publish the container
+func (is *InstagramService) UploadVideoToInstagram(videoURL, caption, instagramUserID string, cover ReelCover) (string, string, error) {
 	fmt.Printf("\n📸 Starting Instagram upload...\n")
 	
 	// Step 1: Create media container
-	containerID, err := is.createMediaContainer(videoPath, caption, instagramUserID)
+	containerID, err := is.createMediaContainer(videoURL, caption, instagramUserID, cover)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create media container: %v", err)
 	}
@@ -61,18 +68,10 @@ func (is *InstagramService) UploadVideoToInstagram(videoPath, caption, instagram
 }
 
 // createMediaContainer creates an Instagram media container for video
-func (is *InstagramService) createMediaContainer(videoPath, caption, instagramUserID string) (string, error) {
+func (is *InstagramService) createMediaContainer(videoURL, caption, instagramUserID string, cover ReelCover) (string, error) {
 	// Instagram Graph API endpoint
 	apiURL := fmt.Sprintf("https://graph.facebook.com/v18.0/%s/media", instagramUserID)
 	
-	// Upload video to a public URL first (using temporary hosting service)
-	videoURL, err := is.uploadToTemporaryHost(videoPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to upload to temporary host: %v", err)
-	}
-	
-	fmt.Printf("   Video uploaded to temporary host: %s\n", videoURL)
-	
 	// Create container payload
 	payload := map[string]string{
 		"media_type":   "REELS",
@@ -80,6 +79,11 @@ func (is *InstagramService) createMediaContainer(videoPath, caption, instagramUs
 		"caption":      caption,
 		"access_token": is.accessToken,
 	}
+	if cover.URL != "" {
+		payload["cover_url"] = cover.URL
+	} else if cover.FrameOffset != nil {
+		payload["thumb_offset"] = strconv.Itoa(int(*cover.FrameOffset * 1000)) // Milliseconds
+	}
 	
 	jsonData, _ := json.Marshal(payload)
 	
@@ -147,54 +151,6 @@ func (is *InstagramService) publishMediaContainer(containerID, instagramUserID s
 	return postID, postURL, nil
 }
 
-// uploadToTemporaryHost uploads video to a temporary hosting service
-// In production, you should use your own CDN or Instagram's hosting
-func (is *InstagramService) uploadToTemporaryHost(videoPath string) (string, error) {
-	// Use 0x0.st for temporary hosting
-	videoData, err := os.ReadFile(videoPath)
-	if err != nil {
-		return "", err
-	}
-	
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	
-	part, err := writer.CreateFormFile("file", "video.mp4")
-	if err != nil {
-		return "", err
-	}
-	
-	if _, err := part.Write(videoData); err != nil {
-		return "", err
-	}
-	
-	writer.Close()
-	
-	req, err := http.NewRequest("POST", "https://0x0.st", body)
-	if err != nil {
-		return "", err
-	}
-	
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	
-	resp, err := is.client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("upload failed (%s): %s", resp.Status, string(bodyBytes))
-	}
-	
-	// 0x0.st returns URL directly
-	videoURL := string(bodyBytes)
-	
-	return videoURL, nil
-}
-
 // GenerateInstagramCaption generates an engaging Instagram caption from product details
 func GenerateInstagramCaption(productName, productDescription, productPrice string) string {
 	caption := ""
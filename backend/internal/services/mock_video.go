@@ -0,0 +1,301 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dealshare/hacathon/backend/internal/subtitles"
+	"github.com/google/uuid"
+)
+
+// Offline rendering used by the "mock" providers. Everything is drawn locally
+// with ffmpeg from the uploaded media, so the output is a real, playable MP4.
+const (
+	mockWordsPerSecond = subtitles.WordsPerSecond // Roughly a presenter's speaking pace
+	mockMinDuration    = 6.0
+	mockMaxDuration    = 60.0
+	mockProductClip    = 10.0 // Same length as a RunwayML clip
+)
+
+// renderMockVideo renders the product image with a Ken Burns move, the person
+// media as a picture-in-picture and, when burnCaptions is set, the script as
+// captions. It stands in for the whole pipeline when no compositor follows.
+func (vg *VideoGenerator) renderMockVideo(productImagePath, personMediaPath, personMediaType, script, style string, burnCaptions bool) (string, error) {
+	fmt.Printf("\n🧪 Rendering offline mock video with ffmpeg...\n")
+
+	if productImagePath == "" {
+		return "", fmt.Errorf("product image path is required")
+	}
+
+	duration := mockDuration(script)
+	fmt.Printf("📹 Style: %s, duration: %.1fs\n", mockStyle(style), duration)
+
+	var captions []string
+	if burnCaptions {
+		captionDir, err := os.MkdirTemp("", "mock-captions-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create caption directory: %v", err)
+		}
+		defer os.RemoveAll(captionDir)
+
+		captions, err = vg.captionFilters(captionDir, subtitles.Build(script, 0, duration), canvas{compositeWidth, compositeHeight})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	os.MkdirAll(vg.config.GeneratedVideoPath, 0755)
+	outputPath := filepath.Join(vg.config.GeneratedVideoPath, fmt.Sprintf("%s.mp4", uuid.New().String()))
+
+	voiced := vg.mockVoiced(personMediaPath, personMediaType)
+	args := mockVideoArgs(productImagePath, personMediaPath, personMediaType, voiced, style, duration, captions, outputPath)
+	if err := vg.runFFmpeg(args, outputPath); err != nil {
+		return "", fmt.Errorf("mock video rendering failed: %v", err)
+	}
+
+	if _, err := vg.storeVideo(outputPath); err != nil {
+		return "", err
+	}
+	fmt.Printf("✅ Mock video saved at %s\n", outputPath)
+	return outputPath, nil
+}
+
+// renderMockPresenter renders the presenter clip alone, as an avatar vendor would:
+// the person media for as long as the script takes to read, with the clip's own
+// voice or a silent track. The compositor adds the product, captions and music.
+func (vg *VideoGenerator) renderMockPresenter(personMediaPath, personMediaType, script string) (string, error) {
+	fmt.Printf("\n🧪 Rendering offline presenter clip with ffmpeg...\n")
+
+	duration := mockDuration(script)
+	os.MkdirAll(vg.config.GeneratedVideoPath, 0755)
+	outputPath := filepath.Join(vg.config.GeneratedVideoPath, fmt.Sprintf("%s.mp4", uuid.New().String()))
+
+	voiced := vg.mockVoiced(personMediaPath, personMediaType)
+	if err := vg.runFFmpeg(mockPresenterArgs(personMediaPath, personMediaType, voiced, duration, outputPath), outputPath); err != nil {
+		return "", fmt.Errorf("presenter clip rendering failed: %v", err)
+	}
+
+	if _, err := vg.storeVideo(outputPath); err != nil {
+		return "", err
+	}
+	fmt.Printf("✅ Presenter clip saved at %s (%.1fs)\n", outputPath, duration)
+	return outputPath, nil
+}
+
+// mockVoiced reports whether the person media is a video with a voice to keep
+func (vg *VideoGenerator) mockVoiced(personMediaPath, personMediaType string) bool {
+	if personMediaPath == "" || personMediaType != "video" {
+		return false
+	}
+	voiced, err := vg.hasAudio(personMediaPath)
+	if err != nil {
+		fmt.Printf("⚠️  Could not check the person clip for audio, using silence: %v\n", err)
+	}
+	return voiced
+}
+
+// mockPersonInput returns the ffmpeg input options for the person media
+func mockPersonInput(personMediaPath, personMediaType string) []string {
+	if personMediaType == "video" {
+		return []string{"-stream_loop", "-1", "-i", personMediaPath}
+	}
+	return []string{"-loop", "1", "-framerate", fmt.Sprint(compositeFPS), "-i", personMediaPath}
+}
+
+// mockSilence is a silent track, so players, Instagram and the ducking and
+// loudness stages always have one
+var mockSilence = []string{"-f", "lavfi", "-i", "anullsrc=r=44100:cl=stereo"}
+
+// mockVideoArgs returns the ffmpeg arguments for a complete mock video. captions
+// are drawtext filters from captionFilters, or none.
+func mockVideoArgs(productImagePath, personMediaPath, personMediaType string, voiced bool, style string, duration float64, captions []string, outputPath string) []string {
+	args := []string{"-y", "-loop", "1", "-framerate", fmt.Sprint(compositeFPS), "-i", productImagePath}
+	inputs := 1
+	if personMediaPath != "" {
+		args = append(args, mockPersonInput(personMediaPath, personMediaType)...)
+		inputs++
+	}
+	audio := "1:a" // Keep the uploaded voice
+	if !voiced {
+		args = append(args, mockSilence...)
+		audio = fmt.Sprintf("%d:a", inputs)
+	}
+
+	graph := []string{"[0:v]" + kenBurnsFilter(style, int(duration*compositeFPS)) + "[bg]"}
+	current := "bg"
+
+	if personMediaPath != "" {
+		// Person in the bottom-right corner, above the caption band
+		graph = append(graph,
+			"[1:v]fps=30,scale=360:360:force_original_aspect_ratio=decrease,pad=iw+8:ih+8:4:4:white,setsar=1[pip]",
+			fmt.Sprintf("[%s][pip]overlay=x=W-w-32:y=H-h-110[withpip]", current))
+		current = "withpip"
+	}
+
+	if len(captions) > 0 {
+		graph = append(graph, fmt.Sprintf("[%s]%s[captioned]", current, strings.Join(captions, ",")))
+		current = "captioned"
+	}
+	graph = append(graph, fmt.Sprintf("[%s]format=yuv420p[out]", current))
+
+	return append(args,
+		"-filter_complex", strings.Join(graph, ";"),
+		"-map", "[out]",
+		"-map", audio,
+		"-t", formatSeconds(duration),
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "23",
+		"-c:a", "aac", "-b:a", "128k",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+}
+
+// mockPresenterArgs returns the ffmpeg arguments for a presenter clip. Without
+// person media the presenter is a plain card, so the pipeline still has a clip.
+func mockPresenterArgs(personMediaPath, personMediaType string, voiced bool, duration float64, outputPath string) []string {
+	args := []string{"-y"}
+	if personMediaPath != "" {
+		args = append(args, mockPersonInput(personMediaPath, personMediaType)...)
+	} else {
+		args = append(args, "-f", "lavfi", "-i", fmt.Sprintf("color=c=0x1a1a2e:s=720x720:r=%d", compositeFPS))
+	}
+	audio := "0:a"
+	if !voiced {
+		args = append(args, mockSilence...)
+		audio = "1:a"
+	}
+
+	return append(args,
+		"-filter_complex", fmt.Sprintf("[0:v]fps=%d,scale=720:720:force_original_aspect_ratio=decrease,pad=ceil(iw/2)*2:ceil(ih/2)*2,setsar=1,format=yuv420p[out]", compositeFPS),
+		"-map", "[out]",
+		"-map", audio,
+		"-t", formatSeconds(duration),
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "23",
+		"-c:a", "aac", "-b:a", "128k",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+}
+
+// renderKenBurnsClip renders a short silent product clip, standing in for a product video provider
+func (vg *VideoGenerator) renderKenBurnsClip(productImagePath, style string) (string, error) {
+	fmt.Printf("\n🧪 Rendering offline product clip (%s) with ffmpeg...\n", mockStyle(style))
+
+	if productImagePath == "" {
+		return "", fmt.Errorf("product image path is required")
+	}
+
+	os.MkdirAll(vg.config.GeneratedVideoPath, 0755)
+	outputPath := filepath.Join(vg.config.GeneratedVideoPath, fmt.Sprintf("%s.mp4", uuid.New().String()))
+
+	args := []string{
+		"-y", "-loop", "1", "-framerate", fmt.Sprint(compositeFPS), "-i", productImagePath,
+		"-vf", kenBurnsFilter(style, int(mockProductClip*compositeFPS)) + ",format=yuv420p",
+		"-t", formatSeconds(mockProductClip),
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "23",
+		"-movflags", "+faststart",
+		outputPath,
+	}
+
+	if err := vg.runFFmpeg(args, outputPath); err != nil {
+		return "", fmt.Errorf("product clip rendering failed: %v", err)
+	}
+
+	if _, err := vg.storeVideo(outputPath); err != nil {
+		return "", err
+	}
+	fmt.Printf("✅ Product clip saved at %s\n", outputPath)
+	return outputPath, nil
+}
+
+// kenBurnsFilter returns a filter chain that fills the canvas with the image and
+// moves across it over the given number of frames, following product_video_style
+func kenBurnsFilter(style string, frames int) string {
+	// Upscale first so zoompan has pixels to spare and the motion stays smooth
+	chain := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d,setsar=1",
+		compositeWidth*2, compositeHeight*2, compositeWidth*2, compositeHeight*2)
+
+	progress := fmt.Sprintf("(on/%d)", frames)
+	center := "x='iw/2-(iw/zoom/2)':y='ih/2-(ih/zoom/2)'"
+	var zoom, position string
+
+	switch mockStyle(style) {
+	case "zoom":
+		// Push in on the product details
+		zoom, position = "1+0.35*"+progress, center
+	case "pan":
+		// Slide across the product at a fixed zoom
+		zoom, position = "1.25", "x='(iw-iw/zoom)*"+progress+"':y='ih/2-(ih/zoom/2)'"
+	case "reveal", "hero":
+		// Start close and pull back to the full product
+		zoom, position = "1.4-0.4*"+progress, center
+	case "rotation", "showcase", "premium":
+		// Gentle sway around the centre, zoomed enough to hide the rotated corners
+		chain += ",rotate=a='0.04*sin(2*PI*t/5)':fillcolor=black"
+		zoom, position = "1.2+0.1*"+progress, center
+	default:
+		// Cinematic: slow push-in while drifting to the right
+		zoom, position = "1+0.2*"+progress, "x='(iw-iw/zoom)*(0.3+0.4*"+progress+")':y='ih/2-(ih/zoom/2)'"
+	}
+
+	return chain + fmt.Sprintf(",zoompan=z='%s':%s:d=1:s=%dx%d:fps=%d",
+		zoom, position, compositeWidth, compositeHeight, compositeFPS)
+}
+
+// captionFilters returns one drawtext filter per cue, sized for the frame. Vertical
+// frames keep captions clear of the Reels and Stories controls at the bottom.
+// The text goes through files so quotes and colons in the script need no escaping.
+func (vg *VideoGenerator) captionFilters(dir string, cues []subtitles.Cue, frame canvas) ([]string, error) {
+	font := ""
+	if vg.config.CaptionFontPath != "" {
+		font = fmt.Sprintf(":fontfile='%s'", vg.config.CaptionFontPath)
+	}
+	size := min(frame.width, frame.height) / 18
+	margin := frame.height / 18
+	if frame.height > frame.width {
+		margin = frame.height / 5
+	}
+
+	filters := make([]string, 0, len(cues))
+	for i, cue := range cues {
+		textPath := filepath.Join(dir, fmt.Sprintf("%d.txt", i))
+		if err := os.WriteFile(textPath, []byte(cue.Text), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write caption: %v", err)
+		}
+		filters = append(filters, fmt.Sprintf(
+			"drawtext=textfile='%s'%s:fontsize=%d:fontcolor=white:box=1:boxcolor=black@0.6:boxborderw=16:x=(w-tw)/2:y=h-th-%d:enable='between(t,%.3f,%.3f)'",
+			textPath, font, size, margin, cue.Start, cue.End))
+	}
+	return filters, nil
+}
+
+// mockDuration estimates how long a presenter would take to read the script
+func mockDuration(script string) float64 {
+	seconds := float64(len(strings.Fields(script))) / mockWordsPerSecond
+	return math.Min(math.Max(math.Ceil(seconds), mockMinDuration), mockMaxDuration)
+}
+
+// mockStyle resolves "auto" and empty styles the same way the real providers do
+func mockStyle(style string) string {
+	if style == "" || style == "auto" {
+		return "cinematic"
+	}
+	return style
+}
+
+// runFFmpeg runs ffmpeg and removes the partial output if it fails or the run is cancelled
+func (vg *VideoGenerator) runFFmpeg(args []string, outputPath string) error {
+	cmd := exec.CommandContext(vg.context(), "ffmpeg", args...)
+	cmd.WaitDelay = 5 * time.Second // Don't hang on leftover pipes once ffmpeg is killed
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("%v\n%s", err, tail(string(output), 20))
+	}
+	return nil
+}
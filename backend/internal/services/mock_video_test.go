@@ -0,0 +1,173 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dealshare/hacathon/backend/internal/config"
+	"github.com/dealshare/hacathon/backend/internal/subtitles"
+)
+
+func TestMockDuration(t *testing.T) {
+	tests := []struct {
+		words int
+		want  float64
+	}{
+		{0, mockMinDuration},
+		{10, mockMinDuration},
+		{20, 8},
+		{21, 9}, // Rounded up to whole seconds
+		{1000, mockMaxDuration},
+	}
+	for _, tt := range tests {
+		script := strings.TrimSpace(strings.Repeat("word ", tt.words))
+		if got := mockDuration(script); got != tt.want {
+			t.Errorf("mockDuration(%d words) = %v, want %v", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestKenBurnsFilter(t *testing.T) {
+	tests := []struct {
+		style string
+		zoom  string
+	}{
+		{"", "z='1+0.2*(on/300)'"},
+		{"auto", "z='1+0.2*(on/300)'"},
+		{"cinematic", "z='1+0.2*(on/300)'"},
+		{"zoom", "z='1+0.35*(on/300)'"},
+		{"pan", "z='1.25'"},
+		{"reveal", "z='1.4-0.4*(on/300)'"},
+		{"hero", "z='1.4-0.4*(on/300)'"},
+		{"rotation", "z='1.2+0.1*(on/300)'"},
+	}
+	for _, tt := range tests {
+		filter := kenBurnsFilter(tt.style, 300)
+		if !strings.Contains(filter, tt.zoom) {
+			t.Errorf("kenBurnsFilter(%q) = %q, want zoom %s", tt.style, filter, tt.zoom)
+		}
+		if !strings.HasPrefix(filter, "scale=2560:1440:") || !strings.HasSuffix(filter, ":d=1:s=1280x720:fps=30") {
+			t.Errorf("kenBurnsFilter(%q) does not fill the 1280x720 canvas: %q", tt.style, filter)
+		}
+		if rotates := strings.Contains(filter, "rotate="); rotates != (tt.style == "rotation") {
+			t.Errorf("kenBurnsFilter(%q) rotates = %v", tt.style, rotates)
+		}
+	}
+}
+
+func TestMockCaptionFilters(t *testing.T) {
+	vg := NewVideoGenerator(&config.Config{CaptionFontPath: "/fonts/Inter.ttf"})
+	dir := t.TempDir()
+
+	cues := []subtitles.Cue{
+		{Start: 0, End: 2.5, Text: "Meet the kettle:"},
+		{Start: 2.5, End: 5.25, Text: "it's 'quiet', too."},
+	}
+	tests := []struct {
+		name      string
+		frame     canvas
+		fontsize  string
+		placement string
+	}{
+		{"landscape", canvas{1280, 720}, ":fontsize=40:", ":y=h-th-40:"},
+		{"portrait keeps clear of the controls", canvas{720, 1280}, ":fontsize=40:", ":y=h-th-256:"},
+	}
+	for _, tt := range tests {
+		filters, err := vg.captionFilters(dir, cues, tt.frame)
+		if err != nil {
+			t.Fatalf("%s: captionFilters() error = %v", tt.name, err)
+		}
+		if len(filters) != len(cues) {
+			t.Fatalf("%s: captionFilters() returned %d filters, want one per cue", tt.name, len(filters))
+		}
+		for i, filter := range filters {
+			if !strings.Contains(filter, tt.fontsize) || !strings.Contains(filter, tt.placement) || !strings.Contains(filter, ":fontfile='/fonts/Inter.ttf'") {
+				t.Errorf("%s: filter %d = %q", tt.name, i, filter)
+			}
+		}
+		if !strings.Contains(filters[1], "between(t,2.500,5.250)") {
+			t.Errorf("%s: second caption is not shown during its cue: %q", tt.name, filters[1])
+		}
+	}
+
+	// The text goes through a file, so quotes and colons need no escaping
+	text, err := os.ReadFile(filepath.Join(dir, "1.txt"))
+	if err != nil || string(text) != cues[1].Text {
+		t.Errorf("caption file = %q, %v", text, err)
+	}
+	if filters, err := vg.captionFilters(dir, nil, landscape); err != nil || len(filters) != 0 {
+		t.Errorf("captionFilters() without cues = %v, %v", filters, err)
+	}
+}
+
+// argAfter returns the argument following flag, or "" when flag is missing
+func argAfter(args []string, flag string) string {
+	for i, arg := range args[:len(args)-1] {
+		if arg == flag {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func TestMockPresenterArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaPath string
+		mediaType string
+		voiced    bool
+		inputs    []string
+		audio     string
+	}{
+		{"photo", "person.jpg", "image", false, []string{"person.jpg", "anullsrc=r=44100:cl=stereo"}, "1:a"},
+		{"video with a voice", "person.mp4", "video", true, []string{"person.mp4"}, "0:a"},
+		{"silent video", "person.mp4", "video", false, []string{"person.mp4", "anullsrc=r=44100:cl=stereo"}, "1:a"},
+		{"no person media", "", "", false, []string{"color=c=0x1a1a2e:s=720x720:r=30", "anullsrc=r=44100:cl=stereo"}, "1:a"},
+	}
+	for _, tt := range tests {
+		args := mockPresenterArgs(tt.mediaPath, tt.mediaType, tt.voiced, 8, "out.mp4")
+
+		// Only the presenter: the product, captions and music are the compositor's job
+		var inputs []string
+		for i, arg := range args[:len(args)-1] {
+			if arg == "-i" {
+				inputs = append(inputs, args[i+1])
+			}
+		}
+		if strings.Join(inputs, " ") != strings.Join(tt.inputs, " ") {
+			t.Errorf("%s: inputs = %v, want %v", tt.name, inputs, tt.inputs)
+		}
+		if graph := argAfter(args, "-filter_complex"); strings.Contains(graph, "drawtext") || strings.Contains(graph, "zoompan") {
+			t.Errorf("%s: presenter clip draws more than the presenter: %s", tt.name, graph)
+		}
+		var maps []string
+		for i, arg := range args[:len(args)-1] {
+			if arg == "-map" {
+				maps = append(maps, args[i+1])
+			}
+		}
+		if len(maps) != 2 || maps[1] != tt.audio {
+			t.Errorf("%s: maps = %v, want the audio from %s", tt.name, maps, tt.audio)
+		}
+		if got := argAfter(args, "-t"); got != "8" {
+			t.Errorf("%s: -t = %s, want the script's reading time", tt.name, got)
+		}
+	}
+}
+
+func TestMockVideoArgsCaptions(t *testing.T) {
+	caption := "drawtext=textfile='0.txt':fontsize=40"
+
+	graph := argAfter(mockVideoArgs("product.png", "person.jpg", "image", false, "zoom", 6, []string{caption}, "out.mp4"), "-filter_complex")
+	if !strings.Contains(graph, "[withpip]"+caption+"[captioned]") {
+		t.Errorf("burned captions are not drawn over the picture-in-picture: %s", graph)
+	}
+
+	// Captions off or in sidecar files only: nothing is burned in
+	graph = argAfter(mockVideoArgs("product.png", "person.jpg", "image", false, "zoom", 6, nil, "out.mp4"), "-filter_complex")
+	if strings.Contains(graph, "drawtext") || !strings.HasSuffix(graph, "[withpip]format=yuv420p[out]") {
+		t.Errorf("graph without captions = %s", graph)
+	}
+}
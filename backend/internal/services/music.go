@@ -0,0 +1,147 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/dealshare/hacathon/backend/internal/music"
+)
+
+// duckedMusicVolume is the music's level under the voice in Shotstack renders,
+// which cannot duck by sidechain: the bed drops once the presenter starts talking
+const duckedMusicVolume = 0.12
+
+// PickMusic resolves a request's music option, a track name or a mood, to the
+// name of a track in the library; "" or "none" resolve to "" (no music)
+func PickMusic(choice string) (string, error) {
+	track, err := music.Pick(choice)
+	if err != nil || track == nil {
+		return "", err
+	}
+	return track.Name, nil
+}
+
+// musicPath returns the local file of the request's track. A track removed from
+// the library since the job was queued is left out rather than failing the video.
+func (p *Pipeline) musicPath(req VideoRequest) string {
+	if req.Music == "" {
+		return ""
+	}
+	track, ok := music.Get(req.Music)
+	if !ok {
+		fmt.Printf("⚠️  Music track %q is no longer in the library; rendering without music\n", req.Music)
+		return ""
+	}
+	return track.Path
+}
+
+// hostMusic makes a library track reachable by URL: it is copied to the working
+// directory and stored like a video, so any media host can serve it. It returns
+// the URL and the track's length in seconds (0 if unknown).
+func (vg *VideoGenerator) hostMusic(musicPath string, host MediaHost) (string, float64, error) {
+	os.MkdirAll(vg.config.GeneratedVideoPath, 0755)
+	copyPath := filepath.Join(vg.config.GeneratedVideoPath, "music-"+filepath.Base(musicPath))
+	if err := copyFile(musicPath, copyPath); err != nil {
+		return "", 0, err
+	}
+	if _, err := vg.storeVideo(copyPath); err != nil {
+		return "", 0, err
+	}
+
+	url, err := host.Host(copyPath)
+	if err != nil {
+		return "", 0, err
+	}
+	duration, err := vg.getVideoDuration(musicPath)
+	if err != nil {
+		fmt.Printf("⚠️  Could not detect the music's length: %v; it will not loop\n", err)
+		duration = 0
+	}
+	return url, duration, nil
+}
+
+// shotstackMusicClips lays the track out as Shotstack audio clips over a composite
+// of the given length that starts offset seconds into the timeline. The track
+// repeats every trackDuration seconds, and each clip from avatarStart on is
+// ducked under the presenter's voice.
+func shotstackMusicClips(url string, trackDuration, duration, avatarStart, offset float64) []interface{} {
+	cuts := []float64{0}
+	if avatarStart > 0 {
+		cuts = append(cuts, avatarStart)
+	}
+	if trackDuration > 0 {
+		for at := trackDuration; at < duration; at += trackDuration {
+			cuts = append(cuts, at)
+		}
+	}
+	cuts = append(cuts, duration)
+	sort.Float64s(cuts)
+
+	type segment struct{ start, end float64 }
+	var segments []segment
+	for i := 0; i+1 < len(cuts); i++ {
+		if cuts[i+1]-cuts[i] >= 0.01 {
+			segments = append(segments, segment{cuts[i], cuts[i+1]})
+		}
+	}
+
+	clips := make([]interface{}, 0, len(segments))
+	for i, seg := range segments {
+		volume := musicVolume
+		if seg.start >= avatarStart {
+			volume = duckedMusicVolume
+		}
+		trim := seg.start
+		if trackDuration > 0 {
+			trim = math.Mod(seg.start, trackDuration)
+		}
+		asset := map[string]interface{}{
+			"type":   "audio",
+			"src":    url,
+			"trim":   trim,
+			"volume": volume,
+		}
+		// Fade the music in at the start and out at the end
+		switch {
+		case len(segments) == 1:
+			asset["effect"] = "fadeInFadeOut"
+		case i == 0:
+			asset["effect"] = "fadeIn"
+		case i == len(segments)-1:
+			asset["effect"] = "fadeOut"
+		}
+		clips = append(clips, map[string]interface{}{
+			"asset":  asset,
+			"start":  seg.start + offset,
+			"length": seg.end - seg.start,
+		})
+	}
+	return clips
+}
+
+// copyFile copies src to dst, leaving an existing dst alone
+func copyFile(src, dst string) error {
+	if _, err := os.Stat(dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAudioFilters(t *testing.T) {
+	if filters := audioFilters(false, false, 2, 15); filters != nil {
+		t.Errorf("audioFilters() without audio = %v, want none", filters)
+	}
+
+	voice := audioFilters(true, false, 2, 15)
+	if len(voice) != 1 || !strings.HasPrefix(voice[0], "[1:a]") || !strings.HasSuffix(voice[0], "[aout]") {
+		t.Errorf("audioFilters(voice) = %v", voice)
+	}
+
+	bed := audioFilters(false, true, 3, 15)
+	if len(bed) != 1 || !strings.HasPrefix(bed[0], "[3:a]") || !strings.Contains(bed[0], "afade=t=out:st=13:d=2") {
+		t.Errorf("audioFilters(music) = %v, want the bed fading out over the last 2 seconds", bed)
+	}
+
+	mixed := strings.Join(audioFilters(true, true, 2, 15), ";")
+	for _, want := range []string{"asplit=2[voice][speech]", "[bed][speech]sidechaincompress", "[voice][ducked]amix", "loudnorm=I=-14:TP=-1.5:LRA=11"} {
+		if !strings.Contains(mixed, want) {
+			t.Errorf("audioFilters(voice, music) = %s, missing %s", mixed, want)
+		}
+	}
+	if strings.Count(mixed, "loudnorm") != 1 {
+		t.Errorf("audioFilters(voice, music) normalises more than once: %s", mixed)
+	}
+
+	if short := strings.Join(audioFilters(false, true, 2, 1), ";"); !strings.Contains(short, "afade=t=out:st=0:") {
+		t.Errorf("audioFilters() of a composite shorter than the fade = %s", short)
+	}
+}
+
+func TestShotstackMusicClips(t *testing.T) {
+	type clip struct {
+		start, length, trim, volume float64
+		effect                      string
+	}
+	tests := []struct {
+		name                                      string
+		trackDuration, duration, avatarAt, offset float64
+		want                                      []clip
+	}{
+		{"presenter from the start", 0, 15, 0, 0, []clip{{0, 15, 0, duckedMusicVolume, "fadeInFadeOut"}}},
+		{"louder before the presenter", 0, 15, 3, 0, []clip{
+			{0, 3, 0, musicVolume, "fadeIn"},
+			{3, 12, 3, duckedMusicVolume, "fadeOut"},
+		}},
+		{"short track repeats", 6, 15, 2, 0, []clip{
+			{0, 2, 0, musicVolume, "fadeIn"},
+			{2, 4, 2, duckedMusicVolume, ""},
+			{6, 6, 0, duckedMusicVolume, ""},
+			{12, 3, 0, duckedMusicVolume, "fadeOut"},
+		}},
+		{"long track plays once", 60, 15, 0, 0, []clip{{0, 15, 0, duckedMusicVolume, "fadeInFadeOut"}}},
+		{"after an intro", 0, 15, 3, 2.5, []clip{
+			{2.5, 3, 0, musicVolume, "fadeIn"},
+			{5.5, 12, 3, duckedMusicVolume, "fadeOut"},
+		}},
+	}
+	for _, tt := range tests {
+		clips := shotstackMusicClips("https://cdn.example.com/music.mp3", tt.trackDuration, tt.duration, tt.avatarAt, tt.offset)
+		if len(clips) != len(tt.want) {
+			t.Errorf("%s: %d clips, want %d: %v", tt.name, len(clips), len(tt.want), clips)
+			continue
+		}
+		for i, raw := range clips {
+			c := raw.(map[string]interface{})
+			asset := c["asset"].(map[string]interface{})
+			effect, _ := asset["effect"].(string)
+			got := clip{c["start"].(float64), c["length"].(float64), asset["trim"].(float64), asset["volume"].(float64), effect}
+			if got != tt.want[i] || asset["type"] != "audio" || asset["src"] != "https://cdn.example.com/music.mp3" {
+				t.Errorf("%s: clip %d = %+v (%v), want %+v", tt.name, i, got, asset, tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dealshare/hacathon/backend/internal/models"
+)
+
+// Pipeline runs the selected providers in order:
+// avatar clip → product clip → composite. Without a product video provider
+// and compositor, the avatar clip is the final video.
+type Pipeline struct {
+	Avatar       AvatarGenerator
+	ProductVideo ProductVideoGenerator
+	Compositor   Compositor
+
+	generator *VideoGenerator // Used for progress reporting and carries the run's context
+	tasks     *taskTracker
+}
+
+// VideoRequest describes a single video generation run
+type VideoRequest struct {
+	ProductImagePath  string
+	PersonMediaPath   string
+	PersonMediaType   string
+	Script            string
+	Language          string // Code of the script's language, which picks the presenter's voice; empty for English
+	Title             string // The product's name, shown on the title card cover
+	ProductVideoStyle string
+	Layout            string
+	Timing            Timing
+	AspectRatios      []string          // Composites to render, e.g. "9:16"; the first is the primary one (default 16:9)
+	Captions          string            // CaptionsBurned (default), CaptionsSidecar or CaptionsOff
+	Music             string            // Name of a library track mixed under the voice; empty for none
+	Brand             *models.BrandKit  // The workspace's logo, colours and bumpers; nil for none
+	Providers         ProviderSelection // Per-project overrides of the configured providers
+	Resume            ResumeState       // Outputs of an interrupted run of the same job
+}
+
+// ResumeState is what an interrupted run left behind. Steps whose stored video
+// still exists are skipped, and steps with a remote task ID are re-polled.
+type ResumeState struct {
+	AvatarVideoPath  string
+	ProductVideoPath string
+	AvatarTaskID     string
+	ProductTaskID    string
+	CompositeTaskID  string            // The render that was running: the first aspect ratio missing from Renders
+	Renders          map[string]string // Finished composites by aspect ratio
+}
+
+// Run generates the final video and returns its storage key. The request's
+// media paths are local files; clips are reported by storage key.
+func (p *Pipeline) Run(req VideoRequest) (string, error) {
+	vg := p.generator
+
+	if p.ProductVideo == nil || p.Compositor == nil {
+		fmt.Printf("📝 Using %s video generation (avatar only)\n", p.Avatar.Name())
+		vg.reportStage(StageAvatar, 5)
+		avatarVideoPath, err := p.step(p.Avatar, req.Resume.AvatarVideoPath, req.Resume.AvatarTaskID, func() (string, error) {
+			avatarReq := p.avatarRequest(req)
+			avatarReq.Standalone = true
+			return p.Avatar.GenerateAvatar(avatarReq)
+		})
+		if err != nil {
+			return "", err
+		}
+		key := p.artifact(Artifact{Stage: StageAvatar, Kind: models.AssetKindAvatarVideo}, p.Avatar, avatarVideoPath, req.PersonMediaPath, req.ProductImagePath)
+		p.captions(req, avatarVideoPath, false) // No compositor to burn them in, so subtitle files only
+		p.thumbnails(req, avatarVideoPath, "", Branding{})
+		p.stream(avatarVideoPath, "")
+		if req.Music != "" {
+			fmt.Printf("⚠️  Music needs a compositor; the avatar clip is delivered without it\n")
+		}
+		if req.Brand != nil {
+			fmt.Printf("⚠️  Branding needs a compositor; the avatar clip is delivered without it\n")
+		}
+		return key, nil
+	}
+
+	fmt.Printf("\n🚀 ========================================\n")
+	fmt.Printf("🚀 FULL AI PIPELINE STARTED\n")
+	fmt.Printf("🚀 ========================================\n\n")
+
+	// Set defaults
+	ratios, err := AspectRatios(req.AspectRatios)
+	if err != nil {
+		return "", err
+	}
+	productVideoStyle := req.ProductVideoStyle
+	if productVideoStyle == "" {
+		productVideoStyle = "cinematic" // Default: cinematic for MOST dynamic product showcase
+	}
+	layout := req.Layout
+	if layout == "" {
+		layout = "product_main" // Default: PRODUCT CENTERED - product fullscreen with person in bottom-right
+	}
+
+	fmt.Printf("📋 Configuration:\n")
+	fmt.Printf("   Avatar: %s\n", p.Avatar.Name())
+	fmt.Printf("   Product Video: %s\n", p.ProductVideo.Name())
+	fmt.Printf("   Compositor: %s\n", p.Compositor.Name())
+	fmt.Printf("   Product Video Style: %s\n", productVideoStyle)
+	fmt.Printf("   Layout: %s\n", layout)
+	fmt.Printf("   Aspect Ratios: %s\n", strings.Join(ratios, ", "))
+	fmt.Printf("\n📐 Available Layouts:\n")
+	fmt.Printf("   • product_main   : Product fullscreen + Person bottom-right corner - ⭐ RECOMMENDED\n")
+	fmt.Printf("   • presenter      : Person (60%%) left + Product (40%%) right - for product explanation\n")
+	fmt.Printf("   • split          : Side-by-side 50/50 - balanced, professional\n")
+	fmt.Printf("   • dual_highlight : Person + Product both highlighted with borders - integrated, equal showcase\n")
+	fmt.Printf("   • avatar_main    : Avatar fullscreen + product overlay\n\n")
+
+	// Step 1: Generate talking avatar
+	fmt.Printf("📍 STEP 1/3: Generating Talking Avatar with %s\n", p.Avatar.Name())
+	vg.reportStage(StageAvatar, 5)
+	avatarVideoPath, err := p.step(p.Avatar, req.Resume.AvatarVideoPath, req.Resume.AvatarTaskID, func() (string, error) {
+		return p.Avatar.GenerateAvatar(p.avatarRequest(req))
+	})
+	if err != nil {
+		return "", fmt.Errorf("step 1 failed (%s avatar): %v", p.Avatar.Name(), err)
+	}
+	p.artifact(Artifact{Stage: StageAvatar, Kind: models.AssetKindAvatarVideo}, p.Avatar, avatarVideoPath, req.PersonMediaPath, req.ProductImagePath)
+	fmt.Printf("✅ STEP 1 COMPLETE: Avatar video saved at %s\n\n", avatarVideoPath)
+
+	// Step 2: Generate product video
+	fmt.Printf("📍 STEP 2/3: Generating Product Video with %s\n", p.ProductVideo.Name())
+	vg.reportStage(StageProduct, 40)
+	productVideoPath, err := p.step(p.ProductVideo, req.Resume.ProductVideoPath, req.Resume.ProductTaskID, func() (string, error) {
+		return p.ProductVideo.GenerateProductVideo(ProductVideoRequest{
+			ProductImagePath: req.ProductImagePath,
+			Style:            productVideoStyle,
+			AspectRatio:      ratios[0],
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("step 2 failed (%s product video): %v", p.ProductVideo.Name(), err)
+	}
+	p.artifact(Artifact{Stage: StageProduct, Kind: models.AssetKindProductVideo}, p.ProductVideo, productVideoPath, req.ProductImagePath)
+	fmt.Printf("✅ STEP 2 COMPLETE: Product video saved at %s\n\n", productVideoPath)
+
+	// Step 3: Composite videos
+	fmt.Printf("📍 STEP 3/3: Compositing Videos with %s\n", p.Compositor.Name())
+	vg.reportStage(StageComposite, 75)
+	req.Layout = layout
+	finalKey, err := p.composite(req, avatarVideoPath, productVideoPath)
+	if err != nil {
+		return "", fmt.Errorf("step 3 failed (%s compositing): %v", p.Compositor.Name(), err)
+	}
+	fmt.Printf("✅ STEP 3 COMPLETE: Final video saved at %s\n\n", finalKey)
+
+	fmt.Printf("🎉 ========================================\n")
+	fmt.Printf("🎉 FULL AI PIPELINE COMPLETED SUCCESSFULLY!\n")
+	fmt.Printf("🎉 Final Video: %s\n", finalKey)
+	fmt.Printf("🎉 ========================================\n\n")
+
+	return finalKey, nil
+}
+
+// Recomposite renders a new final video from the stored clips in req.Resume with
+// the compositor alone, e.g. to try another layout without paying for new clips
+func (p *Pipeline) Recomposite(req VideoRequest) (string, error) {
+	vg := p.generator
+
+	avatarVideoPath, err := vg.localFile(req.Resume.AvatarVideoPath)
+	if err != nil {
+		return "", fmt.Errorf("avatar clip unavailable: %v", err)
+	}
+	productVideoPath, err := vg.localFile(req.Resume.ProductVideoPath)
+	if err != nil {
+		return "", fmt.Errorf("product clip unavailable: %v", err)
+	}
+	if req.Layout == "" {
+		req.Layout = "product_main"
+	}
+
+	fmt.Printf("🔁 Re-compositing stored clips with %s (layout: %s)\n", p.Compositor.Name(), req.Layout)
+	vg.reportStage(StageComposite, 10)
+	finalKey, err := p.composite(req, avatarVideoPath, productVideoPath)
+	if err != nil {
+		return "", fmt.Errorf("%s compositing failed: %v", p.Compositor.Name(), err)
+	}
+	fmt.Printf("✅ Re-composite saved at %s\n", finalKey)
+	return finalKey, nil
+}
+
+// composite renders the clips in each of the request's aspect ratios and returns
+// the key of the primary (first) render. Renders finished by an interrupted run
+// are reused.
+func (p *Pipeline) composite(req VideoRequest, avatarVideoPath, productVideoPath string) (string, error) {
+	ratios, err := AspectRatios(req.AspectRatios)
+	if err != nil {
+		return "", err
+	}
+
+	captions := p.captions(req, avatarVideoPath, true)
+	if req.Captions == CaptionsSidecar {
+		captions = Captions{}
+	}
+	musicPath := p.musicPath(req)
+	brand, err := p.branding(req.Brand)
+	if err != nil {
+		return "", err
+	}
+
+	var primary string
+	pendingTask := req.Resume.CompositeTaskID
+	for i, ratio := range ratios {
+		if len(ratios) > 1 {
+			fmt.Printf("📐 Render %d/%d: %s\n", i+1, len(ratios), ratio)
+		}
+		existing := req.Resume.Renders[ratio]
+		taskID := ""
+		if existing == "" {
+			// Renders run in order, so the interrupted task belongs to the first missing one
+			taskID, pendingTask = pendingTask, ""
+		}
+
+		finalVideoPath, err := p.step(p.Compositor, existing, taskID, func() (string, error) {
+			return p.Compositor.Composite(CompositeRequest{
+				ProductVideoPath: productVideoPath,
+				AvatarVideoPath:  avatarVideoPath,
+				Layout:           req.Layout,
+				Timing:           req.Timing,
+				AspectRatio:      ratio,
+				Captions:         captions,
+				Music:            musicPath,
+				Brand:            brand,
+			})
+		})
+		if err != nil {
+			return "", fmt.Errorf("%s render: %v", ratio, err)
+		}
+		key := p.artifact(Artifact{Stage: StageComposite, Kind: models.AssetKindFinalVideo, AspectRatio: ratio},
+			p.Compositor, finalVideoPath, avatarVideoPath, productVideoPath)
+		if existing == "" {
+			p.thumbnails(req, finalVideoPath, ratio, brand)
+			p.stream(finalVideoPath, ratio)
+		}
+		if i == 0 {
+			primary = key
+		}
+	}
+	return primary, nil
+}
+
+func (p *Pipeline) avatarRequest(req VideoRequest) AvatarRequest {
+	return AvatarRequest{
+		PersonMediaPath:   req.PersonMediaPath,
+		PersonMediaType:   req.PersonMediaType,
+		ProductImagePath:  req.ProductImagePath,
+		ProductVideoStyle: req.ProductVideoStyle,
+		Script:            req.Script,
+		Voice:             ScriptVoice(req.Language),
+		Captions:          req.Captions,
+	}
+}
+
+// step reuses the output of an interrupted run when it can and otherwise calls generate.
+// A stored clip is reused as is; an outstanding remote task is re-polled
+// if the provider supports it, so the vendor is not paid twice for the same clip.
+// If the run is cancelled mid-step, the step's remote task is cancelled at the vendor too.
+func (p *Pipeline) step(provider interface{ Name() string }, existingPath, taskID string, generate func() (string, error)) (string, error) {
+	p.tasks.reset()
+	if existingPath != "" {
+		if path, err := p.generator.localFile(existingPath); err == nil {
+			fmt.Printf("♻️  Reusing %s output from the interrupted run: %s\n", provider.Name(), existingPath)
+			return path, nil
+		}
+		fmt.Printf("⚠️  %s output %s is gone, regenerating\n", provider.Name(), existingPath)
+	}
+
+	ctx := p.generator.context()
+	if taskID != "" {
+		if resumer, ok := provider.(TaskResumer); ok {
+			fmt.Printf("♻️  Re-polling %s task %s from the interrupted run\n", provider.Name(), taskID)
+			p.tasks.Task(provider.Name(), taskID)
+			path, err := resumer.ResumeTask(taskID)
+			if err == nil {
+				return path, nil
+			}
+			if ctx.Err() != nil {
+				p.cancelRemote(provider)
+				return "", ctx.Err()
+			}
+			fmt.Printf("⚠️  Could not resume %s task %s: %v, regenerating\n", provider.Name(), taskID, err)
+			p.tasks.reset()
+		}
+	}
+
+	path, err := generate()
+	if err != nil && ctx.Err() != nil {
+		p.cancelRemote(provider)
+		return "", ctx.Err()
+	}
+	return path, err
+}
+
+// artifact reports a step's stored output along with the remote task and URL it
+// came from and the files it was made from, and returns its storage key.
+// artifact carries the stage, kind and any aspect ratio; the rest is filled in.
+func (p *Pipeline) artifact(artifact Artifact, provider interface{ Name() string }, localPath string, inputs ...string) string {
+	for _, input := range inputs {
+		if key, err := p.generator.storageKey(input); err == nil {
+			artifact.Parents = append(artifact.Parents, key)
+		}
+	}
+	artifact.TaskID, artifact.RemoteURL = p.tasks.outputs()
+	artifact.Key = videoKey(localPath)
+	artifact.Provider = provider.Name()
+	p.generator.reportArtifact(artifact)
+	return artifact.Key
+}
+
+// cancelRemote stops the running step's remote task, if the provider supports it
+func (p *Pipeline) cancelRemote(provider interface{ Name() string }) {
+	taskID := p.tasks.current()
+	if taskID == "" {
+		return
+	}
+	canceller, ok := provider.(TaskCanceller)
+	if !ok {
+		fmt.Printf("⚠️  %s cannot cancel task %s, it will finish remotely\n", provider.Name(), taskID)
+		return
+	}
+
+	// The run's context is already done, so the vendor call gets its own
+	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+	if err := canceller.CancelTask(ctx, taskID); err != nil {
+		fmt.Printf("⚠️  Failed to cancel %s task %s: %v\n", provider.Name(), taskID, err)
+	}
+}
+
+// taskTracker sits in front of the run's progress reporter and remembers the
+// remote task submitted by the running step and the URL its output came from
+type taskTracker struct {
+	next ProgressReporter
+
+	mu        sync.Mutex
+	taskID    string
+	remoteURL string
+}
+
+func (t *taskTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.taskID = ""
+	t.remoteURL = ""
+}
+
+// outputs returns the running step's remote task and download URL, if any
+func (t *taskTracker) outputs() (string, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.taskID, t.remoteURL
+}
+
+func (t *taskTracker) current() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.taskID
+}
+
+func (t *taskTracker) Task(provider, taskID string) {
+	t.mu.Lock()
+	t.taskID = taskID
+	t.mu.Unlock()
+	if t.next != nil {
+		t.next.Task(provider, taskID)
+	}
+}
+
+func (t *taskTracker) Stage(stage string, percent int) {
+	if t.next != nil {
+		t.next.Stage(stage, percent)
+	}
+}
+
+func (t *taskTracker) Artifact(artifact Artifact) {
+	if t.next != nil {
+		t.next.Artifact(artifact)
+	}
+}
+
+func (t *taskTracker) Download(url, path string) {
+	t.mu.Lock()
+	t.remoteURL = url
+	t.mu.Unlock()
+	if t.next != nil {
+		t.next.Download(url, path)
+	}
+}
+
+func (t *taskTracker) Poll(provider, taskID string, attempt, maxAttempts int, status string) {
+	if t.next != nil {
+		t.next.Poll(provider, taskID, attempt, maxAttempts, status)
+	}
+}
+
+func (t *taskTracker) Upload(host, path, url string) {
+	if t.next != nil {
+		t.next.Upload(host, path, url)
+	}
+}
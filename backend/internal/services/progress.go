@@ -0,0 +1,88 @@
+package services
+
+// Pipeline stages reported while a video is being generated
+const (
+	StageAvatar    = "avatar"
+	StageProduct   = "product"
+	StageComposite = "composite"
+)
+
+// ProgressReporter receives progress updates from a running video pipeline.
+// Implementations must be safe to call from the goroutine running the pipeline.
+type ProgressReporter interface {
+	// Stage is called when the pipeline enters a stage; percent is 0-100 for the whole run
+	Stage(stage string, percent int)
+	// Artifact is called when a stage has stored a file
+	Artifact(artifact Artifact)
+	// Poll is called for every status check of a remote task
+	Poll(provider, taskID string, attempt, maxAttempts int, status string)
+	// Upload is called when a local file has been pushed to a remote host
+	Upload(host, path, url string)
+	// Task is called when a remote task has been submitted, so it can be re-polled after a restart
+	Task(provider, taskID string)
+	// Download is called when a provider's output has been downloaded from url to path
+	Download(url, path string)
+}
+
+// Artifact is a file a run has stored, with where it came from
+type Artifact struct {
+	Stage        string
+	Kind         string   // One of the models.AssetKind constants
+	Key          string   // Storage key
+	Provider     string   // Provider that produced it; empty for files made by the server
+	TaskID       string   // The provider's remote task
+	RemoteURL    string   // Where the provider served it
+	Parents      []string // Storage keys of the files it was made from
+	AspectRatio  string   // For composites, the ratio it was rendered in
+	FrameSeconds *float64 // For thumbnails taken from a video, where in it the frame is
+}
+
+// WithProgress returns a copy of the generator that reports to the given reporter.
+// The copy shares the HTTP client and config, so it is cheap to create per job.
+func (vg *VideoGenerator) WithProgress(progress ProgressReporter) *VideoGenerator {
+	clone := *vg
+	clone.progress = progress
+	return &clone
+}
+
+// reportStage forwards a stage change to the reporter, if any
+func (vg *VideoGenerator) reportStage(stage string, percent int) {
+	if vg.progress != nil {
+		vg.progress.Stage(stage, percent)
+	}
+}
+
+// reportArtifact forwards a stored file to the reporter, if any
+func (vg *VideoGenerator) reportArtifact(artifact Artifact) {
+	if vg.progress != nil {
+		vg.progress.Artifact(artifact)
+	}
+}
+
+// reportPoll forwards a remote task status check to the reporter, if any
+func (vg *VideoGenerator) reportPoll(provider, taskID string, attempt, maxAttempts int, status string) {
+	if vg.progress != nil {
+		vg.progress.Poll(provider, taskID, attempt, maxAttempts, status)
+	}
+}
+
+// reportTask forwards a submitted remote task to the reporter, if any
+func (vg *VideoGenerator) reportTask(provider, taskID string) {
+	if vg.progress != nil {
+		vg.progress.Task(provider, taskID)
+	}
+}
+
+// reportDownload forwards a downloaded provider output to the reporter, if any
+func (vg *VideoGenerator) reportDownload(url, path string) {
+	if vg.progress != nil {
+		vg.progress.Download(url, path)
+	}
+}
+
+// reportUpload forwards a completed upload to the reporter, if any
+func (vg *VideoGenerator) reportUpload(host, path, url string) {
+	if vg.progress != nil {
+		vg.progress.Upload(host, path, url)
+	}
+}
@@ -0,0 +1,523 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dealshare/hacathon/backend/internal/config"
+	"github.com/dealshare/hacathon/backend/internal/layouts"
+	"github.com/dealshare/hacathon/backend/internal/subtitles"
+)
+
+// AvatarRequest is the input for generating a talking presenter clip
+type AvatarRequest struct {
+	PersonMediaPath   string
+	PersonMediaType   string // "image" or "video"
+	ProductImagePath  string // Some vendors render the product into the presenter clip
+	ProductVideoStyle string // Used by providers that animate the product themselves
+	Script            string
+	Voice             string // Voice to read the script with, e.g. "es-ES-AlvaroNeural"; empty for the provider's default
+	Standalone        bool   // No compositor follows, so the clip is the final video
+	Captions          string // The project's caption mode, for providers that can burn captions in themselves
+}
+
+// ProductVideoRequest is the input for animating a product image
+type ProductVideoRequest struct {
+	ProductImagePath string
+	Style            string // "rotation", "zoom", "pan", "reveal", "cinematic", "auto", ...
+	AspectRatio      string // The composite the clip is mainly for, e.g. "9:16"
+}
+
+// CompositeRequest is the input for combining the product and presenter clips
+type CompositeRequest struct {
+	ProductVideoPath string
+	AvatarVideoPath  string
+	Layout           string // Name of a layout in the layouts registry, e.g. "product_main"
+	Timing           Timing
+	AspectRatio      string   // "16:9", "9:16" or "1:1"
+	Captions         Captions // Burned in when there are cues
+	Music            string   // Local path of a track to mix under the voice; empty for none
+	Brand            Branding // Watermark, intro, outro and end card; empty for none
+}
+
+// Captions are the subtitles to burn into a composite
+type Captions struct {
+	Cues    []subtitles.Cue
+	SRTPath string // The same cues as a stored .srt file, for compositors that fetch subtitles by URL
+}
+
+// Composite length limits, in seconds
+const (
+	minCompositeDuration = 3.0
+	maxCompositeDuration = 60.0
+)
+
+// Timing sets how long a composite runs and when the presenter comes in
+type Timing struct {
+	Duration    float64 `json:"duration,omitempty"`     // Seconds; 0 means the default 15
+	AvatarStart float64 `json:"avatar_start,omitempty"` // Seconds before the presenter appears
+}
+
+// Validate checks the timing is renderable
+func (t Timing) Validate() error {
+	if t.Duration != 0 && (t.Duration < minCompositeDuration || t.Duration > maxCompositeDuration) {
+		return fmt.Errorf("duration must be between %.0f and %.0f seconds", minCompositeDuration, maxCompositeDuration)
+	}
+	if t.AvatarStart < 0 || t.AvatarStart >= t.length() {
+		return fmt.Errorf("avatar_start must be at least 0 and less than the duration (%.0f seconds)", t.length())
+	}
+	return nil
+}
+
+// length is the composite's duration in seconds
+func (t Timing) length() float64 {
+	if t.Duration == 0 {
+		return compositeDuration
+	}
+	return t.Duration
+}
+
+// AspectRatios returns the ratios to render: ratios without duplicates, or 16:9 if
+// there are none. The first is the primary render.
+func AspectRatios(ratios []string) ([]string, error) {
+	var unique []string
+	seen := map[string]bool{}
+	for _, ratio := range ratios {
+		if !layouts.ValidAspectRatio(ratio) {
+			return nil, fmt.Errorf("unknown aspect ratio %q (available: %s)", ratio, strings.Join(layouts.AspectRatios, ", "))
+		}
+		if !seen[ratio] {
+			seen[ratio] = true
+			unique = append(unique, ratio)
+		}
+	}
+	if len(unique) == 0 {
+		return []string{layouts.Landscape}, nil
+	}
+	return unique, nil
+}
+
+// ValidLayout reports whether the named layout is defined
+func ValidLayout(name string) bool {
+	_, ok := layouts.Get(name)
+	return ok
+}
+
+// Layouts lists the defined layouts, built-in and from LAYOUTS_DIR
+func Layouts() []string {
+	return layouts.Names()
+}
+
+// resolveLayout returns the named layout, falling back to product_main for unknown names
+func resolveLayout(name string) *layouts.Layout {
+	if layout, ok := layouts.Get(name); ok {
+		return layout
+	}
+	fmt.Printf("📐 Unknown layout %q, falling back to product_main\n", name)
+	layout, _ := layouts.Get("product_main")
+	return layout
+}
+
+// AvatarGenerator turns a presenter image and a script into a talking-head clip
+type AvatarGenerator interface {
+	Name() string
+	GenerateAvatar(req AvatarRequest) (string, error)
+}
+
+// ProductVideoGenerator animates a product image into a short showcase clip
+type ProductVideoGenerator interface {
+	Name() string
+	GenerateProductVideo(req ProductVideoRequest) (string, error)
+}
+
+// Compositor combines the product and presenter clips into the final video
+type Compositor interface {
+	Name() string
+	Composite(req CompositeRequest) (string, error)
+}
+
+// TaskResumer is implemented by providers whose remote tasks can be picked up
+// again by ID, e.g. after a restart, instead of being paid for twice
+type TaskResumer interface {
+	ResumeTask(taskID string) (string, error)
+}
+
+// ScriptWriter writes marketing copy for a product
+type ScriptWriter interface {
+	Name() string
+	GenerateMarketingScript(productName, productDescription, productCategory, productPrice string) (string, error)
+	GenerateScriptVariants(productName, productDescription, productCategory, productPrice string, opts ScriptOptions) ([]string, error)
+	GenerateWebsiteFeatures(productName, productDescription, productCategory, productPrice string) ([]map[string]string, error)
+}
+
+// MediaHost makes a local file reachable over HTTP for vendors that fetch media by URL
+type MediaHost interface {
+	Name() string
+	Host(filePath string) (string, error)
+}
+
+// ProviderEnv is what a provider factory can draw on when it is built for a run
+type ProviderEnv struct {
+	Config    *config.Config
+	Generator *VideoGenerator // Carries the run's context and progress reporter
+	MediaHost MediaHost       // Already resolved from the selection; may be nil
+}
+
+// Provider factories build an implementation for a single run. They return an
+// error when the provider cannot be used, e.g. because its API key is missing.
+type (
+	AvatarGeneratorFactory       func(env *ProviderEnv) (AvatarGenerator, error)
+	ProductVideoGeneratorFactory func(env *ProviderEnv) (ProductVideoGenerator, error)
+	CompositorFactory            func(env *ProviderEnv) (Compositor, error)
+	ScriptWriterFactory          func(env *ProviderEnv) (ScriptWriter, error)
+	MediaHostFactory             func(env *ProviderEnv) (MediaHost, error)
+)
+
+// registry holds every known provider by kind and name
+var registry = struct {
+	sync.RWMutex
+	avatars       map[string]AvatarGeneratorFactory
+	productVideos map[string]ProductVideoGeneratorFactory
+	compositors   map[string]CompositorFactory
+	scriptWriters map[string]ScriptWriterFactory
+	mediaHosts    map[string]MediaHostFactory
+}{
+	avatars:       map[string]AvatarGeneratorFactory{},
+	productVideos: map[string]ProductVideoGeneratorFactory{},
+	compositors:   map[string]CompositorFactory{},
+	scriptWriters: map[string]ScriptWriterFactory{},
+	mediaHosts:    map[string]MediaHostFactory{},
+}
+
+// RegisterAvatarGenerator makes an avatar provider selectable by name
+func RegisterAvatarGenerator(name string, factory AvatarGeneratorFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.avatars[name] = factory
+}
+
+// RegisterProductVideoGenerator makes a product video provider selectable by name
+func RegisterProductVideoGenerator(name string, factory ProductVideoGeneratorFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.productVideos[name] = factory
+}
+
+// RegisterCompositor makes a compositor selectable by name
+func RegisterCompositor(name string, factory CompositorFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.compositors[name] = factory
+}
+
+// RegisterScriptWriter makes a script writer selectable by name
+func RegisterScriptWriter(name string, factory ScriptWriterFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.scriptWriters[name] = factory
+}
+
+// RegisterMediaHost makes a media host selectable by name
+func RegisterMediaHost(name string, factory MediaHostFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.mediaHosts[name] = factory
+}
+
+// ProviderSelection names the provider to use for each pipeline role.
+// An empty ProductVideo or Compositor means the avatar clip is the final video.
+type ProviderSelection struct {
+	Avatar       string `json:"avatar,omitempty"`
+	ProductVideo string `json:"product_video,omitempty"`
+	Compositor   string `json:"compositor,omitempty"`
+	ScriptWriter string `json:"script_writer,omitempty"`
+	MediaHost    string `json:"media_host,omitempty"`
+}
+
+// none disables an optional pipeline role in an override
+const none = "none"
+
+// DefaultProviderSelection derives the configured providers.
+// The legacy AI_PROVIDER/USE_FULL_AI_PIPELINE settings pick the defaults,
+// and the per-role settings (AVATAR_PROVIDER, COMPOSITOR_PROVIDER, ...) override them.
+func DefaultProviderSelection(cfg *config.Config) ProviderSelection {
+	sel := ProviderSelection{
+		ScriptWriter: "gemini",
+		MediaHost:    "signed",
+	}
+
+	switch cfg.AIProvider {
+	case "did":
+		sel.Avatar = "did"
+		if cfg.UseFullAIPipeline {
+			sel.ProductVideo = "runwayml"
+			sel.Compositor = "shotstack"
+		}
+	case "runwayml":
+		sel.Avatar = "runwayml_gen2"
+	case "synthesia":
+		sel.Avatar = "synthesia"
+	default:
+		sel.Avatar = "mock"
+	}
+
+	return sel.Merge(ProviderSelection{
+		Avatar:       cfg.AvatarProvider,
+		ProductVideo: cfg.ProductVideoProvider,
+		Compositor:   cfg.CompositorProvider,
+		ScriptWriter: cfg.ScriptProvider,
+		MediaHost:    cfg.MediaHostProvider,
+	})
+}
+
+// Merge returns the selection with every non-empty field of override applied.
+// "none" clears an optional role.
+func (s ProviderSelection) Merge(override ProviderSelection) ProviderSelection {
+	pick := func(current, next string) string {
+		switch next {
+		case "":
+			return current
+		case none:
+			return ""
+		}
+		return next
+	}
+	return ProviderSelection{
+		Avatar:       pick(s.Avatar, override.Avatar),
+		ProductVideo: pick(s.ProductVideo, override.ProductVideo),
+		Compositor:   pick(s.Compositor, override.Compositor),
+		ScriptWriter: pick(s.ScriptWriter, override.ScriptWriter),
+		MediaHost:    pick(s.MediaHost, override.MediaHost),
+	}
+}
+
+// Pinned returns the selection with unselected optional roles spelled out as "none",
+// so merging it over a later configuration reproduces exactly this selection
+func (s ProviderSelection) Pinned() ProviderSelection {
+	pin := func(name string) string {
+		if name == "" {
+			return none
+		}
+		return name
+	}
+	s.ProductVideo = pin(s.ProductVideo)
+	s.Compositor = pin(s.Compositor)
+	s.MediaHost = pin(s.MediaHost)
+	return s
+}
+
+// Validate checks that every selected provider is registered
+func (s ProviderSelection) Validate() error {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if s.Avatar == "" {
+		return fmt.Errorf("an avatar provider is required")
+	}
+	_, ok := registry.avatars[s.Avatar]
+	if err := checkProvider("avatar", s.Avatar, ok, sortedKeys(registry.avatars)); err != nil {
+		return err
+	}
+	_, ok = registry.productVideos[s.ProductVideo]
+	if err := checkProvider("product video", s.ProductVideo, ok, sortedKeys(registry.productVideos)); err != nil {
+		return err
+	}
+	_, ok = registry.compositors[s.Compositor]
+	if err := checkProvider("compositor", s.Compositor, ok, sortedKeys(registry.compositors)); err != nil {
+		return err
+	}
+	_, ok = registry.scriptWriters[s.ScriptWriter]
+	if err := checkProvider("script writer", s.ScriptWriter, ok, sortedKeys(registry.scriptWriters)); err != nil {
+		return err
+	}
+	_, ok = registry.mediaHosts[s.MediaHost]
+	if err := checkProvider("media host", s.MediaHost, ok, sortedKeys(registry.mediaHosts)); err != nil {
+		return err
+	}
+	if (s.ProductVideo == "") != (s.Compositor == "") {
+		return fmt.Errorf("product video and compositor providers must be selected together")
+	}
+	return nil
+}
+
+// ValidateComposite checks the selection for compositing stored clips again. Only
+// the compositor and media host run, so the other roles are not checked.
+func (s ProviderSelection) ValidateComposite() error {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if s.Compositor == "" {
+		return fmt.Errorf("no compositor selected; set COMPOSITOR_PROVIDER or pick one for this request")
+	}
+	_, ok := registry.compositors[s.Compositor]
+	if err := checkProvider("compositor", s.Compositor, ok, sortedKeys(registry.compositors)); err != nil {
+		return err
+	}
+	_, ok = registry.mediaHosts[s.MediaHost]
+	return checkProvider("media host", s.MediaHost, ok, sortedKeys(registry.mediaHosts))
+}
+
+// checkProvider reports a selected provider that is not registered
+func checkProvider(kind, name string, known bool, names []string) error {
+	if name != "" && !known {
+		return fmt.Errorf("unknown %s provider %q (available: %s)", kind, name, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// buildPipeline instantiates the selected providers for one run
+func buildPipeline(cfg *config.Config, vg *VideoGenerator, sel ProviderSelection) (*Pipeline, error) {
+	if err := sel.Validate(); err != nil {
+		return nil, err
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	pipeline, env, err := newPipeline(cfg, vg, sel)
+	if err != nil {
+		return nil, err
+	}
+
+	avatar, err := registry.avatars[sel.Avatar](env)
+	if err != nil {
+		return nil, fmt.Errorf("avatar provider %q unavailable: %v", sel.Avatar, err)
+	}
+	pipeline.Avatar = avatar
+
+	if sel.ProductVideo != "" {
+		product, err := registry.productVideos[sel.ProductVideo](env)
+		if err != nil {
+			return nil, fmt.Errorf("product video provider %q unavailable: %v", sel.ProductVideo, err)
+		}
+		pipeline.ProductVideo = product
+
+		compositor, err := registry.compositors[sel.Compositor](env)
+		if err != nil {
+			return nil, fmt.Errorf("compositor %q unavailable: %v", sel.Compositor, err)
+		}
+		pipeline.Compositor = compositor
+	}
+
+	return pipeline, nil
+}
+
+// buildCompositePipeline instantiates only the selected compositor, for runs that
+// re-render stored clips; the avatar and product video providers are never built
+func buildCompositePipeline(cfg *config.Config, vg *VideoGenerator, sel ProviderSelection) (*Pipeline, error) {
+	if err := sel.ValidateComposite(); err != nil {
+		return nil, err
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	pipeline, env, err := newPipeline(cfg, vg, sel)
+	if err != nil {
+		return nil, err
+	}
+
+	compositor, err := registry.compositors[sel.Compositor](env)
+	if err != nil {
+		return nil, fmt.Errorf("compositor %q unavailable: %v", sel.Compositor, err)
+	}
+	pipeline.Compositor = compositor
+	return pipeline, nil
+}
+
+// newPipeline creates an empty pipeline and the environment its providers are
+// built in, including the selected media host. The caller holds the registry lock.
+func newPipeline(cfg *config.Config, vg *VideoGenerator, sel ProviderSelection) (*Pipeline, *ProviderEnv, error) {
+	// Providers report to the tracker, so the pipeline knows which remote task to cancel
+	tracker := &taskTracker{next: vg.progress}
+	vg = vg.WithProgress(tracker)
+
+	env := &ProviderEnv{Config: cfg, Generator: vg}
+	if sel.MediaHost != "" {
+		host, err := registry.mediaHosts[sel.MediaHost](env)
+		if err != nil {
+			return nil, nil, fmt.Errorf("media host %q unavailable: %v", sel.MediaHost, err)
+		}
+		env.MediaHost = host
+	}
+
+	return &Pipeline{generator: vg, tasks: tracker}, env, nil
+}
+
+// buildScriptWriter instantiates the named script writer
+func buildScriptWriter(cfg *config.Config, name string) (ScriptWriter, error) {
+	registry.RLock()
+	factory, ok := registry.scriptWriters[name]
+	names := sortedKeys(registry.scriptWriters)
+	registry.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown script writer provider %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	return factory(&ProviderEnv{Config: cfg})
+}
+
+// ProviderStatus says whether a registered provider can be built with the current configuration
+type ProviderStatus struct {
+	Role     string
+	Name     string
+	Selected bool // Part of the default selection
+	Usable   bool
+	Reason   string // Why the provider is unusable
+}
+
+// CheckProviders tries every registered factory once, so missing credentials or
+// tools show up at startup rather than in the middle of a job
+func CheckProviders(cfg *config.Config) []ProviderStatus {
+	sel := DefaultProviderSelection(cfg)
+	env := &ProviderEnv{Config: cfg, Generator: NewVideoGenerator(cfg)}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	var statuses []ProviderStatus
+	record := func(role, name, selected string, err error) {
+		status := ProviderStatus{Role: role, Name: name, Selected: name == selected, Usable: err == nil}
+		if err != nil {
+			status.Reason = err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+
+	// Media hosts first: compositors that fetch clips by URL are checked against the selected one
+	for _, name := range sortedKeys(registry.mediaHosts) {
+		host, err := registry.mediaHosts[name](env)
+		if name == sel.MediaHost {
+			env.MediaHost = host
+		}
+		record("media host", name, sel.MediaHost, err)
+	}
+	for _, name := range sortedKeys(registry.avatars) {
+		_, err := registry.avatars[name](env)
+		record("avatar", name, sel.Avatar, err)
+	}
+	for _, name := range sortedKeys(registry.productVideos) {
+		_, err := registry.productVideos[name](env)
+		record("product video", name, sel.ProductVideo, err)
+	}
+	for _, name := range sortedKeys(registry.compositors) {
+		_, err := registry.compositors[name](env)
+		record("compositor", name, sel.Compositor, err)
+	}
+	for _, name := range sortedKeys(registry.scriptWriters) {
+		_, err := registry.scriptWriters[name](env)
+		record("script writer", name, sel.ScriptWriter, err)
+	}
+	return statuses
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
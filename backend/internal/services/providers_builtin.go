@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// Built-in providers. Each vendor integration in video_generator.go is exposed
+// here behind the provider interfaces so the pipeline can mix and match them.
+func init() {
+	RegisterAvatarGenerator("did", func(env *ProviderEnv) (AvatarGenerator, error) {
+		if err := requireKey("did", "DID_API_KEY", env.Config.DIDAPIKey); err != nil {
+			return nil, err
+		}
+		return &didAvatar{vg: env.Generator}, nil
+	})
+	RegisterAvatarGenerator("synthesia", func(env *ProviderEnv) (AvatarGenerator, error) {
+		if err := requireKey("synthesia", "AI_API_KEY", env.Config.AIAPIKey); err != nil {
+			return nil, err
+		}
+		return &synthesiaAvatar{vg: env.Generator}, nil
+	})
+	RegisterAvatarGenerator("runwayml_gen2", func(env *ProviderEnv) (AvatarGenerator, error) {
+		if err := requireKey("runwayml_gen2", "AI_API_KEY", env.Config.AIAPIKey); err != nil {
+			return nil, err
+		}
+		return &runwayGen2Avatar{vg: env.Generator}, nil
+	})
+	RegisterAvatarGenerator("mock", func(env *ProviderEnv) (AvatarGenerator, error) {
+		if err := requireFFmpeg("mock"); err != nil {
+			return nil, err
+		}
+		return &mockAvatar{vg: env.Generator}, nil
+	})
+
+	RegisterProductVideoGenerator("runwayml", func(env *ProviderEnv) (ProductVideoGenerator, error) {
+		if err := requireKey("runwayml", "RUNWAYML_API_KEY", env.Config.RunwayMLAPIKey); err != nil {
+			return nil, err
+		}
+		return &runwayProductVideo{vg: env.Generator}, nil
+	})
+	RegisterProductVideoGenerator("did", func(env *ProviderEnv) (ProductVideoGenerator, error) {
+		if err := requireKey("did", "DID_API_KEY", env.Config.DIDAPIKey); err != nil {
+			return nil, err
+		}
+		return &didProductVideo{vg: env.Generator}, nil
+	})
+	RegisterProductVideoGenerator("mock", func(env *ProviderEnv) (ProductVideoGenerator, error) {
+		if err := requireFFmpeg("mock"); err != nil {
+			return nil, err
+		}
+		return &mockProductVideo{vg: env.Generator}, nil
+	})
+
+	RegisterCompositor("shotstack", func(env *ProviderEnv) (Compositor, error) {
+		if err := requireKey("shotstack", "SHOTSTACK_API_KEY", env.Config.ShotstackAPIKey); err != nil {
+			return nil, err
+		}
+		if env.MediaHost == nil {
+			return nil, fmt.Errorf("shotstack fetches clips by URL and needs a media host")
+		}
+		return &shotstackCompositor{vg: env.Generator, host: env.MediaHost}, nil
+	})
+	RegisterCompositor("ffmpeg", func(env *ProviderEnv) (Compositor, error) {
+		if err := requireFFmpeg("ffmpeg"); err != nil {
+			return nil, err
+		}
+		return &ffmpegCompositor{vg: env.Generator}, nil
+	})
+
+	RegisterScriptWriter("gemini", func(env *ProviderEnv) (ScriptWriter, error) {
+		if env.Config.GeminiAPIKey == "" {
+			return nil, fmt.Errorf("Gemini API key is required. Please set GOOGLE_GEMINI_API_KEY in your environment variables")
+		}
+		return NewGeminiService(env.Config.GeminiAPIKey), nil
+	})
+
+	RegisterMediaHost("signed", func(env *ProviderEnv) (MediaHost, error) {
+		return &signedURLHost{vg: env.Generator}, nil
+	})
+	RegisterMediaHost("shotstack", func(env *ProviderEnv) (MediaHost, error) {
+		if err := requireKey("shotstack", "SHOTSTACK_API_KEY", env.Config.ShotstackAPIKey); err != nil {
+			return nil, err
+		}
+		return &shotstackIngestHost{vg: env.Generator}, nil
+	})
+}
+
+// didAvatar generates the talking presenter with D-ID
+type didAvatar struct{ vg *VideoGenerator }
+
+func (p *didAvatar) Name() string { return "did" }
+
+func (p *didAvatar) GenerateAvatar(req AvatarRequest) (string, error) {
+	return p.vg.generateAvatarOnly(req.PersonMediaPath, req.Script, req.Voice)
+}
+
+func (p *didAvatar) ResumeTask(taskID string) (string, error) {
+	return p.vg.pollDIDTask(taskID)
+}
+
+func (p *didAvatar) CancelTask(ctx context.Context, taskID string) error {
+	return p.vg.cancelDIDTask(ctx, taskID)
+}
+
+// synthesiaAvatar generates a stock Synthesia presenter
+type synthesiaAvatar struct{ vg *VideoGenerator }
+
+func (p *synthesiaAvatar) Name() string { return "synthesia" }
+
+func (p *synthesiaAvatar) GenerateAvatar(req AvatarRequest) (string, error) {
+	return p.vg.GenerateWithSynthesia(req.ProductImagePath, req.Script)
+}
+
+func (p *synthesiaAvatar) ResumeTask(taskID string) (string, error) {
+	return p.vg.pollSynthesiaTask(taskID)
+}
+
+func (p *synthesiaAvatar) CancelTask(ctx context.Context, taskID string) error {
+	return p.vg.cancelSynthesiaTask(ctx, taskID)
+}
+
+// runwayGen2Avatar drives the product image with the person media using RunwayML Gen-2
+type runwayGen2Avatar struct{ vg *VideoGenerator }
+
+func (p *runwayGen2Avatar) Name() string { return "runwayml_gen2" }
+
+func (p *runwayGen2Avatar) GenerateAvatar(req AvatarRequest) (string, error) {
+	return p.vg.GenerateWithRunwayML(req.ProductImagePath, req.PersonMediaPath, req.Script)
+}
+
+func (p *runwayGen2Avatar) ResumeTask(taskID string) (string, error) {
+	return p.vg.pollRunwayMLTask(taskID)
+}
+
+func (p *runwayGen2Avatar) CancelTask(ctx context.Context, taskID string) error {
+	return p.vg.cancelRunwayMLTask(ctx, taskID)
+}
+
+// mockAvatar renders presenter clips offline for development and CI without paid APIs.
+// With no compositor to follow it renders the complete video instead.
+type mockAvatar struct{ vg *VideoGenerator }
+
+func (p *mockAvatar) Name() string { return "mock" }
+
+func (p *mockAvatar) GenerateAvatar(req AvatarRequest) (string, error) {
+	if req.Standalone {
+		burn := req.Captions == "" || req.Captions == CaptionsBurned
+		return p.vg.renderMockVideo(req.ProductImagePath, req.PersonMediaPath, req.PersonMediaType, req.Script, req.ProductVideoStyle, burn)
+	}
+	return p.vg.renderMockPresenter(req.PersonMediaPath, req.PersonMediaType, req.Script)
+}
+
+// runwayProductVideo animates the product with RunwayML Gen-3
+type runwayProductVideo struct{ vg *VideoGenerator }
+
+func (p *runwayProductVideo) Name() string { return "runwayml" }
+
+func (p *runwayProductVideo) GenerateProductVideo(req ProductVideoRequest) (string, error) {
+	return p.vg.generateProductVideoWithRunwayML(req.ProductImagePath, req.Style, req.AspectRatio)
+}
+
+func (p *runwayProductVideo) ResumeTask(taskID string) (string, error) {
+	return p.vg.pollRunwayMLTask(taskID)
+}
+
+func (p *runwayProductVideo) CancelTask(ctx context.Context, taskID string) error {
+	return p.vg.cancelRunwayMLTask(ctx, taskID)
+}
+
+// didProductVideo presents the product image as a D-ID talk
+type didProductVideo struct{ vg *VideoGenerator }
+
+func (p *didProductVideo) Name() string { return "did" }
+
+func (p *didProductVideo) GenerateProductVideo(req ProductVideoRequest) (string, error) {
+	return p.vg.generateProductVideoWithDID(req.ProductImagePath, req.Style)
+}
+
+func (p *didProductVideo) ResumeTask(taskID string) (string, error) {
+	return p.vg.pollDIDTask(taskID)
+}
+
+func (p *didProductVideo) CancelTask(ctx context.Context, taskID string) error {
+	return p.vg.cancelDIDTask(ctx, taskID)
+}
+
+// mockProductVideo renders a Ken Burns clip of the product image offline
+type mockProductVideo struct{ vg *VideoGenerator }
+
+func (p *mockProductVideo) Name() string { return "mock" }
+
+func (p *mockProductVideo) GenerateProductVideo(req ProductVideoRequest) (string, error) {
+	return p.vg.renderKenBurnsClip(req.ProductImagePath, req.Style)
+}
+
+// shotstackCompositor renders the layout remotely with Shotstack.
+// Shotstack has no API to stop a render, so it is not a TaskCanceller.
+type shotstackCompositor struct {
+	vg   *VideoGenerator
+	host MediaHost
+}
+
+func (p *shotstackCompositor) Name() string { return "shotstack" }
+
+// Shotstack cannot target a loudness, so its renders are normalised afterwards
+func (p *shotstackCompositor) Composite(req CompositeRequest) (string, error) {
+	return p.normalized(p.vg.compositeWithShotstack(req.ProductVideoPath, req.AvatarVideoPath, req.Layout, req.Timing, req.AspectRatio, req.Captions, req.Music, req.Brand, p.host))
+}
+
+func (p *shotstackCompositor) ResumeTask(taskID string) (string, error) {
+	return p.normalized(p.vg.pollShotstackRender(taskID))
+}
+
+func (p *shotstackCompositor) normalized(videoPath string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	if err := p.vg.normalizeLoudness(videoPath); err != nil {
+		return "", err
+	}
+	if _, err := p.vg.storeVideo(videoPath); err != nil {
+		return "", err
+	}
+	return videoPath, nil
+}
+
+// ffmpegCompositor renders the layout locally with ffmpeg, so clips never leave the server
+type ffmpegCompositor struct{ vg *VideoGenerator }
+
+func (p *ffmpegCompositor) Name() string { return "ffmpeg" }
+
+func (p *ffmpegCompositor) Composite(req CompositeRequest) (string, error) {
+	return p.vg.compositeWithFFmpeg(req.ProductVideoPath, req.AvatarVideoPath, req.Layout, req.Timing, req.AspectRatio, req.Captions, req.Music, req.Brand)
+}
+
+// signedURLHost hands out this server's own signed, time-limited URLs, so media
+// never leaves our storage except to the vendor fetching it
+type signedURLHost struct{ vg *VideoGenerator }
+
+func (h *signedURLHost) Name() string { return "signed" }
+
+func (h *signedURLHost) Host(filePath string) (string, error) {
+	url, err := h.vg.mediaURL(filePath)
+	if err == nil {
+		h.vg.reportUpload("signed", filePath, url)
+	}
+	return url, err
+}
+
+// shotstackIngestHost uploads files to Shotstack's asset storage
+type shotstackIngestHost struct{ vg *VideoGenerator }
+
+func (h *shotstackIngestHost) Name() string { return "shotstack" }
+
+func (h *shotstackIngestHost) Host(filePath string) (string, error) {
+	url, err := h.vg.uploadToShotstack(filePath)
+	if err == nil {
+		h.vg.reportUpload("shotstack", filePath, url)
+	}
+	return url, err
+}
+
+// requireKey fails a provider whose credential was not found in any secret source
+func requireKey(provider, name, value string) error {
+	if value == "" {
+		return fmt.Errorf("the %s provider needs %s, which no secret source provides", provider, name)
+	}
+	return nil
+}
+
+// requireFFmpeg reports an error when a provider that renders locally cannot find ffmpeg
+func requireFFmpeg(provider string) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("the %s provider renders locally and needs ffmpeg installed: %v", provider, err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dealshare/hacathon/backend/internal/config"
+)
+
+func TestDefaultProviderSelection(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want ProviderSelection
+	}{
+		{"mock", config.Config{AIProvider: "mock"}, ProviderSelection{Avatar: "mock", ScriptWriter: "gemini", MediaHost: "signed"}},
+		{"unknown provider is mock", config.Config{AIProvider: "heygen"}, ProviderSelection{Avatar: "mock", ScriptWriter: "gemini", MediaHost: "signed"}},
+		{"d-id alone", config.Config{AIProvider: "did"}, ProviderSelection{Avatar: "did", ScriptWriter: "gemini", MediaHost: "signed"}},
+		{"full pipeline", config.Config{AIProvider: "did", UseFullAIPipeline: true},
+			ProviderSelection{Avatar: "did", ProductVideo: "runwayml", Compositor: "shotstack", ScriptWriter: "gemini", MediaHost: "signed"}},
+		{"full pipeline needs d-id", config.Config{AIProvider: "runwayml", UseFullAIPipeline: true},
+			ProviderSelection{Avatar: "runwayml_gen2", ScriptWriter: "gemini", MediaHost: "signed"}},
+		{"synthesia", config.Config{AIProvider: "synthesia"}, ProviderSelection{Avatar: "synthesia", ScriptWriter: "gemini", MediaHost: "signed"}},
+		{"per-role settings win", config.Config{AIProvider: "did", UseFullAIPipeline: true, AvatarProvider: "mock", MediaHostProvider: "shotstack"},
+			ProviderSelection{Avatar: "mock", ProductVideo: "runwayml", Compositor: "shotstack", ScriptWriter: "gemini", MediaHost: "shotstack"}},
+		{"none clears a role", config.Config{AIProvider: "did", UseFullAIPipeline: true, ProductVideoProvider: "none", CompositorProvider: "none"},
+			ProviderSelection{Avatar: "did", ScriptWriter: "gemini", MediaHost: "signed"}},
+	}
+	for _, tt := range tests {
+		if got := DefaultProviderSelection(&tt.cfg); got != tt.want {
+			t.Errorf("%s: DefaultProviderSelection() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProviderSelectionMerge(t *testing.T) {
+	base := ProviderSelection{Avatar: "did", ProductVideo: "runwayml", Compositor: "shotstack", ScriptWriter: "gemini", MediaHost: "signed"}
+
+	if got := base.Merge(ProviderSelection{}); got != base {
+		t.Errorf("empty override changed the selection to %+v", got)
+	}
+	got := base.Merge(ProviderSelection{Avatar: "synthesia", ProductVideo: none, Compositor: none})
+	want := ProviderSelection{Avatar: "synthesia", ScriptWriter: "gemini", MediaHost: "signed"}
+	if got != want {
+		t.Errorf("Merge() = %+v, want %+v", got, want)
+	}
+}
+
+func TestProviderSelectionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sel     ProviderSelection
+		wantErr string // Empty when the selection is valid
+	}{
+		{"avatar only", ProviderSelection{Avatar: "mock"}, ""},
+		{"full pipeline", ProviderSelection{Avatar: "did", ProductVideo: "runwayml", Compositor: "shotstack", ScriptWriter: "gemini", MediaHost: "shotstack"}, ""},
+		{"no avatar", ProviderSelection{ScriptWriter: "gemini"}, "avatar provider is required"},
+		{"unknown avatar", ProviderSelection{Avatar: "heygen"}, `unknown avatar provider "heygen"`},
+		{"unknown product video", ProviderSelection{Avatar: "did", ProductVideo: "pika", Compositor: "shotstack"}, "unknown product video provider"},
+		{"unknown compositor", ProviderSelection{Avatar: "did", ProductVideo: "runwayml", Compositor: "premiere"}, "unknown compositor provider"},
+		{"unknown script writer", ProviderSelection{Avatar: "did", ScriptWriter: "gpt"}, "unknown script writer provider"},
+		{"unknown media host", ProviderSelection{Avatar: "did", MediaHost: "dropbox"}, "unknown media host provider"},
+		{"product video without compositor", ProviderSelection{Avatar: "did", ProductVideo: "runwayml"}, "selected together"},
+		{"compositor without product video", ProviderSelection{Avatar: "did", Compositor: "shotstack"}, "selected together"},
+	}
+	for _, tt := range tests {
+		err := tt.sel.Validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: Validate() error = %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: Validate() error = %v, want one mentioning %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+type fakeAvatar struct{}
+
+func (fakeAvatar) Name() string                                 { return "test_avatar" }
+func (fakeAvatar) GenerateAvatar(AvatarRequest) (string, error) { return "avatar.mp4", nil }
+
+type fakeProductVideo struct{}
+
+func (fakeProductVideo) Name() string { return "test_product" }
+func (fakeProductVideo) GenerateProductVideo(ProductVideoRequest) (string, error) {
+	return "product.mp4", nil
+}
+
+// fakeCompositor remembers the media host it was built with
+type fakeCompositor struct{ host MediaHost }
+
+func (fakeCompositor) Name() string                               { return "test_compositor" }
+func (fakeCompositor) Composite(CompositeRequest) (string, error) { return "final.mp4", nil }
+
+type fakeHost struct{}
+
+func (fakeHost) Name() string                { return "test_host" }
+func (fakeHost) Host(string) (string, error) { return "https://cdn.example.com/file", nil }
+
+func registerFakeProviders() {
+	RegisterAvatarGenerator("test_avatar", func(env *ProviderEnv) (AvatarGenerator, error) { return fakeAvatar{}, nil })
+	RegisterAvatarGenerator("test_broken", func(env *ProviderEnv) (AvatarGenerator, error) {
+		return nil, errors.New("no API key")
+	})
+	RegisterProductVideoGenerator("test_product", func(env *ProviderEnv) (ProductVideoGenerator, error) {
+		return fakeProductVideo{}, nil
+	})
+	RegisterCompositor("test_compositor", func(env *ProviderEnv) (Compositor, error) {
+		return fakeCompositor{host: env.MediaHost}, nil
+	})
+	RegisterMediaHost("test_host", func(env *ProviderEnv) (MediaHost, error) { return fakeHost{}, nil })
+}
+
+func TestBuildPipeline(t *testing.T) {
+	registerFakeProviders()
+	cfg := &config.Config{}
+	vg := NewVideoGenerator(cfg)
+
+	full := ProviderSelection{Avatar: "test_avatar", ProductVideo: "test_product", Compositor: "test_compositor", MediaHost: "test_host"}
+	pipeline, err := buildPipeline(cfg, vg, full)
+	if err != nil {
+		t.Fatalf("buildPipeline() error = %v", err)
+	}
+	if pipeline.Avatar.Name() != "test_avatar" || pipeline.ProductVideo.Name() != "test_product" {
+		t.Errorf("pipeline providers = %s, %s", pipeline.Avatar.Name(), pipeline.ProductVideo.Name())
+	}
+	if compositor, ok := pipeline.Compositor.(fakeCompositor); !ok || compositor.host == nil || compositor.host.Name() != "test_host" {
+		t.Errorf("compositor was not built with the selected media host: %+v", pipeline.Compositor)
+	}
+
+	avatarOnly, err := buildPipeline(cfg, vg, ProviderSelection{Avatar: "test_avatar"})
+	if err != nil {
+		t.Fatalf("buildPipeline(avatar only) error = %v", err)
+	}
+	if avatarOnly.ProductVideo != nil || avatarOnly.Compositor != nil {
+		t.Errorf("avatar-only pipeline has a product video or compositor")
+	}
+
+	if _, err := buildPipeline(cfg, vg, ProviderSelection{Avatar: "test_broken"}); err == nil || !strings.Contains(err.Error(), "no API key") {
+		t.Errorf("buildPipeline(broken avatar) error = %v, want the factory's error", err)
+	}
+	if _, err := buildPipeline(cfg, vg, ProviderSelection{Avatar: "test_avatar", Compositor: "test_compositor"}); err == nil {
+		t.Errorf("buildPipeline() accepted an invalid selection")
+	}
+}
+
+func TestBuildCompositePipeline(t *testing.T) {
+	registerFakeProviders()
+	cfg := &config.Config{}
+	vg := NewVideoGenerator(cfg)
+
+	sel := ProviderSelection{Avatar: "test_broken", ProductVideo: "test_product", Compositor: "test_compositor", MediaHost: "test_host"}
+	pipeline, err := buildCompositePipeline(cfg, vg, sel)
+	if err != nil {
+		t.Fatalf("buildCompositePipeline() error = %v, want the avatar provider left unbuilt", err)
+	}
+	if pipeline.Avatar != nil || pipeline.ProductVideo != nil {
+		t.Errorf("composite pipeline built clip providers: %+v", pipeline)
+	}
+	if compositor, ok := pipeline.Compositor.(fakeCompositor); !ok || compositor.host == nil {
+		t.Errorf("compositor was not built with the selected media host: %+v", pipeline.Compositor)
+	}
+
+	if _, err := buildCompositePipeline(cfg, vg, ProviderSelection{Avatar: "test_avatar"}); err == nil || !strings.Contains(err.Error(), "no compositor") {
+		t.Errorf("buildCompositePipeline() without a compositor error = %v", err)
+	}
+}
+
+func TestTimingValidate(t *testing.T) {
+	tests := []struct {
+		timing  Timing
+		wantErr bool
+	}{
+		{Timing{}, false},
+		{Timing{Duration: 30, AvatarStart: 5}, false},
+		{Timing{AvatarStart: 14.5}, false}, // Within the default 15 seconds
+		{Timing{AvatarStart: 15}, true},
+		{Timing{Duration: 2}, true},
+		{Timing{Duration: 61}, true},
+		{Timing{Duration: 10, AvatarStart: 12}, true},
+		{Timing{AvatarStart: -1}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.timing.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.Validate() error = %v, wantErr %v", tt.timing, err, tt.wantErr)
+		}
+	}
+	if !ValidLayout("split") || ValidLayout("mosaic") {
+		t.Errorf("ValidLayout() does not match the compositor layouts %v", Layouts())
+	}
+}
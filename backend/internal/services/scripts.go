@@ -0,0 +1,133 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Script variant options
+var (
+	ScriptTones     = []string{"professional", "emotional", "benefits", "hype"}
+	ScriptDurations = []int{6, 15, 30, 60} // Seconds
+)
+
+const (
+	defaultScriptTone     = "professional"
+	defaultScriptDuration = 15
+	defaultScriptLanguage = "en"
+	defaultScriptVariants = 3
+	maxScriptVariants     = 5
+	maxScriptAudience     = 200 // Characters
+	scriptWordsPerSecond  = 2.5 // About 150 words a minute, read aloud
+)
+
+// scriptToneGuides tell the script writer what each tone sounds like
+var scriptToneGuides = map[string]string{
+	"professional": "confident, polished and trustworthy; clear facts, no slang",
+	"emotional":    "warm and heartfelt; speak to how the product makes the viewer feel",
+	"benefits":     "practical; lead with what the viewer gains from the product rather than its specs",
+	"hype":         "high-energy and punchy, with short exclamatory sentences and a bold hook",
+}
+
+// ScriptLanguage is a language scripts can be written in, and the Microsoft voice
+// the presenter reads them with
+type ScriptLanguage struct {
+	Code  string `json:"code"`
+	Name  string `json:"name"`
+	Voice string `json:"voice"`
+}
+
+// ScriptLanguages are the languages scripts can be written in
+var ScriptLanguages = []ScriptLanguage{
+	{Code: "en", Name: "English", Voice: "en-US-GuyNeural"},
+	{Code: "es", Name: "Spanish", Voice: "es-ES-AlvaroNeural"},
+	{Code: "fr", Name: "French", Voice: "fr-FR-HenriNeural"},
+	{Code: "de", Name: "German", Voice: "de-DE-ConradNeural"},
+	{Code: "it", Name: "Italian", Voice: "it-IT-DiegoNeural"},
+	{Code: "pt", Name: "Portuguese", Voice: "pt-BR-AntonioNeural"},
+	{Code: "hi", Name: "Hindi", Voice: "hi-IN-MadhurNeural"},
+	{Code: "ja", Name: "Japanese", Voice: "ja-JP-KeitaNeural"},
+}
+
+// ScriptOptions describe the script variants to write
+type ScriptOptions struct {
+	Tone     string `json:"tone,omitempty"`     // One of ScriptTones; default "professional"
+	Duration int    `json:"duration,omitempty"` // Seconds read aloud, one of ScriptDurations; default 15
+	Audience string `json:"audience,omitempty"` // Who the video is for, e.g. "new parents"; optional
+	Language string `json:"language,omitempty"` // Code of one of ScriptLanguages; default "en"
+	Variants int    `json:"variants,omitempty"` // How many to write, 1 to 5; default 3
+}
+
+// WithDefaults fills in unset options and checks the rest
+func (o ScriptOptions) WithDefaults() (ScriptOptions, error) {
+	o.Tone = strings.ToLower(strings.TrimSpace(o.Tone))
+	if o.Tone == "" {
+		o.Tone = defaultScriptTone
+	}
+	if _, ok := scriptToneGuides[o.Tone]; !ok {
+		return o, fmt.Errorf("unknown tone %q (available: %s)", o.Tone, strings.Join(ScriptTones, ", "))
+	}
+
+	if o.Duration == 0 {
+		o.Duration = defaultScriptDuration
+	}
+	valid := false
+	for _, duration := range ScriptDurations {
+		valid = valid || duration == o.Duration
+	}
+	if !valid {
+		durations := make([]string, len(ScriptDurations))
+		for i, duration := range ScriptDurations {
+			durations[i] = fmt.Sprint(duration)
+		}
+		return o, fmt.Errorf("duration must be one of %s seconds", strings.Join(durations, ", "))
+	}
+
+	o.Audience = strings.TrimSpace(o.Audience)
+	if len([]rune(o.Audience)) > maxScriptAudience {
+		return o, fmt.Errorf("audience must be at most %d characters", maxScriptAudience)
+	}
+
+	o.Language = strings.ToLower(strings.TrimSpace(o.Language))
+	if o.Language == "" {
+		o.Language = defaultScriptLanguage
+	}
+	if _, ok := scriptLanguage(o.Language); !ok {
+		codes := make([]string, len(ScriptLanguages))
+		for i, language := range ScriptLanguages {
+			codes[i] = language.Code
+		}
+		return o, fmt.Errorf("unknown language %q (available: %s)", o.Language, strings.Join(codes, ", "))
+	}
+
+	if o.Variants == 0 {
+		o.Variants = defaultScriptVariants
+	}
+	if o.Variants < 1 || o.Variants > maxScriptVariants {
+		return o, fmt.Errorf("variants must be between 1 and %d", maxScriptVariants)
+	}
+	return o, nil
+}
+
+// targetWords is about how many English words are read aloud in the duration
+func (o ScriptOptions) targetWords() int {
+	return int(float64(o.Duration) * scriptWordsPerSecond)
+}
+
+func scriptLanguage(code string) (ScriptLanguage, bool) {
+	for _, language := range ScriptLanguages {
+		if language.Code == code {
+			return language, true
+		}
+	}
+	return ScriptLanguage{}, false
+}
+
+// ScriptVoice is the presenter's voice for a script in the language; unknown and
+// empty codes get the English voice
+func ScriptVoice(code string) string {
+	if language, ok := scriptLanguage(code); ok {
+		return language.Voice
+	}
+	return ScriptLanguages[0].Voice
+}
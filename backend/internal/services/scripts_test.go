@@ -0,0 +1,68 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScriptOptionsWithDefaults(t *testing.T) {
+	got, err := ScriptOptions{}.WithDefaults()
+	want := ScriptOptions{Tone: "professional", Duration: 15, Language: "en", Variants: 3}
+	if err != nil || got != want {
+		t.Errorf("WithDefaults() = %+v, %v, want %+v", got, err, want)
+	}
+
+	got, err = ScriptOptions{Tone: " Hype ", Duration: 6, Audience: "  new parents ", Language: "ES", Variants: 5}.WithDefaults()
+	want = ScriptOptions{Tone: "hype", Duration: 6, Audience: "new parents", Language: "es", Variants: 5}
+	if err != nil || got != want {
+		t.Errorf("WithDefaults() = %+v, %v, want %+v", got, err, want)
+	}
+
+	tests := []struct {
+		opts    ScriptOptions
+		wantErr string
+	}{
+		{ScriptOptions{Tone: "sarcastic"}, "unknown tone"},
+		{ScriptOptions{Duration: 20}, "duration must be one of 6, 15, 30, 60"},
+		{ScriptOptions{Duration: -15}, "duration"},
+		{ScriptOptions{Audience: strings.Repeat("é", maxScriptAudience+1)}, "audience"},
+		{ScriptOptions{Language: "klingon"}, "unknown language"},
+		{ScriptOptions{Variants: 6}, "variants"},
+		{ScriptOptions{Variants: -1}, "variants"},
+	}
+	for _, tt := range tests {
+		if _, err := tt.opts.WithDefaults(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%+v.WithDefaults() error = %v, want one mentioning %q", tt.opts, err, tt.wantErr)
+		}
+	}
+
+	// Counted in characters, so a full-length audience in any script is accepted
+	if _, err := (ScriptOptions{Audience: strings.Repeat("é", maxScriptAudience)}).WithDefaults(); err != nil {
+		t.Errorf("WithDefaults() rejected a %d-character audience: %v", maxScriptAudience, err)
+	}
+}
+
+func TestScriptVoice(t *testing.T) {
+	tests := map[string]string{
+		"en":  "en-US-GuyNeural",
+		"hi":  "hi-IN-MadhurNeural",
+		"ja":  "ja-JP-KeitaNeural",
+		"":    "en-US-GuyNeural",
+		"xx":  "en-US-GuyNeural",
+		"ES":  "en-US-GuyNeural", // Codes are stored lower case
+		"pt ": "en-US-GuyNeural",
+	}
+	for code, want := range tests {
+		if got := ScriptVoice(code); got != want {
+			t.Errorf("ScriptVoice(%q) = %s, want %s", code, got, want)
+		}
+	}
+}
+
+func TestScriptTargetWords(t *testing.T) {
+	for duration, want := range map[int]int{6: 15, 15: 37, 30: 75, 60: 150} {
+		if got := (ScriptOptions{Duration: duration}).targetWords(); got != want {
+			t.Errorf("targetWords() for %d seconds = %d, want %d", duration, got, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dealshare/hacathon/backend/internal/storage"
+)
+
+// Providers write videos to GeneratedVideoPath, which serves as the working
+// directory. Every finished video is stored under videos/<file name> before its
+// path is returned, so the pipeline can refer to it by key.
+
+// storeVideo saves a finished video from the working directory to blob storage
+func (vg *VideoGenerator) storeVideo(localPath string) (string, error) {
+	key := videoKey(localPath)
+	if err := storage.PutFile(vg.context(), vg.storage, key, localPath); err != nil {
+		return "", fmt.Errorf("failed to store %s: %v", filepath.Base(localPath), err)
+	}
+	return key, nil
+}
+
+// localFile returns a local path for a stored key, downloading it if needed
+func (vg *VideoGenerator) localFile(ref string) (string, error) {
+	return storage.Fetch(vg.context(), vg.storage, ref, vg.config.StorageCacheDir)
+}
+
+// videoKey is the key a video from the working directory is stored under
+func videoKey(localPath string) string {
+	return storage.VideoKey(filepath.Base(localPath))
+}
+
+// storageKey finds the key of a local media file: an upload, a cached copy or a
+// finished video in the working directory
+func (vg *VideoGenerator) storageKey(localPath string) (string, error) {
+	if key, ok := storage.KeyFor(vg.storage, localPath, vg.config.StorageCacheDir); ok {
+		return key, nil
+	}
+	dir, _ := filepath.Abs(filepath.Dir(localPath))
+	workDir, _ := filepath.Abs(vg.config.GeneratedVideoPath)
+	if dir == workDir {
+		return videoKey(localPath), nil
+	}
+	return "", fmt.Errorf("%s is not in storage", filepath.Base(localPath))
+}
+
+// mediaURL returns a signed, time-limited URL vendors can download a local media file from
+func (vg *VideoGenerator) mediaURL(localPath string) (string, error) {
+	key, err := vg.storageKey(localPath)
+	if err != nil {
+		return "", err
+	}
+	return vg.signer.URL(key, vg.signer.TTL())
+}
+
+// saveWebsite stores a website's files under websites/<id>/
+func saveWebsite(store storage.Storage, websiteKey string, files map[string]string) error {
+	for name, content := range files {
+		key := websiteKey + "/" + name
+		if err := store.Put(context.Background(), key, strings.NewReader(content), int64(len(content)), storage.ContentType(name)); err != nil {
+			return fmt.Errorf("failed to write %s: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// Cover candidates taken from every final render
+const (
+	thumbnailFrames = 6    // Frames offered besides the title card
+	sceneThreshold  = 0.3  // How much a frame must differ from the one before to start a new scene
+	thumbnailMinGap = 1.0  // Seconds between two frames offered as covers
+	thumbnailEdge   = 0.5  // Seconds at either end of the video no frame is taken from
+	titleCardTint   = 0.55 // Opacity of the brand's colour over the title card's frame
+)
+
+// thumbnails offers cover images for a final render: frames where its scenes
+// change (topped up with evenly spaced ones) and a title card with the brand's
+// logo and the product's name. They are reported as thumbnail assets made from
+// the render. Covers are an extra, so failures are only logged.
+func (p *Pipeline) thumbnails(req VideoRequest, videoPath, ratio string, brand Branding) {
+	vg := p.generator
+	var parents []string
+	if key, err := vg.storageKey(videoPath); err == nil {
+		parents = []string{key}
+	}
+
+	duration, err := vg.getVideoDuration(videoPath)
+	if err != nil || duration <= 0 {
+		duration = req.Timing.length()
+		fmt.Printf("⚠️  Could not read the render's length, assuming %.0fs for thumbnails\n", duration)
+	}
+
+	scenes, err := vg.sceneChanges(videoPath)
+	if err != nil {
+		fmt.Printf("⚠️  Scene detection failed: %v, using evenly spaced frames\n", err)
+	}
+	times := thumbnailTimes(scenes, duration)
+
+	os.MkdirAll(vg.config.GeneratedVideoPath, 0755)
+	store := func(path string, seconds *float64) {
+		key, err := vg.storeVideo(path)
+		if err != nil {
+			fmt.Printf("⚠️  Failed to save thumbnail: %v\n", err)
+			return
+		}
+		vg.reportArtifact(Artifact{Stage: StageComposite, Kind: models.AssetKindThumbnail, Key: key, Parents: parents, AspectRatio: ratio, FrameSeconds: seconds})
+	}
+
+	// The title card comes first: it is the job's default cover
+	cardPath := filepath.Join(vg.config.GeneratedVideoPath, fmt.Sprintf("cover-%s.jpg", uuid.New().String()))
+	if err := vg.titleCard(videoPath, duration/2, req.Title, brand, cardPath); err != nil {
+		fmt.Printf("⚠️  Failed to make the title card: %v\n", err)
+	} else {
+		store(cardPath, nil)
+	}
+
+	count := 0
+	for _, seconds := range times {
+		framePath := filepath.Join(vg.config.GeneratedVideoPath, fmt.Sprintf("thumb-%s.jpg", uuid.New().String()))
+		args := []string{"-y", "-ss", formatSeconds(seconds), "-i", videoPath, "-frames:v", "1", "-q:v", "2", framePath}
+		if err := vg.runFFmpeg(args, framePath); err != nil {
+			fmt.Printf("⚠️  Failed to take a thumbnail at %.1fs: %v\n", seconds, err)
+			continue
+		}
+		store(framePath, &seconds)
+		count++
+	}
+	fmt.Printf("🖼️  %d thumbnails and a title card offered as covers\n", count)
+}
+
+// thumbnailTimes picks up to thumbnailFrames moments to take covers at: scene
+// changes first, spread over the video if there are too many, then evenly
+// spaced moments that are not too close to them
+func thumbnailTimes(scenes []float64, duration float64) []float64 {
+	var candidates []float64
+	for _, t := range scenes {
+		if t >= thumbnailEdge && t <= duration-thumbnailEdge {
+			candidates = append(candidates, t)
+		}
+	}
+	if len(candidates) > thumbnailFrames {
+		step := float64(len(candidates)) / thumbnailFrames
+		spread := make([]float64, thumbnailFrames)
+		for i := range spread {
+			spread[i] = candidates[int(float64(i)*step)]
+		}
+		candidates = spread
+	}
+
+	for i := 1; i <= thumbnailFrames && len(candidates) < thumbnailFrames; i++ {
+		t := duration * float64(i) / (thumbnailFrames + 1)
+		near := false
+		for _, c := range candidates {
+			near = near || math.Abs(c-t) < thumbnailMinGap
+		}
+		if !near {
+			candidates = append(candidates, t)
+		}
+	}
+	sort.Float64s(candidates)
+	return candidates
+}
+
+// sceneChanges returns the times, in seconds, at which the video cuts to a new scene
+func (vg *VideoGenerator) sceneChanges(videoPath string) ([]float64, error) {
+	cmd := exec.CommandContext(vg.context(), "ffmpeg",
+		"-i", videoPath,
+		"-an",
+		"-vf", fmt.Sprintf("select='gt(scene,%g)',metadata=print:file=-", sceneThreshold),
+		"-f", "null", "-",
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v\n%s", err, tail(stderr.String(), 5))
+	}
+
+	// metadata prints "frame:12   pts:6144    pts_time:0.4" before each selected frame's tags
+	var times []float64
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		for _, field := range strings.Fields(scanner.Text()) {
+			if value, ok := strings.CutPrefix(field, "pts_time:"); ok {
+				if t, err := strconv.ParseFloat(value, 64); err == nil {
+					times = append(times, t)
+				}
+			}
+		}
+	}
+	return times, nil
+}
+
+// titleCard draws the product's name and the brand's logo over a frame of the
+// video tinted with the brand's colour
+func (vg *VideoGenerator) titleCard(videoPath string, at float64, title string, brand Branding, outputPath string) error {
+	color := brand.Color
+	if color == "" {
+		color = defaultPrimaryColor
+	}
+	frame := vg.videoDimensions(videoPath)
+	short := min(frame[0], frame[1])
+
+	args := []string{"-y", "-ss", formatSeconds(at), "-i", videoPath}
+	graph := []string{fmt.Sprintf("[0:v]drawbox=c=%s@%.2f:t=fill[bg]", strings.Replace(color, "#", "0x", 1), titleCardTint)}
+	current := "bg"
+
+	if brand.Logo != "" {
+		args = append(args, "-i", brand.Logo)
+		graph = append(graph,
+			fmt.Sprintf("[1:v]scale=%d:-2[cardlogo]", even(float64(short)*endCardLogoScale)),
+			fmt.Sprintf("[%s][cardlogo]overlay=x=(main_w-overlay_w)/2:y=main_h/3-overlay_h/2[logoed]", current),
+		)
+		current = "logoed"
+	}
+
+	if title = strings.TrimSpace(title); title != "" {
+		textDir, err := os.MkdirTemp("", "cover-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(textDir)
+		textPath := filepath.Join(textDir, "title.txt")
+		if err := os.WriteFile(textPath, []byte(title), 0644); err != nil {
+			return err
+		}
+		font := ""
+		if vg.config.CaptionFontPath != "" {
+			font = fmt.Sprintf(":fontfile='%s'", vg.config.CaptionFontPath)
+		}
+		// Long names are shrunk to fit the width; a glyph is about half as wide as it is tall
+		size := min(short/9, int(float64(frame[0])*0.9/(0.55*float64(len([]rune(title))))))
+		y := "(h-th)/2"
+		if brand.Logo != "" {
+			y = "h*2/3-th/2"
+		}
+		graph = append(graph, fmt.Sprintf("[%s]drawtext=textfile='%s'%s:fontsize=%d:fontcolor=white:shadowcolor=black@0.5:shadowx=2:shadowy=2:x=(w-tw)/2:y=%s[titled]",
+			current, textPath, font, size, y))
+		current = "titled"
+	}
+
+	args = append(args,
+		"-filter_complex", strings.Join(graph, ";"),
+		"-map", "["+current+"]",
+		"-frames:v", "1", "-q:v", "2",
+		outputPath,
+	)
+	return vg.runFFmpeg(args, outputPath)
+}
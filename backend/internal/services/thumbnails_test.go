@@ -0,0 +1,26 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestThumbnailTimes(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenes   []float64
+		duration float64
+		want     []float64
+	}{
+		{"no scene changes", nil, 14, []float64{2, 4, 6, 8, 10, 12}},
+		{"scene changes at the edges are skipped", []float64{0.2, 3, 13.8}, 14, []float64{2, 3, 4, 6, 8, 10}},
+		{"evenly spaced frames keep clear of scene changes", []float64{2.5, 5.9}, 14, []float64{2.5, 4, 5.9, 8, 10, 12}},
+		{"many scene changes are spread out", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, 14, []float64{1, 3, 5, 7, 9, 11}},
+		{"short video offers fewer frames", nil, 3.5, []float64{0.5, 1.5, 2.5}},
+	}
+	for _, tt := range tests {
+		if got := thumbnailTimes(tt.scenes, tt.duration); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: thumbnailTimes() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
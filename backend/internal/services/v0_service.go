@@ -3,41 +3,46 @@ package services
 import (
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
 	"time"
+
+	"github.com/dealshare/hacathon/backend/internal/storage"
 )
 
 // V0Service handles integration with v0.dev by Vercel for website generation
 type V0Service struct {
-	apiKey string
-	client *http.Client
+	apiKey  string
+	client  *http.Client
+	storage storage.Storage
 }
 
-// NewV0Service creates a new v0.dev service
-func NewV0Service(apiKey string) *V0Service {
+// NewV0Service creates a new v0.dev service that saves websites to store
+func NewV0Service(apiKey string, store storage.Storage) *V0Service {
 	return &V0Service{
-		apiKey: apiKey,
+		apiKey:  apiKey,
+		storage: store,
 		client: &http.Client{
 			Timeout: 60 * time.Second,
 		},
 	}
 }
 
-// GenerateWebsite generates a Next.js website using v0.dev
+// GenerateWebsite generates a Next.js website using v0.dev and stores it under websiteKey
 // Note: v0.dev doesn't have a public API yet, so this uses Vercel AI SDK approach
-func (v *V0Service) GenerateWebsite(productName, productDescription, productPrice, productImageURL, videoURL string, features []map[string]string) (string, error) {
+// The theme sets the pages' colours, fonts and logo; posterURL and ogImageURL are
+// the video's poster and the absolute URL of the link preview image, and streamURL
+// its HLS playlist (empty to play the MP4 only).
+func (v *V0Service) GenerateWebsite(websiteKey, productName, productDescription, productPrice, productImageURL, videoURL, streamURL, subtitlesURL, posterURL, ogImageURL string, features []map[string]string, theme Theme) (string, error) {
 	fmt.Printf("\n🌐 Generating website with v0.dev approach...\n")
 
 	// Since v0.dev doesn't have public API, we generate modern HTML/CSS/JS
 	// This simulates what v0.dev does internally with modern design patterns
 	
 	// Generate the code with actual product image and video
-	html, css, js := v.generateModernWebsite(productName, productDescription, productPrice, productImageURL, videoURL, features)
+	html, css, js := v.generateModernWebsite(productName, productDescription, productPrice, productImageURL, videoURL, streamURL, subtitlesURL, posterURL, ogImageURL, features, theme)
 
 	fmt.Printf("✅ Website generated successfully!\n")
 
-	return v.saveWebsiteFiles(html, css, js)
+	return v.saveWebsiteFiles(websiteKey, html, css, js)
 }
 
 // buildWebsitePrompt creates a prompt for website generation
@@ -49,7 +54,7 @@ Description: %s
 Price: %s
 
 Style: Modern gradient design with:
-- Hero section with gradient background (purple to blue)
+- Hero section with gradient background in the brand's colours
 - Product showcase with image
 - Features grid (4 features with icons)
 - Video section
@@ -63,12 +68,12 @@ Framework: HTML5 with modern JavaScript`, productName, productDescription, produ
 }
 
 // generateModernWebsite creates a beautiful modern website
-func (v *V0Service) generateModernWebsite(productName, productDescription, productPrice, productImageURL, videoURL string, features []map[string]string) (string, string, string) {
+func (v *V0Service) generateModernWebsite(productName, productDescription, productPrice, productImageURL, videoURL, streamURL, subtitlesURL, posterURL, ogImageURL string, features []map[string]string, theme Theme) (string, string, string) {
 	// Generate enhanced HTML with actual product image and video
-	html := v.generateEnhancedHTML(productName, productDescription, productPrice, productImageURL, videoURL, features)
+	html := v.generateEnhancedHTML(productName, productDescription, productPrice, productImageURL, videoURL, streamURL, subtitlesURL, posterURL, ogImageURL, features, theme)
 	
 	// Generate modern CSS with animations
-	css := v.generateModernCSS()
+	css := v.generateModernCSS(theme)
 	
 	// Generate interactive JavaScript
 	js := v.generateInteractiveJS()
@@ -76,8 +81,9 @@ func (v *V0Service) generateModernWebsite(productName, productDescription, produ
 	return html, css, js
 }
 
-// generateEnhancedHTML creates beautiful HTML with v0.dev style
-func (v *V0Service) generateEnhancedHTML(productName, productDescription, productPrice, productImageURL, videoURL string, features []map[string]string) string {
+// generateEnhancedHTML creates beautiful HTML with v0.dev style. Tailwind's brand-*
+// and accent-* colours are the theme's primary and secondary colours.
+func (v *V0Service) generateEnhancedHTML(productName, productDescription, productPrice, productImageURL, videoURL, streamURL, subtitlesURL, posterURL, ogImageURL string, features []map[string]string, theme Theme) string {
 	if productName == "" {
 		productName = "Amazing Product"
 	}
@@ -96,7 +102,7 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
 			fmt.Printf("   %d. %s %s\n", i+1, f["icon"], f["title"])
 		}
 	}
-	colors := []string{"purple", "blue", "indigo", "violet"}
+	colors := []string{"brand", "accent"}
 	for i, feature := range features {
 		if i >= 4 {
 			break
@@ -127,9 +133,9 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
 	videoHTML := ""
 	if videoURL != "" {
 		videoHTML = fmt.Sprintf(`<video id="demo-video" controls class="w-full rounded-2xl" poster="%s">
-                        <source src="%s" type="video/mp4">
+                        <source src="%s" type="video/mp4">%s
                         Your browser does not support the video tag.
-                    </video>`, productImageURL, videoURL)
+                    </video>`, posterURL, videoURL, captionTrack(subtitlesURL, 24))
 	} else {
 		videoHTML = `<div class="p-8 text-center text-gray-500">
                         <p class="text-xl">Video coming soon...</p>
@@ -140,7 +146,7 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
 	priceHTML := ""
 	if productPrice != "" && productPrice != "$0" {
 		priceHTML = fmt.Sprintf(`<div class="flex items-center space-x-3">
-                        <span class="text-3xl font-black text-purple-600">%s</span>
+                        <span class="text-3xl font-black text-brand-600">%s</span>
                         <span class="px-4 py-2 bg-green-100 text-green-700 rounded-lg font-bold">Limited Offer!</span>
                     </div>`, productPrice)
 	}
@@ -150,6 +156,12 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
 	if len(productName) > 0 {
 		iconLetter = string(productName[0])
 	}
+	navIcon := logoImage(theme, "h-10 w-auto")
+	if navIcon == "" {
+		navIcon = fmt.Sprintf(`<div class="w-10 h-10 bg-gradient-to-br from-brand-600 to-accent-600 rounded-lg flex items-center justify-center shadow-lg">
+                        <span class="text-white font-bold text-xl">%s</span>
+                    </div>`, iconLetter)
+	}
 
 	currentYear := time.Now().Year()
 
@@ -160,16 +172,18 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
     <meta name="description" content="%s">
     <title>%s - Premium Product</title>
+    %s
     <link rel="stylesheet" href="styles.css">
-    <link href="https://fonts.googleapis.com/css2?family=Inter:wght@300;400;500;600;700;900&display=swap" rel="stylesheet">
+    %s
     <script src="https://cdn.tailwindcss.com"></script>
     <script>
         tailwind.config = {
             theme: {
                 extend: {
                     colors: {
-                        primary: '#6366f1',
-                        secondary: '#8b5cf6',
+                        primary: 'var(--primary)',
+                        secondary: 'var(--secondary)',
+                        %s
                     }
                 }
             }
@@ -213,26 +227,24 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
         }
     </style>
 </head>
-<body class="font-['Inter'] antialiased">
+<body class="antialiased">
     
     <!-- Navigation -->
     <nav class="fixed top-0 left-0 right-0 z-50 bg-white/80 backdrop-blur-lg border-b border-gray-200 shadow-sm">
         <div class="container mx-auto px-6 py-4">
             <div class="flex items-center justify-between">
                 <div class="flex items-center space-x-3">
-                    <div class="w-10 h-10 bg-gradient-to-br from-purple-600 to-blue-600 rounded-lg flex items-center justify-center shadow-lg">
-                        <span class="text-white font-bold text-xl">%s</span>
-                    </div>
-                    <span class="text-2xl font-bold bg-gradient-to-r from-purple-600 to-blue-600 bg-clip-text text-transparent">
+                    %s
+                    <span class="text-2xl font-bold bg-gradient-to-r from-brand-600 to-accent-600 bg-clip-text text-transparent">
                         %s
                     </span>
                 </div>
                 <div class="hidden md:flex space-x-8">
-                    <a href="#features" class="text-gray-700 hover:text-purple-600 font-medium transition">Features</a>
-                    <a href="#video" class="text-gray-700 hover:text-purple-600 font-medium transition">Demo</a>
-                    <a href="#pricing" class="text-gray-700 hover:text-purple-600 font-medium transition">Pricing</a>
+                    <a href="#features" class="text-gray-700 hover:text-brand-600 font-medium transition">Features</a>
+                    <a href="#video" class="text-gray-700 hover:text-brand-600 font-medium transition">Demo</a>
+                    <a href="#pricing" class="text-gray-700 hover:text-brand-600 font-medium transition">Pricing</a>
                 </div>
-                <button class="px-6 py-2 bg-gradient-to-r from-purple-600 to-blue-600 text-white rounded-lg font-semibold hover:shadow-lg transition transform hover:scale-105">
+                <button class="px-6 py-2 bg-gradient-to-r from-brand-600 to-accent-600 text-white rounded-lg font-semibold hover:shadow-lg transition transform hover:scale-105">
                     Get Started
                 </button>
             </div>
@@ -241,17 +253,17 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
 
     <!-- Hero Section -->
     <section class="relative pt-32 pb-20 px-6 overflow-hidden">
-        <div class="absolute inset-0 bg-gradient-to-br from-purple-50 via-blue-50 to-indigo-50"></div>
+        <div class="absolute inset-0 bg-gradient-to-br from-brand-50 via-accent-50 to-brand-50"></div>
         <div class="absolute inset-0 bg-grid-pattern opacity-10"></div>
         
         <div class="container mx-auto relative z-10">
             <div class="grid lg:grid-cols-2 gap-12 items-center">
                 <div class="space-y-8 animate-fade-in">
-                    <div class="inline-block px-4 py-2 bg-purple-100 rounded-full text-purple-600 font-semibold text-sm">
+                    <div class="inline-block px-4 py-2 bg-brand-100 rounded-full text-brand-600 font-semibold text-sm">
                         ✨ New Product Launch
                     </div>
                     <h1 class="text-5xl lg:text-6xl font-black leading-tight">
-                        <span class="bg-gradient-to-r from-purple-600 to-blue-600 bg-clip-text text-transparent">
+                        <span class="bg-gradient-to-r from-brand-600 to-accent-600 bg-clip-text text-transparent">
                             %s
                         </span>
                     </h1>
@@ -259,17 +271,17 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
                         %s
                     </p>
                     <div class="flex flex-wrap gap-4">
-                        <button class="px-8 py-4 bg-gradient-to-r from-purple-600 to-blue-600 text-white rounded-xl font-bold hover:shadow-2xl transition transform hover:scale-105">
+                        <button class="px-8 py-4 bg-gradient-to-r from-brand-600 to-accent-600 text-white rounded-xl font-bold hover:shadow-2xl transition transform hover:scale-105">
                             🚀 Get Started Now
                         </button>
-                        <button class="px-8 py-4 bg-white border-2 border-purple-600 text-purple-600 rounded-xl font-bold hover:bg-purple-50 transition">
+                        <button class="px-8 py-4 bg-white border-2 border-brand-600 text-brand-600 rounded-xl font-bold hover:bg-brand-50 transition">
                             📹 Watch Demo
                         </button>
                     </div>
                     %s
                 </div>
                 <div class="relative animate-float">
-                    <div class="absolute inset-0 bg-gradient-to-r from-purple-400 to-blue-400 rounded-3xl blur-3xl opacity-30"></div>
+                    <div class="absolute inset-0 bg-gradient-to-r from-brand-400 to-accent-400 rounded-3xl blur-3xl opacity-30"></div>
                     <img src="%s" alt="%s" class="relative z-10 w-full rounded-3xl shadow-2xl transform hover:scale-105 transition duration-500" id="product-image">
                 </div>
             </div>
@@ -281,7 +293,7 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
         <div class="container mx-auto">
             <div class="text-center mb-16 space-y-4">
                 <h2 class="text-4xl lg:text-5xl font-black">
-                    Why Choose <span class="bg-gradient-to-r from-purple-600 to-blue-600 bg-clip-text text-transparent">%s</span>?
+                    Why Choose <span class="bg-gradient-to-r from-brand-600 to-accent-600 bg-clip-text text-transparent">%s</span>?
                 </h2>
                 <p class="text-xl text-gray-600 max-w-2xl mx-auto">
                     Discover the features that make our product stand out from the competition
@@ -295,7 +307,7 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
     </section>
 
     <!-- Video Demo Section -->
-    <section id="video" class="py-20 px-6 bg-gradient-to-br from-purple-50 via-blue-50 to-indigo-50">
+    <section id="video" class="py-20 px-6 bg-gradient-to-br from-brand-50 via-accent-50 to-brand-50">
         <div class="container mx-auto">
             <div class="text-center mb-16 space-y-4">
                 <h2 class="text-4xl lg:text-5xl font-black">See It In Action</h2>
@@ -312,17 +324,17 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
     </section>
 
     <!-- CTA Section -->
-    <section id="pricing" class="py-20 px-6 bg-gradient-to-br from-purple-600 to-blue-600 text-white">
+    <section id="pricing" class="py-20 px-6 bg-gradient-to-br from-brand-600 to-accent-600 text-white">
         <div class="container mx-auto text-center space-y-8">
             <h2 class="text-4xl lg:text-5xl font-black">Ready to Get Started?</h2>
             <p class="text-xl opacity-90 max-w-2xl mx-auto">
                 Join thousands of satisfied customers who have already transformed their experience
             </p>
             <div class="flex flex-wrap gap-4 justify-center">
-                <button class="px-8 py-4 bg-white text-purple-600 rounded-xl font-bold hover:shadow-2xl transition transform hover:scale-105">
+                <button class="px-8 py-4 bg-white text-brand-600 rounded-xl font-bold hover:shadow-2xl transition transform hover:scale-105">
                     🎯 Get Started Now
                 </button>
-                <button class="px-8 py-4 bg-transparent border-2 border-white text-white rounded-xl font-bold hover:bg-white hover:text-purple-600 transition">
+                <button class="px-8 py-4 bg-transparent border-2 border-white text-white rounded-xl font-bold hover:bg-white hover:text-brand-600 transition">
                     💬 Contact Sales
                 </button>
             </div>
@@ -356,9 +368,9 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
                 <div>
                     <h4 class="font-bold mb-4">Connect</h4>
                     <div class="flex space-x-4">
-                        <a href="#" class="w-10 h-10 bg-gray-800 rounded-lg flex items-center justify-center hover:bg-purple-600 transition">📱</a>
-                        <a href="#" class="w-10 h-10 bg-gray-800 rounded-lg flex items-center justify-center hover:bg-purple-600 transition">🐦</a>
-                        <a href="#" class="w-10 h-10 bg-gray-800 rounded-lg flex items-center justify-center hover:bg-purple-600 transition">💼</a>
+                        <a href="#" class="w-10 h-10 bg-gray-800 rounded-lg flex items-center justify-center hover:bg-brand-600 transition">📱</a>
+                        <a href="#" class="w-10 h-10 bg-gray-800 rounded-lg flex items-center justify-center hover:bg-brand-600 transition">🐦</a>
+                        <a href="#" class="w-10 h-10 bg-gray-800 rounded-lg flex items-center justify-center hover:bg-brand-600 transition">💼</a>
                     </div>
                 </div>
             </div>
@@ -368,12 +380,15 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
         </div>
     </footer>
 
-    <script src="script.js"></script>
+    %s<script src="script.js"></script>
 </body>
 </html>`,
 		productDescription,  // meta description
 		productName,         // title
-		iconLetter,          // nav icon
+		openGraphTags(productName, productDescription, ogImageURL), // link previews
+		theme.FontsLink(),   // fonts
+		theme.TailwindColors(), // brand-* and accent-* colours
+		navIcon,             // nav icon
 		productName,         // nav brand
 		productName,         // hero title
 		productDescription,  // hero description
@@ -386,11 +401,13 @@ func (v *V0Service) generateEnhancedHTML(productName, productDescription, produc
 		productName,         // footer brand
 		currentYear,         // year
 		productName,         // footer copyright
+		hlsPlayer("demo-video", streamURL, videoURL), // adaptive streaming
 	)
 }
 
-// generateModernCSS creates modern CSS with animations
-func (v *V0Service) generateModernCSS() string {
+// generateModernCSS creates modern CSS with animations, declaring the theme's
+// CSS variables that the page's Tailwind colours refer to
+func (v *V0Service) generateModernCSS(theme Theme) string {
 	return `/* Modern CSS with v0.dev styling */
 * {
     margin: 0;
@@ -398,6 +415,15 @@ func (v *V0Service) generateModernCSS() string {
     box-sizing: border-box;
 }
 
+` + theme.CSSVariables() + `
+body {
+    font-family: var(--font-body);
+}
+
+h1, h2, h3, h4 {
+    font-family: var(--font-heading);
+}
+
 @keyframes fade-in {
     from {
         opacity: 0;
@@ -442,12 +468,12 @@ func (v *V0Service) generateModernCSS() string {
 }
 
 ::-webkit-scrollbar-thumb {
-    background: linear-gradient(135deg, #6366f1, #8b5cf6);
+    background: linear-gradient(135deg, var(--primary), var(--secondary));
     border-radius: 6px;
 }
 
 ::-webkit-scrollbar-thumb:hover {
-    background: linear-gradient(135deg, #8b5cf6, #6366f1);
+    background: linear-gradient(135deg, var(--secondary), var(--primary));
 }
 
 /* Responsive grid for features - FORCE 4 columns on desktop */
@@ -525,30 +551,17 @@ document.addEventListener('DOMContentLoaded', function() {
 }
 
 // saveWebsiteFiles saves the generated website files
-func (v *V0Service) saveWebsiteFiles(html, css, js string) (string, error) {
-	// Create website directory
-	websiteID := fmt.Sprintf("v0-website-%d", time.Now().Unix())
-	websiteDir := filepath.Join("generated", "websites", websiteID)
-	
-	if err := os.MkdirAll(websiteDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create directory: %v", err)
-	}
-
+func (v *V0Service) saveWebsiteFiles(websiteKey, html, css, js string) (string, error) {
 	// Save files
 	files := map[string]string{
 		"index.html": html,
 		"styles.css": css,
 		"script.js":  js,
 	}
-
-	for filename, content := range files {
-		filePath := filepath.Join(websiteDir, filename)
-		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
-			return "", fmt.Errorf("failed to write %s: %v", filename, err)
-		}
+	if err := saveWebsite(v.storage, websiteKey, files); err != nil {
+		return "", err
 	}
 
-	fmt.Printf("✅ Website files saved to: %s\n", websiteDir)
-	return websiteDir, nil
+	fmt.Printf("✅ Website files saved to: %s\n", websiteKey)
+	return websiteKey, nil
 }
-
@@ -2,6 +2,8 @@ package services
 
 import (
 	"fmt"
+	"html"
+	"strings"
 	"time"
 )
 
@@ -16,8 +18,70 @@ func getDefaultFeatures() []map[string]string {
 	}
 }
 
-// MarketingWebsiteTemplate generates professional marketing website HTML
-func MarketingWebsiteTemplate(productName, productDescription, videoURL, productImageURL string, features []map[string]string) string {
+// captionTrack returns the <track> element for a video's WebVTT captions, indented
+// to match the <source> element, or nothing if the video has no captions
+func captionTrack(subtitlesURL string, indent int) string {
+	if subtitlesURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n%s<track kind=\"captions\" src=\"%s\" srclang=\"en\" label=\"English\" default>",
+		strings.Repeat(" ", indent), subtitlesURL)
+}
+
+// hlsPlayer makes the video element play the HLS stream: natively where the browser
+// can (Safari, iOS), elsewhere with hls.js. The MP4 stays the <source>, and is
+// switched back to if the stream fails. Without a stream the page has the MP4 only.
+func hlsPlayer(videoID, streamURL, videoURL string) string {
+	if streamURL == "" {
+		return ""
+	}
+	return fmt.Sprintf(`<script src="https://cdn.jsdelivr.net/npm/hls.js@1"></script>
+    <script>
+        (function () {
+            var video = document.getElementById('%s');
+            var stream = '%s', mp4 = '%s';
+            if (!video) return;
+            if (video.canPlayType('application/vnd.apple.mpegurl')) {
+                video.src = stream;
+            } else if (window.Hls && Hls.isSupported()) {
+                var hls = new Hls({ capLevelToPlayerSize: true });
+                hls.on(Hls.Events.ERROR, function (event, data) {
+                    if (data.fatal) {
+                        hls.destroy();
+                        video.src = mp4;
+                    }
+                });
+                hls.loadSource(stream);
+                hls.attachMedia(video);
+            }
+        })();
+    </script>
+    `, videoID, streamURL, videoURL)
+}
+
+// openGraphTags describes the page to social networks and chat apps that preview
+// links to it. imageURL must be absolute; without one no image is announced.
+func openGraphTags(title, description, imageURL string) string {
+	tags := []string{
+		`<meta property="og:type" content="website">`,
+		fmt.Sprintf(`<meta property="og:title" content="%s">`, html.EscapeString(title)),
+		fmt.Sprintf(`<meta property="og:description" content="%s">`, html.EscapeString(description)),
+	}
+	if imageURL != "" {
+		tags = append(tags,
+			fmt.Sprintf(`<meta property="og:image" content="%s">`, html.EscapeString(imageURL)),
+			`<meta name="twitter:card" content="summary_large_image">`,
+		)
+	}
+	return strings.Join(tags, "\n    ")
+}
+
+// MarketingWebsiteTemplate generates professional marketing website HTML.
+// streamURL, if set, is the video's HLS master playlist, played in preference to
+// the MP4. subtitlesURL, if set, adds the video's WebVTT captions. posterURL is shown
+// before the video plays and ogImageURL, an absolute URL, in link previews. The
+// theme's fonts are loaded here; its colours come with ModernWebsiteCSS.
+func MarketingWebsiteTemplate(productName, productDescription, videoURL, streamURL, subtitlesURL, posterURL, ogImageURL, productImageURL string, features []map[string]string, theme Theme) string {
 	if productName == "" {
 		productName = "Amazing Product"
 	}
@@ -55,10 +119,10 @@ func MarketingWebsiteTemplate(productName, productDescription, videoURL, product
 	videoHTML := ""
 	if videoURL != "" {
 		videoHTML = fmt.Sprintf(`
-                <video controls class="promo-video" poster="%s">
-                    <source src="%s" type="video/mp4">
+                <video id="promo-video" controls class="promo-video" poster="%s">
+                    <source src="%s" type="video/mp4">%s
                     Your browser does not support the video tag.
-                </video>`, productImageURL, videoURL)
+                </video>`, posterURL, videoURL, captionTrack(subtitlesURL, 20))
 	} else {
 		videoHTML = `
                 <div class="video-placeholder">
@@ -75,17 +139,18 @@ func MarketingWebsiteTemplate(productName, productDescription, videoURL, product
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
     <meta name="description" content="%s">
     <title>%s - Official Product Page</title>
+    %s
     <link rel="stylesheet" href="styles.css">
     <link rel="preconnect" href="https://fonts.googleapis.com">
     <link rel="preconnect" href="https://fonts.gstatic.com" crossorigin>
-    <link href="https://fonts.googleapis.com/css2?family=Inter:wght@300;400;600;700;900&display=swap" rel="stylesheet">
+    %s
 </head>
 <body>
     <!-- Hero Section -->
     <section class="hero">
         <nav class="navbar">
             <div class="container">
-                <div class="logo">%s</div>
+                <div class="logo">%s%s</div>
                 <ul class="nav-menu">
                     <li><a href="#features">Features</a></li>
                     <li><a href="#video">Watch Demo</a></li>
@@ -182,27 +247,39 @@ func MarketingWebsiteTemplate(productName, productDescription, videoURL, product
         </div>
     </footer>
 
-    <script src="script.js"></script>
+    %s<script src="script.js"></script>
 </body>
 </html>`,
 		productDescription, // meta description
 		productName,        // page title
-		productName,        // logo
-		productName,        // hero title
-		productDescription, // hero description
-		productImageURL,    // product image src
-		productName,        // product image alt
-		productName,        // "Why Choose X?"
-		featuresHTML,       // features cards
-		videoHTML,          // video player
-		productName,        // footer brand
-		currentYear,        // year
-		productName,        // footer copyright
+		openGraphTags(productName, productDescription, ogImageURL), // link previews
+		theme.FontsLink(),              // fonts
+		logoImage(theme, "logo-image"), // brand logo
+		productName,                    // logo
+		productName,                    // hero title
+		productDescription,             // hero description
+		productImageURL,                // product image src
+		productName,                    // product image alt
+		productName,                    // "Why Choose X?"
+		featuresHTML,                   // features cards
+		videoHTML,                      // video player
+		productName,                    // footer brand
+		currentYear,                    // year
+		productName,                    // footer copyright
+		hlsPlayer("promo-video", streamURL, videoURL), // adaptive streaming
 	)
 }
 
-// ModernWebsiteCSS generates modern marketing CSS
-func ModernWebsiteCSS() string {
+// logoImage is the brand logo as an <img> of the given class, if the theme has one
+func logoImage(theme Theme, class string) string {
+	if theme.LogoURL == "" {
+		return ""
+	}
+	return fmt.Sprintf(`<img src="%s" alt="Logo" class="%s">`, theme.LogoURL, class)
+}
+
+// ModernWebsiteCSS generates modern marketing CSS in the theme's colours and fonts
+func ModernWebsiteCSS(theme Theme) string {
 	return `/* ===== RESET & BASE ===== */
 * {
     margin: 0;
@@ -210,10 +287,9 @@ func ModernWebsiteCSS() string {
     box-sizing: border-box;
 }
 
+/* ===== BRAND ===== */
+` + theme.CSSVariables() + `
 :root {
-    --primary: #667eea;
-    --primary-dark: #5a67d8;
-    --secondary: #764ba2;
     --text-dark: #1a202c;
     --text-light: #718096;
     --bg-light: #f7fafc;
@@ -224,13 +300,17 @@ func ModernWebsiteCSS() string {
 }
 
 body {
-    font-family: 'Inter', -apple-system, BlinkMacSystemFont, 'Segoe UI', sans-serif;
+    font-family: var(--font-body);
     line-height: 1.6;
     color: var(--text-dark);
     background: var(--white);
     overflow-x: hidden;
 }
 
+h1, h2, h3, h4, .logo {
+    font-family: var(--font-heading);
+}
+
 .container {
     max-width: 1200px;
     margin: 0 auto;
@@ -263,6 +343,12 @@ body {
     background-clip: text;
 }
 
+.logo-image {
+    height: 2rem;
+    margin-right: 0.5rem;
+    vertical-align: middle;
+}
+
 .nav-menu {
     list-style: none;
     display: flex;
@@ -283,7 +369,7 @@ body {
 
 /* ===== HERO SECTION ===== */
 .hero {
-    background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+    background: linear-gradient(135deg, var(--primary) 0%, var(--secondary) 100%);
     padding: 4rem 0 6rem;
     position: relative;
     overflow: hidden;
@@ -461,7 +547,7 @@ body {
     left: 0;
     right: 0;
     height: 4px;
-    background: linear-gradient(90deg, #667eea 0%, #764ba2 100%);
+    background: linear-gradient(90deg, var(--primary) 0%, var(--secondary) 100%);
     transform: scaleX(0);
     transition: transform 0.4s ease;
 }
@@ -473,7 +559,7 @@ body {
 .feature-card:hover {
     transform: translateY(-12px);
     box-shadow: 0 20px 40px rgba(0,0,0,0.15);
-    border-color: rgba(102, 126, 234, 0.3);
+    border-color: var(--brand-200);
 }
 
 .feature-icon {
@@ -539,7 +625,7 @@ body {
 
 /* ===== CTA SECTION ===== */
 .cta-section {
-    background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+    background: linear-gradient(135deg, var(--primary) 0%, var(--secondary) 100%);
     padding: 6rem 0;
     text-align: center;
     color: var(--white);
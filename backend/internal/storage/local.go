@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local stores objects on disk, one directory per key prefix
+type Local struct {
+	roots  map[string]string // Key prefix → directory
+	signer *Signer           // Issues download URLs; nil for plain static routes
+}
+
+// NewLocal maps each key prefix to a directory, e.g. "uploads/" → "./uploads".
+// Signed URLs are issued by signer, as this server serves the files itself.
+func NewLocal(roots map[string]string, signer *Signer) *Local {
+	return &Local{roots: roots, signer: signer}
+}
+
+func (l *Local) Name() string { return "local" }
+
+// Path is the file holding key
+func (l *Local) Path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	prefix := prefixOf(key)
+	root, ok := l.roots[prefix]
+	if !ok {
+		return "", fmt.Errorf("no directory configured for %s", prefix)
+	}
+	return filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(key, prefix))), nil
+}
+
+func (l *Local) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	target, err := l.Path(key)
+	if err != nil {
+		return err
+	}
+	return writeFile(target, body)
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	target, err := l.Path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(target)
+	if os.IsNotExist(err) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	stat, err := file.Stat()
+	if err != nil || stat.IsDir() {
+		file.Close()
+		return nil, nil, ErrNotFound
+	}
+	return file, &Object{Size: stat.Size(), ContentType: ContentType(target), ModTime: stat.ModTime()}, nil
+}
+
+// SignedURL returns this server's signed media URL for the file, or its static
+// route (relative to the server's address) without a signer
+func (l *Local) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if _, err := l.Path(key); err != nil {
+		return "", err
+	}
+	if l.signer != nil {
+		return l.signer.URL(key, ttl)
+	}
+	return PublicPath(key), nil
+}
+
+// Delete removes the file and any directories left empty under its root
+func (l *Local) Delete(ctx context.Context, key string) error {
+	target, err := l.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	root := filepath.Clean(l.roots[prefixOf(key)])
+	for dir := filepath.Dir(target); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break // Not empty
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dealshare/hacathon/backend/internal/config"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func newTestSigner(t *testing.T, secret string) *Signer {
+	t.Helper()
+	s, err := NewSigner(&config.Config{MediaURLSecret: secret, PublicBaseURL: "https://media.example.com/", MediaURLTTLMinutes: 60})
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	return s
+}
+
+func TestNewSigner(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		baseURL string
+		wantErr bool
+	}{
+		{"configured", testSecret, "https://media.example.com", false},
+		{"random secret", "", "http://localhost:8080", false},
+		{"short secret", "too-short", "https://media.example.com", true},
+		{"no scheme", testSecret, "media.example.com", true},
+		{"other scheme", testSecret, "ftp://media.example.com", true},
+		{"no host", testSecret, "https://", true},
+	}
+	for _, tt := range tests {
+		_, err := NewSigner(&config.Config{MediaURLSecret: tt.secret, PublicBaseURL: tt.baseURL})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: NewSigner() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSignerURL(t *testing.T) {
+	s := newTestSigner(t, testSecret)
+	raw, err := s.URL("videos/p1/final.mp4", time.Hour)
+	if err != nil {
+		t.Fatalf("URL() error = %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	if got := u.Scheme + "://" + u.Host + u.Path; got != "https://media.example.com/media/videos/p1/final.mp4" {
+		t.Errorf("URL() address = %q", got)
+	}
+	key := strings.TrimPrefix(u.Path, MediaRoute)
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+	if err := s.Verify(key, expires, signature, time.Now()); err != nil {
+		t.Errorf("Verify() of a fresh URL: %v", err)
+	}
+
+	for _, key := range []string{"../etc/passwd", "videos/../uploads/x", "other/x.mp4", ""} {
+		if _, err := s.URL(key, time.Hour); err == nil {
+			t.Errorf("URL(%q) succeeded, want an error", key)
+		}
+	}
+}
+
+func TestSignerVerify(t *testing.T) {
+	s := newTestSigner(t, testSecret)
+	other := newTestSigner(t, strings.Repeat("x", 32))
+	now := time.Unix(1_700_000_000, 0)
+	key := "uploads/p1/person.jpg"
+	expires := strconv.FormatInt(now.Add(time.Hour).Unix(), 10)
+	signature := s.signature(key, expires)
+
+	tests := []struct {
+		name      string
+		key       string
+		expires   string
+		signature string
+		now       time.Time
+		wantErr   bool
+	}{
+		{"valid", key, expires, signature, now, false},
+		{"valid until the second it expires", key, expires, signature, now.Add(time.Hour), false},
+		{"expired", key, expires, signature, now.Add(time.Hour + time.Second), true},
+		{"other key", "uploads/p1/product.jpg", expires, signature, now, true},
+		{"extended expiry", key, strconv.FormatInt(now.Add(24*time.Hour).Unix(), 10), signature, now, true},
+		{"malformed expiry", key, "tomorrow", signature, now, true},
+		{"no signature", key, expires, "", now, true},
+		{"signed with another secret", key, expires, other.signature(key, expires), now, true},
+		{"uppercase signature", key, expires, strings.ToUpper(signature), now, true},
+	}
+	for _, tt := range tests {
+		err := s.Verify(tt.key, tt.expires, tt.signature, tt.now)
+		if tt.wantErr && !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: Verify() error = %v, want ErrInvalidSignature", tt.name, err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: Verify() error = %v", tt.name, err)
+		}
+	}
+}
+
+func TestKeyFor(t *testing.T) {
+	dir := t.TempDir()
+	local := NewLocal(map[string]string{
+		PrefixUploads: filepath.Join(dir, "uploads"),
+		PrefixVideos:  filepath.Join(dir, "generated", "videos"),
+	}, nil)
+
+	tests := []struct {
+		ref    string
+		want   string
+		wantOK bool
+	}{
+		{"videos/p1/final.mp4", "videos/p1/final.mp4", true},
+		{filepath.Join(dir, "uploads", "p1", "person.jpg"), "uploads/p1/person.jpg", true},
+		{filepath.Join(dir, "generated", "videos", "p1", "final.mp4"), "videos/p1/final.mp4", true},
+		{filepath.Join(dir, "uploads"), "", false},
+		{filepath.Join(dir, "uploads", "..", "secrets.env"), "", false},
+		{filepath.Join(dir, "elsewhere", "x.mp4"), "", false},
+		{"/etc/passwd", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := KeyFor(local, tt.ref, "")
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("KeyFor(%q) = %q, %v, want %q, %v", tt.ref, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dealshare/hacathon/backend/internal/config"
+)
+
+// Key prefixes. Every stored object lives under one of them, and each maps to a
+// public static route (see PublicPath).
+const (
+	PrefixUploads  = "uploads/"
+	PrefixVideos   = "videos/"
+	PrefixWebsites = "websites/"
+)
+
+// ErrNotFound is returned when a key does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Object describes a stored object
+type Object struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage stores media and generated files by key, e.g. "videos/<id>.mp4"
+type Storage interface {
+	// Name identifies the backend in logs, e.g. "local" or "s3:bucket"
+	Name() string
+	// Put stores the reader's contents under key; size is -1 when unknown
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the object; the caller closes it
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// SignedURL returns a URL the object can be downloaded from for at least ttl
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// Delete removes the object; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// New opens the backend selected by STORAGE_BACKEND. The local backend's signed
+// URLs come from signer.
+func New(cfg *config.Config, signer *Signer) (Storage, error) {
+	switch cfg.StorageBackend {
+	case "", "local":
+		return NewLocal(map[string]string{
+			PrefixUploads:  cfg.UploadPath,
+			PrefixVideos:   cfg.GeneratedVideoPath,
+			PrefixWebsites: cfg.WebsitePath,
+		}, signer), nil
+	case "s3":
+		return NewS3(S3Options{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			PathStyle:       cfg.S3PathStyle,
+		})
+	}
+	return nil, fmt.Errorf("unknown STORAGE_BACKEND %q (expected local or s3)", cfg.StorageBackend)
+}
+
+// keyPattern allows the characters the backend generates keys from. Anything else,
+// including ".." segments, is rejected before it reaches a backend.
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*(/[A-Za-z0-9_-][A-Za-z0-9._-]*)*$`)
+
+// ValidKey reports whether key is well formed and under a known prefix
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key) && prefixOf(key) != ""
+}
+
+func prefixOf(key string) string {
+	for _, prefix := range []string{PrefixUploads, PrefixVideos, PrefixWebsites} {
+		if strings.HasPrefix(key, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// UploadKey, VideoKey and WebsiteKey build keys under each prefix
+func UploadKey(parts ...string) string  { return PrefixUploads + path.Join(parts...) }
+func VideoKey(parts ...string) string   { return PrefixVideos + path.Join(parts...) }
+func WebsiteKey(parts ...string) string { return PrefixWebsites + path.Join(parts...) }
+
+// PublicPath is the static route serving key, relative to the server's root
+func PublicPath(key string) string {
+	switch prefixOf(key) {
+	case PrefixUploads:
+		return "/static/uploads/" + strings.TrimPrefix(key, PrefixUploads)
+	case PrefixVideos:
+		return "/static/generated/videos/" + strings.TrimPrefix(key, PrefixVideos)
+	case PrefixWebsites:
+		return "/static/generated/websites/" + strings.TrimPrefix(key, PrefixWebsites)
+	}
+	return ""
+}
+
+// ContentType guesses an object's type from its name
+func ContentType(name string) string {
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".mp4":
+		return "video/mp4"
+	case ".mov":
+		return "video/quicktime"
+	case ".webm":
+		return "video/webm"
+	case ".avi":
+		return "video/x-msvideo"
+	case ".vtt":
+		return "text/vtt; charset=utf-8"
+	case ".srt":
+		return "application/x-subrip; charset=utf-8"
+	case ".m3u8":
+		return "application/vnd.apple.mpegurl"
+	case ".ts":
+		return "video/mp2t"
+	case ".mp3":
+		return "audio/mpeg"
+	case ".m4a", ".aac":
+		return "audio/mp4"
+	case ".wav":
+		return "audio/wav"
+	default:
+		if contentType := mime.TypeByExtension(ext); contentType != "" {
+			return contentType
+		}
+	}
+	return "application/octet-stream"
+}
+
+// PutFile stores a local file under key. With the local backend, a file that is
+// already the key's own file is left alone.
+func PutFile(ctx context.Context, s Storage, key, localPath string) error {
+	if local, ok := s.(*Local); ok {
+		if target, err := local.Path(key); err == nil && sameFile(target, localPath) {
+			return nil
+		}
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, key, file, stat.Size(), ContentType(localPath))
+}
+
+// Fetch returns a local path holding the object, for tools such as ffmpeg that
+// need a file. The local backend returns the stored file itself; other backends
+// download it to cacheDir once. References that are not keys are treated as
+// plain local paths, as stored by older versions.
+func Fetch(ctx context.Context, s Storage, ref, cacheDir string) (string, error) {
+	if !ValidKey(ref) {
+		if _, err := os.Stat(ref); err != nil {
+			return "", fmt.Errorf("%s is neither a storage key nor a local file", ref)
+		}
+		return ref, nil
+	}
+	if local, ok := s.(*Local); ok {
+		path, err := local.Path(ref)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", ErrNotFound
+		}
+		return path, nil
+	}
+
+	target := filepath.Join(cacheDir, filepath.FromSlash(ref))
+	if _, err := os.Stat(target); err == nil {
+		return target, nil
+	}
+
+	body, _, err := s.Get(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	if err := writeFile(target, body); err != nil {
+		return "", fmt.Errorf("failed to cache %s: %v", ref, err)
+	}
+	return target, nil
+}
+
+// writeFile writes through a temporary file, so readers never see a partial file
+func writeFile(target string, body io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func sameFile(a, b string) bool {
+	statA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	statB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(statA, statB)
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"videos/12.mp4", true},
+		{"uploads/3/product_ab12.png", true},
+		{"websites/landing-4.html", true},
+		{"videos/../config.env", false},
+		{"videos/./12.mp4", false},
+		{"videos//12.mp4", false},
+		{"videos/", false},
+		{"/videos/12.mp4", false},
+		{"secrets/12.mp4", false},
+		{"uploads/my photo.png", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := ValidKey(tt.key); got != tt.want {
+			t.Errorf("ValidKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestPublicPath(t *testing.T) {
+	tests := map[string]string{
+		UploadKey("3", "product.png"): "/static/uploads/3/product.png",
+		VideoKey("12.mp4"):            "/static/generated/videos/12.mp4",
+		WebsiteKey("landing_4.html"):  "/static/generated/websites/landing_4.html",
+		"elsewhere/12.mp4":            "",
+	}
+	for key, want := range tests {
+		if got := PublicPath(key); got != want {
+			t.Errorf("PublicPath(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func newTestLocal(t *testing.T) (*Local, string) {
+	root := t.TempDir()
+	return NewLocal(map[string]string{
+		PrefixUploads: filepath.Join(root, "uploads"),
+		PrefixVideos:  filepath.Join(root, "videos"),
+	}, nil), root
+}
+
+func TestLocalRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	local, root := newTestLocal(t)
+	key := VideoKey("7", "final.mp4")
+
+	if err := local.Put(ctx, key, strings.NewReader("video bytes"), -1, "video/mp4"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "videos", "7", "final.mp4")); err != nil {
+		t.Errorf("Put() did not write under the prefix's directory: %v", err)
+	}
+
+	body, object, err := local.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "video bytes" || object.Size != 11 || object.ContentType != "video/mp4" {
+		t.Errorf("Get() = %q, %+v", data, object)
+	}
+
+	if url, err := local.SignedURL(ctx, key, time.Hour); err != nil || url != "/static/generated/videos/7/final.mp4" {
+		t.Errorf("SignedURL() = %q, %v", url, err)
+	}
+
+	if err := local.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, _, err := local.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "videos", "7")); !os.IsNotExist(err) {
+		t.Errorf("Delete() left the empty directory behind")
+	}
+	if err := local.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of a missing key error = %v", err)
+	}
+
+	if err := local.Put(ctx, "websites/page.html", strings.NewReader("<html>"), -1, "text/html"); err == nil {
+		t.Errorf("Put() accepted a prefix without a directory")
+	}
+	if _, err := local.Path("videos/../../etc/passwd"); err == nil {
+		t.Errorf("Path() accepted a key escaping its root")
+	}
+}
+
+// memoryStorage is a remote backend kept in memory, counting downloads
+type memoryStorage struct {
+	objects map[string][]byte
+	gets    int
+}
+
+func (m *memoryStorage) Name() string { return "memory" }
+
+func (m *memoryStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(body)
+	m.objects[key] = data
+	return err
+}
+
+func (m *memoryStorage) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	m.gets++
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), &Object{Size: int64(len(data))}, nil
+}
+
+func (m *memoryStorage) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return "https://bucket.example.com/" + key, nil
+}
+
+func (m *memoryStorage) Delete(ctx context.Context, key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+	cache := t.TempDir()
+	remote := &memoryStorage{objects: map[string][]byte{"videos/1/avatar.mp4": []byte("avatar")}}
+
+	path, err := Fetch(ctx, remote, "videos/1/avatar.mp4", cache)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "avatar" || !strings.HasPrefix(path, cache) {
+		t.Errorf("Fetch() = %s holding %q", path, data)
+	}
+	if _, err := Fetch(ctx, remote, "videos/1/avatar.mp4", cache); err != nil || remote.gets != 1 {
+		t.Errorf("second Fetch() downloaded again (%d downloads, error %v)", remote.gets, err)
+	}
+	if _, err := Fetch(ctx, remote, "videos/1/missing.mp4", cache); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Fetch() of a missing key error = %v, want ErrNotFound", err)
+	}
+
+	// Paths stored before keys existed are used as they are
+	legacy := filepath.Join(t.TempDir(), "old.mp4")
+	os.WriteFile(legacy, []byte("old"), 0644)
+	if path, err := Fetch(ctx, remote, legacy, cache); err != nil || path != legacy {
+		t.Errorf("Fetch(legacy path) = %s, %v", path, err)
+	}
+	if _, err := Fetch(ctx, remote, "/no/such/file.mp4", cache); err == nil {
+		t.Errorf("Fetch() accepted a path that does not exist")
+	}
+
+	// The local backend hands out its own file
+	local, _ := newTestLocal(t)
+	local.Put(ctx, "uploads/photo.png", strings.NewReader("png"), -1, "image/png")
+	want, _ := local.Path("uploads/photo.png")
+	if path, err := Fetch(ctx, local, "uploads/photo.png", cache); err != nil || path != want {
+		t.Errorf("Fetch(local) = %s, %v, want %s", path, err, want)
+	}
+}
@@ -0,0 +1,140 @@
+// Package subtitles times a script into caption cues and writes them as SRT or WebVTT
+package subtitles
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Caption layout limits. Phrases break at punctuation or when a line would run
+// past MaxChars, which keeps them readable on a 9:16 frame.
+const (
+	MaxChars       = 32   // Characters per cue
+	WordsPerSecond = 2.5  // Speaking pace assumed when the audio length is unknown
+	minCueSeconds  = 0.8  // Shortest time a cue stays on screen
+	pauseWeight    = 0.15 // Extra weight of a phrase ending in a pause (.,!?;:), as a fraction of its length
+)
+
+// Cue is a caption shown from Start to End, in seconds from the start of the video
+type Cue struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// EstimateDuration is how long a presenter takes to read the script aloud
+func EstimateDuration(script string) float64 {
+	return float64(len(strings.Fields(script))) / WordsPerSecond
+}
+
+// Build splits the script into phrases and spreads them over the speech, which
+// starts at offset and lasts spoken seconds. Each phrase gets time in proportion
+// to its length, plus a little for a pause after punctuation, which follows the
+// audio closely without needing word timings.
+func Build(script string, offset, spoken float64) []Cue {
+	phrases := Phrases(script)
+	if len(phrases) == 0 || spoken <= 0 {
+		return nil
+	}
+
+	weights := make([]float64, len(phrases))
+	total := 0.0
+	for i, phrase := range phrases {
+		weights[i] = float64(utf8.RuneCountInString(phrase))
+		if endsWithPause(phrase) {
+			weights[i] *= 1 + pauseWeight
+		}
+		total += weights[i]
+	}
+
+	cues := make([]Cue, 0, len(phrases))
+	at := offset
+	for i, phrase := range phrases {
+		length := math.Max(spoken*weights[i]/total, minCueSeconds)
+		cues = append(cues, Cue{Start: round(at), End: round(at + length), Text: phrase})
+		at += length
+	}
+	return cues
+}
+
+// Phrases splits a script into caption-sized phrases
+func Phrases(script string) []string {
+	var phrases []string
+	var line []string
+	width := 0
+	flush := func() {
+		if len(line) > 0 {
+			phrases = append(phrases, strings.Join(line, " "))
+			line, width = nil, 0
+		}
+	}
+
+	for _, word := range strings.Fields(script) {
+		n := utf8.RuneCountInString(word)
+		if len(line) > 0 && width+1+n > MaxChars {
+			flush()
+		}
+		if len(line) > 0 {
+			width++
+		}
+		line = append(line, word)
+		width += n
+		if endsWithPause(word) {
+			flush()
+		}
+	}
+	flush()
+	return phrases
+}
+
+// Trim drops the cues that start at or after limit and cuts the last one short,
+// so captions never outlast the video
+func Trim(cues []Cue, limit float64) []Cue {
+	trimmed := make([]Cue, 0, len(cues))
+	for _, cue := range cues {
+		if cue.Start >= limit {
+			break
+		}
+		cue.End = math.Min(cue.End, limit)
+		trimmed = append(trimmed, cue)
+	}
+	return trimmed
+}
+
+// SRT formats the cues as a SubRip file
+func SRT(cues []Cue) string {
+	var b strings.Builder
+	for i, cue := range cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(cue.Start, ","), timestamp(cue.End, ","), cue.Text)
+	}
+	return b.String()
+}
+
+// VTT formats the cues as a WebVTT file, as used by the HTML <track> element
+func VTT(cues []Cue) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		// "-->" may not appear in cue text
+		text := strings.ReplaceAll(cue.Text, "-->", "->")
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", timestamp(cue.Start, "."), timestamp(cue.End, "."), text)
+	}
+	return b.String()
+}
+
+// timestamp formats seconds as HH:MM:SS followed by sep and milliseconds
+func timestamp(seconds float64, sep string) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+func endsWithPause(word string) bool {
+	return strings.ContainsAny(word[len(word)-1:], ".,!?;:")
+}
+
+// round keeps cue times to the millisecond the formats can express
+func round(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
@@ -0,0 +1,106 @@
+package subtitles
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPhrases(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"empty", "  \n ", nil},
+		{"breaks at punctuation", "Meet the kettle. It boils fast, and quietly!", []string{"Meet the kettle.", "It boils fast,", "and quietly!"}},
+		{"breaks long lines", "one two three four five six seven eight nine ten", []string{"one two three four five six", "seven eight nine ten"}},
+		{"collapses whitespace", "Hello\n\n  world", []string{"Hello world"}},
+		{"keeps an overlong word whole", strings.Repeat("x", 40) + " end", []string{strings.Repeat("x", 40), "end"}},
+		{"counts runes, not bytes", "café café café café café café café", []string{"café café café café café café", "café"}},
+	}
+	for _, tt := range tests {
+		if got := Phrases(tt.script); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Phrases() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name           string
+		script         string
+		offset, spoken float64
+		want           []Cue
+	}{
+		{"no speech", "Hello there.", 0, 0, nil},
+		{"no script", "", 0, 5, nil},
+		{"one phrase fills the speech", "Hello there", 1.5, 4, []Cue{{1.5, 5.5, "Hello there"}}},
+		{"in proportion to length", "aaaa bbbb cccc dddd eeee ffff gggg hhhh", 0, 10, []Cue{
+			{0, 7.632, "aaaa bbbb cccc dddd eeee ffff"},
+			{7.632, 10, "gggg hhhh"},
+		}},
+		{"pauses weigh more", "Buy it, now", 0, 11.05, []Cue{{0, 8.05, "Buy it,"}, {8.05, 11.05, "now"}}},
+		{"short phrases last a minimum", "Hi. Yes. No.", 0, 1, []Cue{{0, 0.8, "Hi."}, {0.8, 1.6, "Yes."}, {1.6, 2.4, "No."}}},
+	}
+	for _, tt := range tests {
+		if got := Build(tt.script, tt.offset, tt.spoken); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Build() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildCoversSpeech(t *testing.T) {
+	script := "Say hello to the SmartBrew kettle. It boils a full litre in ninety seconds, keeps your tea hot for hours, and switches itself off. Order yours today!"
+	cues := Build(script, 0.5, 30)
+	for i, cue := range cues {
+		if cue.End <= cue.Start {
+			t.Errorf("cue %d ends at %v before it starts at %v", i, cue.End, cue.Start)
+		}
+		if i > 0 && cue.Start != cues[i-1].End {
+			t.Errorf("cue %d starts at %v, want %v where the previous one ends", i, cue.Start, cues[i-1].End)
+		}
+	}
+	if end := cues[len(cues)-1].End; math.Abs(end-30.5) > 0.002 {
+		t.Errorf("last cue ends at %v, want 30.5", end)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	cues := []Cue{{0, 2, "a"}, {2, 4, "b"}, {4, 6, "c"}}
+	tests := []struct {
+		limit float64
+		want  []Cue
+	}{
+		{10, cues},
+		{6, cues},
+		{5, []Cue{{0, 2, "a"}, {2, 4, "b"}, {4, 5, "c"}}},
+		{4, []Cue{{0, 2, "a"}, {2, 4, "b"}}},
+		{0, []Cue{}},
+	}
+	for _, tt := range tests {
+		if got := Trim(cues, tt.limit); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Trim(%v) = %+v, want %+v", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestFormats(t *testing.T) {
+	cues := []Cue{{0, 1.5, "Meet the kettle."}, {3661.0015, 3662.25, "a --> b"}}
+	tests := []struct {
+		name   string
+		format func([]Cue) string
+		want   string
+	}{
+		{"srt", SRT, "1\n00:00:00,000 --> 00:00:01,500\nMeet the kettle.\n\n" +
+			"2\n01:01:01,002 --> 01:01:02,250\na --> b\n\n"},
+		{"vtt", VTT, "WEBVTT\n\n00:00:00.000 --> 00:00:01.500\nMeet the kettle.\n\n" +
+			"01:01:01.002 --> 01:01:02.250\na -> b\n\n"},
+	}
+	for _, tt := range tests {
+		if got := tt.format(cues); got != tt.want {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package workspaces
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dealshare/hacathon/backend/internal/config"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"github.com/dealshare/hacathon/backend/internal/secrets"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned for an unknown workspace ID
+var ErrNotFound = errors.New("workspace not found")
+
+// Store keeps workspaces and their encrypted credentials, and resolves the
+// configuration a project's services are built with
+type Store struct {
+	db       *gorm.DB
+	config   *config.Config
+	envelope *secrets.Envelope // nil when WORKSPACE_ENCRYPTION_KEY is not set
+}
+
+// NewStore opens the workspace store. Without WORKSPACE_ENCRYPTION_KEY workspaces
+// still work, but cannot hold credentials of their own.
+func NewStore(db *gorm.DB, cfg *config.Config) (*Store, error) {
+	store := &Store{db: db, config: cfg}
+	if cfg.WorkspaceEncryptionKey != "" {
+		envelope, err := secrets.NewEnvelope(cfg.WorkspaceEncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WORKSPACE_ENCRYPTION_KEY: %v", err)
+		}
+		store.envelope = envelope
+	}
+	return store, nil
+}
+
+// Get loads a workspace by ID
+func (s *Store) Get(id string) (*models.Workspace, error) {
+	var workspace models.Workspace
+	if err := s.db.First(&workspace, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// SetCredentials encrypts and stores the workspace's credentials, replacing any
+// previous ones. They are bound to the workspace and do not decrypt if copied to another.
+func (s *Store) SetCredentials(workspace *models.Workspace, creds config.Credentials) error {
+	if err := creds.Validate(); err != nil {
+		return err
+	}
+	if len(creds.Names()) == 0 {
+		workspace.Credentials = ""
+		return nil
+	}
+	if s.envelope == nil {
+		return fmt.Errorf("workspace credentials need WORKSPACE_ENCRYPTION_KEY to be configured")
+	}
+
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	// The record is sealed to the workspace's ID, so a new workspace gets its ID now
+	if workspace.ID == "" {
+		workspace.ID = uuid.New().String()
+	}
+	sealed, err := s.envelope.Seal(plaintext, []byte(workspace.ID))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt workspace credentials: %v", err)
+	}
+	workspace.Credentials = sealed
+	return nil
+}
+
+// Credentials decrypts the workspace's credentials
+func (s *Store) Credentials(workspace *models.Workspace) (config.Credentials, error) {
+	var creds config.Credentials
+	if workspace.Credentials == "" {
+		return creds, nil
+	}
+	if s.envelope == nil {
+		return creds, fmt.Errorf("workspace %s has encrypted credentials but WORKSPACE_ENCRYPTION_KEY is not configured", workspace.ID)
+	}
+
+	plaintext, err := s.envelope.Open(workspace.Credentials, []byte(workspace.ID))
+	if err != nil {
+		return creds, fmt.Errorf("workspace %s: %v", workspace.ID, err)
+	}
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return creds, fmt.Errorf("workspace %s: invalid credentials: %v", workspace.ID, err)
+	}
+	return creds, nil
+}
+
+// Config returns the configuration for a workspace: the server's configuration with
+// every credential the workspace has set replacing the server's own, and the rest
+// empty unless WORKSPACE_CREDENTIAL_FALLBACK lets the workspace use them. An empty ID
+// (projects created outside any workspace) gets the server's configuration.
+func (s *Store) Config(workspaceID string) (*config.Config, error) {
+	if workspaceID == "" {
+		return s.config, nil
+	}
+
+	workspace, err := s.Get(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := s.Credentials(workspace)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := s.config.WithCredentials(creds)
+	if err != nil {
+		return nil, fmt.Errorf("workspace %s: %v", workspaceID, err)
+	}
+	return cfg, nil
+}
+
+// BrandKit loads a workspace's brand kit; it is nil for projects outside any
+// workspace and for workspaces that have not set one up
+func (s *Store) BrandKit(workspaceID string) (*models.BrandKit, error) {
+	if workspaceID == "" {
+		return nil, nil
+	}
+	var kit models.BrandKit
+	if err := s.db.First(&kit, "workspace_id = ?", workspaceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &kit, nil
+}
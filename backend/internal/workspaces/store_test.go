@@ -0,0 +1,61 @@
+package workspaces
+
+import (
+	"bytes"
+	"encoding/base64"
+	"path/filepath"
+	"testing"
+
+	"github.com/dealshare/hacathon/backend/internal/config"
+	"github.com/dealshare/hacathon/backend/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "workspaces.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Workspace{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+	store, err := NewStore(db, &config.Config{WorkspaceEncryptionKey: key})
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	return store
+}
+
+func TestCredentialsSealedToWorkspace(t *testing.T) {
+	store := newTestStore(t)
+	creds := config.Credentials{ShotstackAPIKey: "brand-shotstack"}
+
+	// A new workspace has no ID until it is saved; its credentials are sealed to the one it gets
+	brand := &models.Workspace{Name: "Brand"}
+	if err := store.SetCredentials(brand, creds); err != nil {
+		t.Fatalf("SetCredentials() error = %v", err)
+	}
+	if brand.ID == "" {
+		t.Fatal("SetCredentials() left the new workspace without an ID")
+	}
+	store.db.Create(brand)
+
+	saved, err := store.Get(brand.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got, err := store.Credentials(saved); err != nil || got != creds {
+		t.Errorf("Credentials() = %+v, %v, want %+v", got, err, creds)
+	}
+
+	// Copying the record into another workspace's row does not hand it the keys
+	other := &models.Workspace{Name: "Other", Credentials: saved.Credentials}
+	store.db.Create(other)
+	if got, err := store.Credentials(other); err == nil {
+		t.Errorf("Credentials() of a copied record = %+v, want an error", got)
+	}
+}
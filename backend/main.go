@@ -2,12 +2,17 @@ package main
 
 import (
 	"log"
-	"os"
+	"net/url"
+	"strings"
 
 	"github.com/dealshare/hacathon/backend/internal/config"
 	"github.com/dealshare/hacathon/backend/internal/database"
 	"github.com/dealshare/hacathon/backend/internal/handlers"
+	"github.com/dealshare/hacathon/backend/internal/layouts"
+	"github.com/dealshare/hacathon/backend/internal/media"
+	"github.com/dealshare/hacathon/backend/internal/music"
 	"github.com/dealshare/hacathon/backend/internal/router"
+	"github.com/dealshare/hacathon/backend/internal/services"
 	"github.com/joho/godotenv"
 )
 
@@ -18,30 +23,36 @@ func main() {
 	}
 
 	// Load configuration
-	cfg := config.Load()
-
-	// Check for Gemini API key
-	geminiKey := os.Getenv("GOOGLE_GEMINI_API_KEY")
-	if geminiKey == "" {
-		geminiKey = os.Getenv("GEMINI_API_KEY")
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
 	// Log configuration
 	log.Printf("=== Configuration ===")
 	log.Printf("AI Provider: %s", cfg.AIProvider)
-	log.Printf("AI API Key: %s", func() string {
-		if cfg.AIAPIKey != "" {
-			return "***" + cfg.AIAPIKey[len(cfg.AIAPIKey)-10:]
+	log.Printf("Secret sources: %s", cfg.SecretSources)
+	log.Printf("Storage: %s, media URLs at %s", cfg.StorageBackend, cfg.PublicBaseURL)
+	if u, err := url.Parse(cfg.PublicBaseURL); err == nil && (u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1") {
+		log.Printf("⚠️  PUBLIC_BASE_URL is %s; vendors that fetch media by URL (D-ID, Shotstack, Instagram) cannot reach it", cfg.PublicBaseURL)
+	}
+	logProviders(cfg)
+	if cfg.AllowVideoUploads {
+		if err := media.RequireProbe(); err != nil {
+			log.Fatalf("Video uploads cannot be validated: %v (install ffmpeg, or set ALLOW_VIDEO_UPLOADS=false)", err)
 		}
-		return "(not set)"
-	}())
-	
-	// Log Gemini status
-	if geminiKey != "" {
-		log.Printf("🤖 Google Gemini: ENABLED (%s...%s)", geminiKey[:8], geminiKey[len(geminiKey)-4:])
+	}
+	if err := layouts.LoadDir(cfg.LayoutsDir); err != nil {
+		log.Fatalf("Failed to load layouts from %s: %v", cfg.LayoutsDir, err)
+	}
+	log.Printf("Layouts: %s", strings.Join(layouts.Names(), ", "))
+	if err := music.LoadDir(cfg.MusicDir); err != nil {
+		log.Fatalf("Failed to load music from %s: %v", cfg.MusicDir, err)
+	}
+	if moods := music.Moods(); len(moods) > 0 {
+		log.Printf("Music: %d tracks (%s)", len(music.Tracks()), strings.Join(moods, ", "))
 	} else {
-		log.Printf("⚠️  Google Gemini: DISABLED (no API key found)")
-		log.Printf("   Set GOOGLE_GEMINI_API_KEY environment variable to enable AI script generation")
+		log.Printf("Music: none (add tracks and %s to %s)", music.ManifestFile, cfg.MusicDir)
 	}
 	log.Printf("====================")
 
@@ -57,20 +68,36 @@ func main() {
 	}
 
 	// Initialize handlers
-	h := handlers.New(db, cfg)
+	h, err := handlers.New(db, cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize handlers: %v", err)
+	}
 
 	// Setup router
-	r := router.Setup(h)
+	r := router.Setup(h, cfg.CORSOrigins)
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	log.Printf("Server starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
+	log.Printf("Server starting on port %s", cfg.Port)
+	if err := r.Run(":" + cfg.Port); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
 
+// logProviders reports which providers the configured credentials and tools allow,
+// and warns when one of the default providers cannot run
+func logProviders(cfg *config.Config) {
+	for _, status := range services.CheckProviders(cfg) {
+		marker := "  "
+		if status.Selected {
+			marker = "▶ "
+		}
+		if status.Usable {
+			log.Printf("%s✅ %s: %s", marker, status.Role, status.Name)
+			continue
+		}
+		log.Printf("%s❌ %s: %s (%s)", marker, status.Role, status.Name, status.Reason)
+		if status.Selected {
+			log.Printf("⚠️  The default %s provider %q is unusable; jobs using it will fail", status.Role, status.Name)
+		}
+	}
+}
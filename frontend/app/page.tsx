@@ -1,11 +1,26 @@
 'use client'
 
-import { useState, useCallback } from 'react'
+import { useState, useCallback, useEffect } from 'react'
 import { useDropzone } from 'react-dropzone'
 import axios from 'axios'
+import {
+  login,
+  register,
+  setAuthToken,
+  getScriptVersions,
+  editScript,
+  submitScript,
+  reviewScript,
+  Script,
+} from '@/lib/api'
 
 const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080'
 
+// Websites are stored under keys like "websites/<id>", served at /static/generated/websites/<id>;
+// older projects stored the directory path ("generated/websites/<id>")
+const websiteURL = (websitePath = '') =>
+  `${API_URL}/static/${websitePath.startsWith('websites/') ? `generated/${websitePath}` : websitePath}/index.html`
+
 interface Project {
   id: string
   status: string
@@ -14,6 +29,7 @@ interface Project {
   product_category: string
   product_price: string
   generated_script: string
+  active_script_id?: string
   product_image_path: string
   person_media_path: string
   generated_video_path?: string
@@ -23,6 +39,9 @@ interface Project {
 }
 
 export default function Home() {
+  const [authToken, setAuthTokenState] = useState<string | null>(null)
+  const [authEmail, setAuthEmail] = useState<string>('')
+  const [authPassword, setAuthPassword] = useState<string>('')
   const [productImage, setProductImage] = useState<File | null>(null)
   const [personMedia, setPersonMedia] = useState<File | null>(null)
   const [productName, setProductName] = useState<string>('')
@@ -32,6 +51,8 @@ export default function Home() {
   const [productVideoStyle, setProductVideoStyle] = useState<string>('cinematic')
   const [layout, setLayout] = useState<string>('product_main')
   const [project, setProject] = useState<Project | null>(null)
+  const [script, setScript] = useState<Script | null>(null) // The active script and its review status
+  const [scriptDraft, setScriptDraft] = useState<string | null>(null) // Text being edited
   const [loading, setLoading] = useState(false)
   const [error, setError] = useState<string | null>(null)
   const [activeStep, setActiveStep] = useState(1)
@@ -111,6 +132,8 @@ export default function Home() {
 
       setProject(response.data)
       setActiveStep(2)
+      const versions = await getScriptVersions(response.data.project_id, response.data.active_script_id)
+      setScript(versions.find((version) => version.id === response.data.active_script_id) || null)
     } catch (err: any) {
       setError(err.response?.data?.error || 'Failed to upload files')
     } finally {
@@ -118,6 +141,28 @@ export default function Home() {
     }
   }
 
+  // Scripts are reviewed before rendering; an edit saves a new draft version that becomes active
+  const handleScriptAction = async (action: (projectId: string, scriptId: string) => Promise<Script>) => {
+    if (!project || !script) return
+
+    setError(null)
+    try {
+      const updated = await action(project.project_id || project.id, script.id)
+      setScript(updated)
+      setScriptDraft(null)
+      setProject({ ...project, generated_script: updated.text, active_script_id: updated.id })
+    } catch (err: any) {
+      setError(err.response?.data?.error || 'Failed to update the script')
+    }
+  }
+
+  const handleRejectScript = () => {
+    const comment = window.prompt('What should change in the script?')
+    if (comment) {
+      handleScriptAction((projectId, scriptId) => reviewScript(projectId, scriptId, 'reject', comment))
+    }
+  }
+
   const handleGenerateVideo = async () => {
     if (!project) return
 
@@ -132,7 +177,21 @@ export default function Home() {
           layout: layout
         }
       )
-      setProject({ ...project, ...response.data })
+      setProject({ ...project, status: response.data.status })
+
+      // Generation runs in the background - poll the job until it finishes
+      const job = await waitForJob(response.data.job_id)
+      if (job.status === 'failed') {
+        throw { response: { data: { error: job.error || 'Video generation failed' } } }
+      }
+      if (job.status === 'cancelled') {
+        throw { response: { data: { error: 'Video generation was cancelled' } } }
+      }
+      setProject({
+        ...project,
+        generated_video_path: job.result_path,
+        status: 'video_complete',
+      })
       setActiveStep(3)
     } catch (err: any) {
       setError(err.response?.data?.error || 'Failed to generate video')
@@ -141,6 +200,16 @@ export default function Home() {
     }
   }
 
+  const waitForJob = async (jobId: string): Promise<any> => {
+    while (true) {
+      await new Promise((resolve) => setTimeout(resolve, 3000))
+      const response = await axios.get(`${API_URL}/api/v1/jobs/${jobId}`)
+      if (['completed', 'failed', 'cancelled'].includes(response.data.status)) {
+        return response.data
+      }
+    }
+  }
+
   const handleGenerateWebsite = async () => {
     if (!project) return
 
@@ -195,6 +264,42 @@ export default function Home() {
     }
   }
 
+  // Restore the session, and drop it once the API rejects it
+  useEffect(() => {
+    const stored = localStorage.getItem('authToken')
+    if (stored) {
+      setAuthToken(stored)
+      setAuthTokenState(stored)
+    }
+    const interceptor = axios.interceptors.response.use(undefined, (err) => {
+      if (err.response?.status === 401) {
+        localStorage.removeItem('authToken')
+        setAuthToken(null)
+        setAuthTokenState(null)
+      }
+      return Promise.reject(err)
+    })
+    return () => axios.interceptors.response.eject(interceptor)
+  }, [])
+
+  const handleSignIn = async (firstAccount: boolean) => {
+    setLoading(true)
+    setError(null)
+
+    try {
+      const session = firstAccount
+        ? await register(authEmail, authPassword)
+        : await login(authEmail, authPassword)
+      localStorage.setItem('authToken', session.token)
+      setAuthToken(session.token)
+      setAuthTokenState(session.token)
+    } catch (err: any) {
+      setError(err.response?.data?.error || 'Failed to sign in')
+    } finally {
+      setLoading(false)
+    }
+  }
+
   const resetForm = () => {
     setProductImage(null)
     setPersonMedia(null)
@@ -205,10 +310,53 @@ export default function Home() {
     setProductImagePreview(null)
     setPersonMediaPreview(null)
     setProject(null)
+    setScript(null)
+    setScriptDraft(null)
     setActiveStep(1)
     setError(null)
   }
 
+  if (!authToken) {
+    return (
+      <main className="min-h-screen bg-gradient-to-br from-purple-50 via-white to-blue-50 flex items-center justify-center">
+        <div className="bg-white rounded-2xl shadow-xl p-8 w-full max-w-md space-y-4">
+          <h1 className="text-2xl font-extrabold bg-gradient-to-r from-purple-600 to-blue-600 bg-clip-text text-transparent">
+            Sign in to AI Product Marketing Studio
+          </h1>
+          <input
+            type="email"
+            value={authEmail}
+            onChange={(e) => setAuthEmail(e.target.value)}
+            placeholder="Email"
+            className="w-full px-4 py-3 border border-gray-300 rounded-lg"
+          />
+          <input
+            type="password"
+            value={authPassword}
+            onChange={(e) => setAuthPassword(e.target.value)}
+            placeholder="Password"
+            className="w-full px-4 py-3 border border-gray-300 rounded-lg"
+          />
+          {error && <p className="text-sm text-red-600">{error}</p>}
+          <button
+            onClick={() => handleSignIn(false)}
+            disabled={loading}
+            className="w-full px-6 py-3 bg-gradient-to-r from-purple-600 to-blue-600 text-white rounded-lg font-semibold disabled:opacity-50"
+          >
+            Sign In
+          </button>
+          <button
+            onClick={() => handleSignIn(true)}
+            disabled={loading}
+            className="w-full text-sm text-gray-600 hover:text-purple-600"
+          >
+            First time here? Create the admin account
+          </button>
+        </div>
+      </main>
+    )
+  }
+
   return (
     <main className="min-h-screen bg-gradient-to-br from-purple-50 via-white to-blue-50">
       {/* Header */}
@@ -521,14 +669,78 @@ export default function Home() {
                   <span className="mr-2">✨</span>
                   AI-Generated Script (15 seconds)
                 </h3>
-                <p className="text-green-800 leading-relaxed italic">"{project.generated_script}"</p>
+                {scriptDraft !== null ? (
+                  <textarea
+                    value={scriptDraft}
+                    onChange={(e) => setScriptDraft(e.target.value)}
+                    rows={4}
+                    className="w-full px-4 py-3 border-2 border-green-200 rounded-xl focus:border-green-500 focus:outline-none"
+                  />
+                ) : (
+                  <p className="text-green-800 leading-relaxed italic">"{project.generated_script}"</p>
+                )}
+                {script && (
+                  <div className="mt-4 space-y-3">
+                    <p className="text-sm text-green-900">
+                      Version {script.version} · <span className="font-semibold">{script.status.replace('_', ' ')}</span>
+                      {script.review_comment && <span className="block text-gray-600 mt-1">Reviewer: {script.review_comment}</span>}
+                    </p>
+                    {script.findings && script.findings.length > 0 && (
+                      <ul className="text-sm space-y-1">
+                        {script.findings.map((finding, i) => (
+                          <li key={i} className={finding.severity === 'hard' ? 'text-red-700' : 'text-yellow-700'}>
+                            <span className="font-semibold">{finding.severity === 'hard' ? 'Blocks approval' : 'Check'}:</span>{' '}
+                            {finding.excerpt && <>&ldquo;{finding.excerpt}&rdquo; &ndash; </>}{finding.message}
+                          </li>
+                        ))}
+                      </ul>
+                    )}
+                    <div className="flex flex-wrap gap-2">
+                      {scriptDraft !== null ? (
+                        <>
+                          <button
+                            onClick={() => handleScriptAction((projectId, scriptId) => editScript(projectId, scriptId, scriptDraft))}
+                            className="px-4 py-2 bg-green-600 text-white rounded-lg font-semibold hover:bg-green-700"
+                          >
+                            Save as new version
+                          </button>
+                          <button onClick={() => setScriptDraft(null)} className="px-4 py-2 bg-gray-200 rounded-lg font-semibold">
+                            Cancel
+                          </button>
+                        </>
+                      ) : (
+                        <button onClick={() => setScriptDraft(script.text)} className="px-4 py-2 bg-white border-2 border-green-300 rounded-lg font-semibold">
+                          ✏️ Edit
+                        </button>
+                      )}
+                      {script.status === 'draft' && scriptDraft === null && (
+                        <button onClick={() => handleScriptAction(submitScript)} className="px-4 py-2 bg-blue-600 text-white rounded-lg font-semibold hover:bg-blue-700">
+                          Submit for review
+                        </button>
+                      )}
+                      {script.status === 'in_review' && (
+                        <>
+                          <button
+                            onClick={() => handleScriptAction((projectId, scriptId) => reviewScript(projectId, scriptId, 'approve'))}
+                            className="px-4 py-2 bg-green-600 text-white rounded-lg font-semibold hover:bg-green-700"
+                          >
+                            ✅ Approve
+                          </button>
+                          <button onClick={handleRejectScript} className="px-4 py-2 bg-red-600 text-white rounded-lg font-semibold hover:bg-red-700">
+                            Reject
+                          </button>
+                        </>
+                      )}
+                    </div>
+                  </div>
+                )}
               </div>
             )}
 
                 {project.status === 'uploaded' && (
                   <button
                     onClick={handleGenerateVideo}
-                    disabled={loading}
+                    disabled={loading || script?.status !== 'approved'}
                 className="w-full bg-gradient-to-r from-green-600 to-emerald-600 text-white py-4 px-8 rounded-xl font-bold text-lg hover:from-green-700 hover:to-emerald-700 disabled:from-gray-400 disabled:to-gray-400 disabled:cursor-not-allowed transition-all shadow-lg hover:shadow-xl transform hover:scale-105"
               >
                 {loading ? (
@@ -726,7 +938,7 @@ export default function Home() {
                 </div>
               </div>
               <a
-                href={`${API_URL}/static/${project.website_path}/index.html`}
+                href={websiteURL(project.website_path)}
                 target="_blank"
                 rel="noopener noreferrer"
                 className="px-6 py-3 bg-gradient-to-r from-blue-600 to-indigo-600 text-white rounded-xl font-bold hover:from-blue-700 hover:to-indigo-700 transition-all shadow-lg hover:shadow-xl transform hover:scale-105 flex items-center space-x-2"
@@ -746,11 +958,11 @@ export default function Home() {
                     <div className="w-3 h-3 rounded-full bg-green-500"></div>
                   </div>
                   <div className="flex-1 bg-gray-700 rounded px-3 py-1 text-xs text-gray-300 font-mono">
-                    {API_URL}/static/{project.website_path}/index.html
+                    {websiteURL(project.website_path)}
                   </div>
                 </div>
                 <iframe
-                  src={`${API_URL}/static/${project.website_path}/index.html`}
+                  src={websiteURL(project.website_path)}
                   className="w-full"
                   style={{ height: '600px', border: 'none' }}
                   title="Website Preview"
@@ -767,7 +979,7 @@ export default function Home() {
                 </div>
                 <button
                   onClick={() => {
-                    const url = `${API_URL}/static/${project.website_path}/index.html`
+                    const url = websiteURL(project.website_path)
                     navigator.clipboard.writeText(url)
                     alert('Website URL copied to clipboard!')
                   }}
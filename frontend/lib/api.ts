@@ -4,9 +4,12 @@ const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080'
 
 export interface Project {
   id: string
+  workspace_id?: string
   product_image_path: string
   person_media_path: string
   person_media_type: string
+  generated_script?: string
+  active_script_id?: string // Videos are generated from this script once it is approved
   generated_video_path?: string
   website_path?: string
   website_url?: string
@@ -28,6 +31,34 @@ export interface GenerateResponse {
   status: string
 }
 
+export interface VideoJobResponse {
+  project_id: string
+  job_id: string
+  status: string
+  status_url: string
+}
+
+export interface Job {
+  id: string
+  project_id: string
+  type: string
+  status: 'queued' | 'running' | 'completed' | 'failed' | 'cancelled'
+  stage: string
+  progress: number
+  error?: string
+  script_id?: string // The approved script the avatar clip speaks
+  script_version?: number
+  avatar_video_path?: string
+  product_video_path?: string
+  avatar_task_id?: string
+  product_task_id?: string
+  composite_task_id?: string
+  attempts: number
+  result_path?: string
+  created_at: string
+  updated_at: string
+}
+
 const api = axios.create({
   baseURL: `${API_URL}/api/v1`,
   headers: {
@@ -35,13 +66,53 @@ const api = axios.create({
   },
 })
 
+// Session token sent with every request, including plain axios calls
+export const setAuthToken = (token: string | null) => {
+  for (const instance of [axios, api]) {
+    if (token) {
+      instance.defaults.headers.common['Authorization'] = `Bearer ${token}`
+    } else {
+      delete instance.defaults.headers.common['Authorization']
+    }
+  }
+}
+
+export interface User {
+  id: string
+  email: string
+  name: string
+  role: 'viewer' | 'editor' | 'reviewer' | 'publisher'
+  workspace_id?: string
+}
+
+export interface Session {
+  token: string
+  expires_at: string
+  user: User
+}
+
+export const login = async (email: string, password: string): Promise<Session> => {
+  const response = await api.post<Session>('/auth/login', { email, password })
+  return response.data
+}
+
+// Only succeeds for the very first account, which becomes an agency-level publisher
+export const register = async (email: string, password: string, name?: string): Promise<Session> => {
+  const response = await api.post<Session>('/auth/register', { email, password, name })
+  return response.data
+}
+
 export const uploadMedia = async (
   productImage: File,
-  personMedia: File
+  personMedia: File,
+  workspaceId?: string
 ): Promise<UploadResponse> => {
   const formData = new FormData()
   formData.append('product_image', productImage)
   formData.append('person_media', personMedia)
+  if (workspaceId) {
+    formData.append('workspace_id', workspaceId)
+  }
 
   const response = await api.post<UploadResponse>('/upload', formData, {
     headers: {
@@ -52,23 +123,157 @@ export const uploadMedia = async (
   return response.data
 }
 
+export interface ProviderSelection {
+  avatar?: string
+  product_video?: string
+  compositor?: string
+  script_writer?: string
+  media_host?: string
+}
+
+// The video's script is the project's active one, which must be approved first
 export interface VideoGenerationOptions {
-  script?: string
   product_video_style?: 'rotation' | 'zoom' | 'pan' | 'reveal' | 'auto'
   layout?: 'product_main' | 'avatar_main'
+  providers?: ProviderSelection
 }
 
 export const generateVideo = async (
   projectId: string, 
   options?: VideoGenerationOptions
-): Promise<GenerateResponse> => {
-  const response = await api.post<GenerateResponse>(
+): Promise<VideoJobResponse> => {
+  const response = await api.post<VideoJobResponse>(
     `/projects/${projectId}/generate-video`,
     options
   )
   return response.data
 }
 
+// Scripts: variants are generated, edited into new versions, reviewed, and one is active
+export type ScriptStatus = 'draft' | 'in_review' | 'approved' | 'rejected'
+
+export interface Script {
+  id: string
+  project_id: string
+  root_id: string
+  previous_id?: string
+  version: number
+  text: string
+  tone: 'professional' | 'emotional' | 'benefits' | 'hype'
+  duration_seconds: 6 | 15 | 30 | 60
+  audience?: string
+  language: string
+  provider?: string
+  author_id?: string
+  status: ScriptStatus
+  reviewer_id?: string
+  review_comment?: string
+  approved_by?: string
+  findings: ComplianceFinding[] | null
+  submitted_at?: string
+  reviewed_at?: string
+  created_at: string
+  updated_at: string
+}
+
+// A problem found in generated copy; hard findings block approval and publishing
+export interface ComplianceFinding {
+  source: string // 'script', 'caption' or 'website_feature_<n>'
+  kind: 'banned_phrase' | 'unverifiable_claim' | 'price_mismatch' | 'missing_disclaimer'
+  severity: 'hard' | 'warning'
+  rule: string
+  excerpt?: string
+  message: string
+}
+
+export interface ComplianceReport {
+  project_id: string
+  active_script_id: string
+  pack: string
+  blocked: boolean
+  findings: ComplianceFinding[]
+}
+
+export interface ScriptOptions {
+  tone?: Script['tone']
+  duration?: Script['duration_seconds']
+  audience?: string
+  language?: string
+  variants?: number
+}
+
+export const getScripts = async (projectId: string): Promise<{ active_script_id: string; scripts: Script[] }> => {
+  const response = await api.get(`/projects/${projectId}/scripts`)
+  return response.data
+}
+
+export const generateScripts = async (projectId: string, options?: ScriptOptions): Promise<Script[]> => {
+  const response = await api.post<{ scripts: Script[] }>(`/projects/${projectId}/scripts`, options)
+  return response.data.scripts
+}
+
+export const setActiveScript = async (projectId: string, scriptId: string): Promise<Script> => {
+  const response = await api.put<{ script: Script }>(`/projects/${projectId}/script`, { script_id: scriptId })
+  return response.data.script
+}
+
+export const getScriptVersions = async (projectId: string, scriptId: string): Promise<Script[]> => {
+  const response = await api.get<{ versions: Script[] }>(`/projects/${projectId}/scripts/${scriptId}/versions`)
+  return response.data.versions
+}
+
+// Checks the active script and the Instagram caption (the generated one unless given)
+export const getCompliance = async (projectId: string, caption?: string): Promise<ComplianceReport> => {
+  const response = await api.get<ComplianceReport>(`/projects/${projectId}/compliance`, { params: caption ? { caption } : undefined })
+  return response.data
+}
+
+// Saves a new draft version; editing the active script makes the new version active
+export const editScript = async (projectId: string, scriptId: string, text: string): Promise<Script> => {
+  const response = await api.post<{ script: Script }>(`/projects/${projectId}/scripts/${scriptId}/versions`, { text })
+  return response.data.script
+}
+
+export const submitScript = async (projectId: string, scriptId: string): Promise<Script> => {
+  const response = await api.post<{ script: Script }>(`/projects/${projectId}/scripts/${scriptId}/submit`)
+  return response.data.script
+}
+
+export const withdrawScript = async (projectId: string, scriptId: string): Promise<Script> => {
+  const response = await api.post<{ script: Script }>(`/projects/${projectId}/scripts/${scriptId}/withdraw`)
+  return response.data.script
+}
+
+// Reviewers only; rejecting needs a comment
+export const reviewScript = async (
+  projectId: string,
+  scriptId: string,
+  decision: 'approve' | 'reject',
+  comment?: string
+): Promise<Script> => {
+  const response = await api.post<{ script: Script }>(`/projects/${projectId}/scripts/${scriptId}/review`, { decision, comment })
+  return response.data.script
+}
+
+export const getJob = async (jobId: string): Promise<Job> => {
+  const response = await api.get<Job>(`/jobs/${jobId}`)
+  return response.data
+}
+
+// Opens the project's progress stream. EventSource cannot send the Authorization
+// header, so it authenticates with a one-minute ticket for this stream only.
+export const openProjectEvents = async (projectId: string): Promise<EventSource> => {
+  const response = await api.post<{ events_url: string }>(`/projects/${projectId}/events/ticket`)
+  return new EventSource(`${API_URL}${response.data.events_url}`)
+}
+
+export const cancelGeneration = async (
+  projectId: string
+): Promise<{ project_id: string; job_id: string; status: 'cancelling' | 'cancelled' }> => {
+  const response = await api.post(`/projects/${projectId}/cancel`)
+  return response.data
+}
+
 export const generateWebsite = async (projectId: string): Promise<GenerateResponse> => {
   const response = await api.post<GenerateResponse>(
     `/projects/${projectId}/generate-website`
@@ -76,8 +281,10 @@ export const generateWebsite = async (projectId: string): Promise<GenerateRespon
   return response.data
 }
 
-export const getProjects = async (): Promise<Project[]> => {
-  const response = await api.get<{ projects: Project[] }>('/projects')
+export const getProjects = async (workspaceId?: string): Promise<Project[]> => {
+  const response = await api.get<{ projects: Project[] }>('/projects', {
+    params: workspaceId ? { workspace_id: workspaceId } : undefined,
+  })
   return response.data.projects
 }
 
@@ -87,3 +294,44 @@ export const getProject = async (projectId: string): Promise<Project> => {
 }
 
 
+
+// Workspaces hold each brand's vendor accounts; credential values are write-only
+export interface WorkspaceCredentials {
+  did_api_key?: string
+  ai_api_key?: string
+  runwayml_api_key?: string
+  shotstack_api_key?: string
+  gemini_api_key?: string
+  instagram_access_token?: string
+  instagram_user_id?: string
+}
+
+export interface Workspace {
+  id: string
+  name: string
+  credentials?: (keyof WorkspaceCredentials)[] // Names of the credentials that are set
+  credentials_error?: string
+  created_at: string
+  updated_at: string
+}
+
+export const getWorkspaces = async (): Promise<Workspace[]> => {
+  const response = await api.get<{ workspaces: Workspace[] }>('/workspaces')
+  return response.data.workspaces
+}
+
+export const createWorkspace = async (
+  name: string,
+  credentials?: WorkspaceCredentials
+): Promise<Workspace> => {
+  const response = await api.post<Workspace>('/workspaces', { name, credentials })
+  return response.data
+}
+
+export const updateWorkspaceCredentials = async (
+  workspaceId: string,
+  credentials: WorkspaceCredentials
+): Promise<Workspace> => {
+  const response = await api.put<Workspace>(`/workspaces/${workspaceId}/credentials`, credentials)
+  return response.data
+}